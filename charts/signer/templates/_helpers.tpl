@@ -50,6 +50,18 @@ app.kubernetes.io/name: {{ include "signer.name" . }}
 app.kubernetes.io/instance: {{ .Release.Name }}
 {{- end }}
 
+{{/*
+Container image. PKCS#11 needs the cgo build, published with a -pkcs11 tag suffix;
+the default static image only has a stub that refuses to start.
+*/}}
+{{- define "signer.image" -}}
+{{- $tag := .Values.image.tag | default .Chart.AppVersion }}
+{{- if and (eq .Values.env.signingBackend "pkcs11") (not (hasSuffix "-pkcs11" $tag)) }}
+{{- $tag = printf "%s-pkcs11" $tag }}
+{{- end }}
+{{- printf "%s:%s" .Values.image.repository $tag }}
+{{- end }}
+
 {{/*
 Create the name of the service account to use
 */}}
@@ -60,3 +72,14 @@ Create the name of the service account to use
 {{- default "default" .Values.serviceAccount.name }}
 {{- end }}
 {{- end }}
+
+{{/*
+Name and namespace of the CA Secret
+*/}}
+{{- define "signer.caSecretName" -}}
+{{- if .Values.env.caSecretName }}{{ .Values.env.caSecretName }}{{ else }}{{ include "signer.fullname" . }}-ca{{ end }}
+{{- end }}
+
+{{- define "signer.caSecretNamespace" -}}
+{{- if .Values.env.caSecretNamespace }}{{ .Values.env.caSecretNamespace }}{{ else }}{{ .Release.Namespace }}{{ end }}
+{{- end }}
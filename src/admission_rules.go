@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// admissionRuleCostLimit bounds the runtime cost of a single rule evaluation
+const admissionRuleCostLimit = 1000000
+
+// AdmissionRule is a CEL expression that must evaluate to true for a
+// PodCertificateRequest to be signed. Expressions can reference:
+//   - request: the PCR spec plus name, namespace, keyAlgorithm and keySize
+//   - pod: the requesting Pod object
+//   - namespaceObject: name, labels and annotations of the PCR's namespace
+//     ("namespace" is a reserved word in CEL)
+type AdmissionRule struct {
+	Expression string `json:"expression"`
+	Message    string `json:"message,omitempty"`
+}
+
+// AdmissionRules is a compiled, ordered list of AdmissionRule
+type AdmissionRules struct {
+	rules []compiledAdmissionRule
+}
+
+type compiledAdmissionRule struct {
+	AdmissionRule
+	program cel.Program
+}
+
+// admissionInput holds the objects a rule is evaluated against
+type admissionInput struct {
+	PCR          *certificatesv1beta1.PodCertificateRequest
+	Pod          *corev1.Pod
+	Namespace    *corev1.Namespace
+	KeyAlgorithm string
+	KeySize      int32
+}
+
+// ParseAdmissionRules decodes a JSON list of rules, e.g.
+// [{"expression": "namespaceObject.labels['mesh'] == 'enabled'", "message": "mesh not enabled"}]
+func ParseAdmissionRules(raw string) ([]AdmissionRule, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var rules []AdmissionRule
+	if err := json.Unmarshal([]byte(raw), &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse admission rules: %w", err)
+	}
+	return rules, nil
+}
+
+// NewAdmissionRules compiles the rules, rejecting any that do not evaluate to a bool
+func NewAdmissionRules(rules []AdmissionRule) (*AdmissionRules, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("request", cel.DynType),
+		cel.Variable("pod", cel.DynType),
+		cel.Variable("namespaceObject", cel.DynType),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL environment: %w", err)
+	}
+
+	compiled := make([]compiledAdmissionRule, 0, len(rules))
+	for i, rule := range rules {
+		if rule.Expression == "" {
+			return nil, fmt.Errorf("admission rule %d has an empty expression", i)
+		}
+		ast, issues := env.Compile(rule.Expression)
+		if issues != nil && issues.Err() != nil {
+			return nil, fmt.Errorf("failed to compile admission rule %q: %w", rule.Expression, issues.Err())
+		}
+		if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+			return nil, fmt.Errorf("admission rule %q must evaluate to bool, got %s", rule.Expression, ast.OutputType())
+		}
+		program, err := env.Program(ast, cel.CostLimit(admissionRuleCostLimit))
+		if err != nil {
+			return nil, fmt.Errorf("failed to build admission rule %q: %w", rule.Expression, err)
+		}
+		if rule.Message == "" {
+			rule.Message = fmt.Sprintf("admission rule failed: %s", rule.Expression)
+		}
+		compiled = append(compiled, compiledAdmissionRule{AdmissionRule: rule, program: program})
+	}
+
+	return &AdmissionRules{rules: compiled}, nil
+}
+
+// Len returns the number of compiled rules
+func (a *AdmissionRules) Len() int {
+	if a == nil {
+		return 0
+	}
+	return len(a.rules)
+}
+
+// Evaluate runs the rules in order and returns the first one that does not
+// hold, or nil if the request is admitted. A rule that fails to evaluate, e.g.
+// by indexing a label the namespace does not have, does not hold either: it is
+// returned together with a *ruleEvaluationError describing why.
+func (a *AdmissionRules) Evaluate(input admissionInput) (*AdmissionRule, error) {
+	if a.Len() == 0 {
+		return nil, nil
+	}
+
+	vars, err := input.activation()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range a.rules {
+		rule := &a.rules[i]
+		out, _, err := rule.program.Eval(vars)
+		if err != nil {
+			return &rule.AdmissionRule, &ruleEvaluationError{Expression: rule.Expression, Err: err}
+		}
+		allowed, ok := out.Value().(bool)
+		if !ok {
+			return &rule.AdmissionRule, &ruleEvaluationError{Expression: rule.Expression, Err: fmt.Errorf("returned %T, expected bool", out.Value())}
+		}
+		if !allowed {
+			return &rule.AdmissionRule, nil
+		}
+	}
+	return nil, nil
+}
+
+// ruleEvaluationError reports a rule that failed to evaluate against a request.
+// It is deterministic for that request, so the request is denied like for a false rule.
+type ruleEvaluationError struct {
+	Expression string
+	Err        error
+}
+
+func (e *ruleEvaluationError) Error() string {
+	return fmt.Sprintf("failed to evaluate admission rule %q: %v", e.Expression, e.Err)
+}
+
+func (e *ruleEvaluationError) Unwrap() error {
+	return e.Err
+}
+
+func (in admissionInput) activation() (map[string]any, error) {
+	spec := in.PCR.Spec
+
+	var maxExpirationSeconds int64
+	if spec.MaxExpirationSeconds != nil {
+		maxExpirationSeconds = int64(*spec.MaxExpirationSeconds)
+	}
+	request := map[string]any{
+		"name":                      in.PCR.Name,
+		"namespace":                 in.PCR.Namespace,
+		"signerName":                spec.SignerName,
+		"podName":                   spec.PodName,
+		"podUID":                    string(spec.PodUID),
+		"serviceAccountName":        spec.ServiceAccountName,
+		"serviceAccountUID":         string(spec.ServiceAccountUID),
+		"nodeName":                  string(spec.NodeName),
+		"maxExpirationSeconds":      maxExpirationSeconds,
+		"unverifiedUserAnnotations": stringMapToAny(spec.UnverifiedUserAnnotations),
+		"keyAlgorithm":              in.KeyAlgorithm,
+		"keySize":                   int64(in.KeySize),
+	}
+
+	pod, err := runtime.DefaultUnstructuredConverter.ToUnstructured(in.Pod)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert pod for admission rules: %w", err)
+	}
+
+	namespace := map[string]any{
+		"name":        in.Namespace.Name,
+		"labels":      stringMapToAny(in.Namespace.Labels),
+		"annotations": stringMapToAny(in.Namespace.Annotations),
+	}
+
+	return map[string]any{
+		"request":         request,
+		"pod":             pod,
+		"namespaceObject": namespace,
+	}, nil
+}
+
+func stringMapToAny(m map[string]string) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
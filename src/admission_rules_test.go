@@ -0,0 +1,118 @@
+package main
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newAdmissionTestInput(nsLabels map[string]string, keyAlgorithm string, keySize int32) admissionInput {
+	return admissionInput{
+		PCR: &certificatesv1beta1.PodCertificateRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "pcr", Namespace: "shop"},
+			Spec: certificatesv1beta1.PodCertificateRequestSpec{
+				SignerName:         "novog93.ghcr/signer",
+				PodName:            "web-0",
+				ServiceAccountName: "web",
+			},
+		},
+		Pod: &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "web-0",
+				Namespace: "shop",
+				Labels:    map[string]string{"app": "web"},
+			},
+		},
+		Namespace:    &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop", Labels: nsLabels}},
+		KeyAlgorithm: keyAlgorithm,
+		KeySize:      keySize,
+	}
+}
+
+func TestParseAdmissionRules(t *testing.T) {
+	RegisterTestingT(t)
+
+	rules, err := ParseAdmissionRules("")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(rules).To(BeEmpty())
+
+	rules, err = ParseAdmissionRules(`[{"expression": "true", "message": "always"}]`)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(rules).To(Equal([]AdmissionRule{{Expression: "true", Message: "always"}}))
+
+	_, err = ParseAdmissionRules("not-json")
+	Expect(err).To(HaveOccurred())
+}
+
+func TestNewAdmissionRules_RejectsInvalidExpressions(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := NewAdmissionRules([]AdmissionRule{{Expression: "request.podName =="}})
+	Expect(err).To(MatchError(ContainSubstring("failed to compile")))
+
+	_, err = NewAdmissionRules([]AdmissionRule{{Expression: "'a string'"}})
+	Expect(err).To(MatchError(ContainSubstring("must evaluate to bool")))
+
+	_, err = NewAdmissionRules([]AdmissionRule{{Expression: ""}})
+	Expect(err).To(MatchError(ContainSubstring("empty expression")))
+}
+
+func TestAdmissionRules_NamespaceLabelRule(t *testing.T) {
+	RegisterTestingT(t)
+
+	rules, err := NewAdmissionRules([]AdmissionRule{{
+		Expression: "'mesh' in namespaceObject.labels && namespaceObject.labels['mesh'] == 'enabled'",
+		Message:    "namespace is not part of the mesh",
+	}})
+	Expect(err).NotTo(HaveOccurred())
+
+	failed, err := rules.Evaluate(newAdmissionTestInput(map[string]string{"mesh": "enabled"}, "ECDSA", 256))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(failed).To(BeNil())
+
+	failed, err = rules.Evaluate(newAdmissionTestInput(nil, "ECDSA", 256))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(failed).NotTo(BeNil())
+	Expect(failed.Message).To(Equal("namespace is not part of the mesh"))
+}
+
+func TestAdmissionRules_KeySizeAndPodRules(t *testing.T) {
+	RegisterTestingT(t)
+
+	rules, err := NewAdmissionRules([]AdmissionRule{
+		{Expression: "request.keyAlgorithm != 'RSA' || request.keySize >= 3072"},
+		{Expression: "pod.metadata.labels.app == request.serviceAccountName", Message: "pod label mismatch"},
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	failed, err := rules.Evaluate(newAdmissionTestInput(nil, "RSA", 2048))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(failed).NotTo(BeNil())
+	Expect(failed.Message).To(ContainSubstring("request.keySize >= 3072"))
+
+	failed, err = rules.Evaluate(newAdmissionTestInput(nil, "RSA", 4096))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(failed).To(BeNil())
+
+	input := newAdmissionTestInput(nil, "ECDSA", 256)
+	input.Pod.Labels["app"] = "other"
+	failed, err = rules.Evaluate(input)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(failed.Message).To(Equal("pod label mismatch"))
+}
+
+func TestAdmissionRules_EvaluationError(t *testing.T) {
+	RegisterTestingT(t)
+
+	rules, err := NewAdmissionRules([]AdmissionRule{{Expression: "namespaceObject.labels['missing'] == 'x'", Message: "missing label"}})
+	Expect(err).NotTo(HaveOccurred())
+
+	// The erroring rule does not hold and is returned with the error
+	failed, err := rules.Evaluate(newAdmissionTestInput(nil, "ECDSA", 256))
+	Expect(err).To(MatchError(ContainSubstring("failed to evaluate")))
+	Expect(failed).NotTo(BeNil())
+	Expect(failed.Message).To(Equal("missing label"))
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// caSecretGenerateRetryInterval is the delay between attempts to create the CA Secret
+const caSecretGenerateRetryInterval = 5 * time.Second
+
+// CASecretGenerator creates the CA Secret with a freshly generated CA when it
+// does not exist yet. It runs on the leader only; the CA secret watcher then
+// loads the new Secret, and other replicas load it when they take over.
+type CASecretGenerator struct {
+	Client   client.Client
+	Config   *Config
+	Recorder events.EventRecorder
+	// RetryInterval is the delay between failed attempts; defaults to caSecretGenerateRetryInterval
+	RetryInterval time.Duration
+}
+
+// Start creates the CA Secret, retrying until it exists or the context is cancelled
+func (g *CASecretGenerator) Start(ctx context.Context) error {
+	log := log.FromContext(ctx).WithName("ca-secret-generator")
+
+	interval := g.RetryInterval
+	if interval <= 0 {
+		interval = caSecretGenerateRetryInterval
+	}
+
+	for {
+		created, err := g.createIfAbsent(ctx)
+		if err == nil {
+			if created {
+				log.Info("Generated CA and created CA secret", "secret", g.Config.CASecretName, "namespace", g.Config.CASecretNamespace)
+			}
+			return nil
+		}
+		log.Error(err, "Failed to create CA secret, retrying", "interval", interval)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// NeedLeaderElection makes only the leader generate a CA, so replicas never race
+// with different CAs; Create fails if the Secret appeared in the meantime.
+func (g *CASecretGenerator) NeedLeaderElection() bool {
+	return true
+}
+
+// createIfAbsent generates a CA and creates the Secret, reporting false if it already exists
+func (g *CASecretGenerator) createIfAbsent(ctx context.Context) (bool, error) {
+	ca, err := newCAFunc(g.Config.CAKeyAlgorithm)
+	if err != nil {
+		return false, err
+	}
+	certPEM, keyPEM, err := ca.EncodePEM()
+	if err != nil {
+		return false, err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      g.Config.CASecretName,
+			Namespace: g.Config.CASecretNamespace,
+			Labels:    map[string]string{managedByLabel: managedByValue},
+		},
+		Data: map[string][]byte{
+			g.Config.CACertKey: certPEM,
+			g.Config.CAKeyKey:  keyPEM,
+		},
+	}
+	if err := g.Client.Create(ctx, secret); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to create CA secret %s/%s: %w", g.Config.CASecretNamespace, g.Config.CASecretName, err)
+	}
+
+	if g.Recorder != nil {
+		g.Recorder.Eventf(secret, nil, corev1.EventTypeNormal, "CAGenerated", eventActionGenerate,
+			"Generated %s CA %s", g.Config.CAKeyAlgorithm, ca.Cert.Subject)
+	}
+	return true, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newGeneratorTestClient(objs ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
+func TestCASecretGenerator_CreatesLoadableSecret(t *testing.T) {
+	RegisterTestingT(t)
+
+	c := newGeneratorTestClient()
+	recorder := &captureRecorder{}
+	g := &CASecretGenerator{
+		Client:   c,
+		Config:   &Config{CASecretName: "ca", CASecretNamespace: "signer", CACertKey: "ca.crt", CAKeyKey: "ca.key", CAKeyAlgorithm: CAKeyAlgorithmECDSAP256},
+		Recorder: recorder,
+	}
+	Expect(g.NeedLeaderElection()).To(BeTrue())
+	Expect(g.Start(context.Background())).To(Succeed())
+
+	ca, err := NewCAFromSecret(context.Background(), c, "ca", "signer", "ca.crt", "ca.key")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(ca.GetCert().IsCA).To(BeTrue())
+	Expect(ca.GetCert().PublicKey).To(Equal(ca.GetKey().Public()))
+	Expect(recorder.events).To(HaveLen(1))
+	Expect(recorder.events[0].reason).To(Equal("CAGenerated"))
+}
+
+func TestCASecretGenerator_KeepsExistingSecret(t *testing.T) {
+	RegisterTestingT(t)
+
+	existing := createFakeSecret("ca", "signer")
+	c := newGeneratorTestClient(existing)
+	g := &CASecretGenerator{
+		Client: c,
+		Config: &Config{CASecretName: "ca", CASecretNamespace: "signer", CACertKey: "ca.crt", CAKeyKey: "ca.key"},
+	}
+	Expect(g.Start(context.Background())).To(Succeed())
+
+	var secret corev1.Secret
+	Expect(c.Get(context.Background(), types.NamespacedName{Name: "ca", Namespace: "signer"}, &secret)).To(Succeed())
+	Expect(secret.Data).To(Equal(existing.Data))
+}
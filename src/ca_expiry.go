@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// CA auto-renewal modes
+const (
+	// CARenewSameKey re-issues the CA certificate for its current key
+	CARenewSameKey = "same-key"
+	// CARenewNewKey generates a new CA key; Secret-based CAs are staged as the next CA and rotated
+	CARenewNewKey = "new-key"
+)
+
+// caExpiryCheckInterval is how often the CA expiry is checked
+const caExpiryCheckInterval = time.Minute
+
+// CAExpiryMonitor exports the CA expiry, warns as it crosses the configured
+// thresholds and renews a self-managed CA before it expires. Self-managed CAs
+// are the in-memory CA and CA Secrets created by the signer (CA_SECRET_CREATE).
+type CAExpiryMonitor struct {
+	Client   client.Client
+	CA       *CAHelper
+	Config   *Config
+	Recorder events.EventRecorder
+	// Interval between checks; defaults to caExpiryCheckInterval
+	Interval time.Duration
+
+	// warnedSerial and warned remember the smallest threshold already warned about for a CA
+	warnedSerial string
+	warned       time.Duration
+	// skippedSerial is the CA already reported as not renewable
+	skippedSerial string
+}
+
+// Start checks the CA every Interval until the context is cancelled
+func (m *CAExpiryMonitor) Start(ctx context.Context) error {
+	log := log.FromContext(ctx).WithName("ca-expiry")
+
+	interval := m.Interval
+	if interval <= 0 {
+		interval = caExpiryCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.check(ctx, time.Now()); err != nil {
+			log.Error(err, "Failed to renew CA")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection renews the CA only from the leader
+func (m *CAExpiryMonitor) NeedLeaderElection() bool {
+	return true
+}
+
+// check updates the expiry gauge, emits threshold warnings and renews the CA when due
+func (m *CAExpiryMonitor) check(ctx context.Context, now time.Time) error {
+	cert := m.CA.GetCert()
+	if cert == nil {
+		return nil
+	}
+	CAExpiryTimestamp.Set(float64(cert.NotAfter.Unix()))
+
+	remaining := cert.NotAfter.Sub(now)
+	m.warn(ctx, cert, remaining)
+
+	if m.Config.CAAutoRenew == "" || remaining > m.Config.CARenewBefore {
+		return nil
+	}
+	return m.renew(ctx, cert, now)
+}
+
+// warn emits one warning per crossed threshold and CA certificate
+func (m *CAExpiryMonitor) warn(ctx context.Context, cert *x509.Certificate, remaining time.Duration) {
+	if serial := cert.SerialNumber.String(); serial != m.warnedSerial {
+		m.warnedSerial, m.warned = serial, 0
+	}
+
+	var crossed time.Duration
+	for _, threshold := range m.Config.CAExpiryWarnings {
+		if remaining <= threshold && (crossed == 0 || threshold < crossed) {
+			crossed = threshold
+		}
+	}
+	if crossed == 0 || (m.warned != 0 && m.warned <= crossed) {
+		return
+	}
+	m.warned = crossed
+
+	msg := fmt.Sprintf("CA %s expires at %s, in less than %s", cert.Subject, cert.NotAfter.UTC().Format(time.RFC3339), crossed)
+	log.FromContext(ctx).Info("WARN: " + msg)
+	m.recordEvent(corev1.EventTypeWarning, "CAExpiring", msg)
+}
+
+// renew re-issues a self-signed, self-managed CA
+func (m *CAExpiryMonitor) renew(ctx context.Context, cert *x509.Certificate, now time.Time) error {
+	log := log.FromContext(ctx)
+
+	if !isSelfSigned(cert) || len(m.CA.GetChainPEM()) > 0 {
+		m.skipRenew(ctx, cert, "CA is not a self-signed root")
+		return nil
+	}
+
+	inMemory := m.Config.CASecretName == "" || externalKeySigningBackend(m.Config.SigningBackend)
+	var secret corev1.Secret
+	if !inMemory {
+		if err := m.Client.Get(ctx, types.NamespacedName{Name: m.Config.CASecretName, Namespace: m.Config.CASecretNamespace}, &secret); err != nil {
+			return fmt.Errorf("failed to get CA secret: %w", err)
+		}
+		if secret.Labels[managedByLabel] != managedByValue {
+			m.skipRenew(ctx, cert, "CA secret is not managed by the signer")
+			return nil
+		}
+		if _, staged := secret.Data[caNextCertKey]; staged {
+			return nil // a rotation is already in progress
+		}
+	}
+
+	key := m.CA.GetKey()
+	if m.Config.CAAutoRenew == CARenewNewKey {
+		var err error
+		if key, err = generateCAKey(m.Config.CAKeyAlgorithm); err != nil {
+			return fmt.Errorf("failed to generate CA private key: %w", err)
+		}
+	}
+	renewed, err := renewCACert(cert, key, now)
+	if err != nil {
+		return err
+	}
+
+	if inMemory {
+		// In-memory CA or certificate of a PKCS#11 or KMS key: keep trusting the old certificate while its leaves live
+		var trust []*x509.Certificate
+		if m.Config.CAAutoRenew == CARenewNewKey {
+			trust = []*x509.Certificate{cert}
+		}
+		m.CA.set(&caKeyPair{cert: renewed, key: key}, trust)
+		log.Info("Renewed in-memory CA", "notAfter", renewed.NotAfter)
+		return nil
+	}
+	return m.renewSecret(ctx, &secret, renewed, key)
+}
+
+// skipRenew logs why the CA is not renewed, once per CA certificate rather
+// than on every check
+func (m *CAExpiryMonitor) skipRenew(ctx context.Context, cert *x509.Certificate, reason string) {
+	if serial := cert.SerialNumber.String(); serial != m.skippedSerial {
+		m.skippedSerial = serial
+		log.FromContext(ctx).Info("WARN: "+reason+", not renewing", "subject", cert.Subject)
+	}
+}
+
+// renewSecret writes the renewed CA into the managed CA Secret: in place for
+// the same key, as the staged next CA for a new key. The secret watcher picks it up.
+func (m *CAExpiryMonitor) renewSecret(ctx context.Context, secret *corev1.Secret, renewed *x509.Certificate, key crypto.Signer) error {
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: renewed.Raw})
+	if m.Config.CAAutoRenew == CARenewNewKey {
+		keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return fmt.Errorf("failed to marshal CA private key: %w", err)
+		}
+		secret.Data[caNextCertKey] = certPEM
+		secret.Data[caNextKeyKey] = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	} else {
+		secret.Data[m.Config.CACertKey] = certPEM
+	}
+	if err := m.Client.Update(ctx, secret); err != nil {
+		return fmt.Errorf("failed to update CA secret: %w", err)
+	}
+
+	log.FromContext(ctx).Info("Renewed CA secret", "mode", m.Config.CAAutoRenew, "notAfter", renewed.NotAfter)
+	m.recordEvent(corev1.EventTypeNormal, "CARenewed", fmt.Sprintf("Renewed CA (%s), valid until %s", m.Config.CAAutoRenew, renewed.NotAfter.UTC().Format(time.RFC3339)))
+	return nil
+}
+
+// recordEvent emits an event regarding the CA Secret; in-memory CAs only log
+func (m *CAExpiryMonitor) recordEvent(eventType, reason, note string) {
+	if m.Recorder == nil || m.Config.CASecretName == "" {
+		return
+	}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: m.Config.CASecretName, Namespace: m.Config.CASecretNamespace}}
+	m.Recorder.Eventf(secret, nil, eventType, reason, eventActionRenew, "%s", note)
+}
+
+// renewCACert issues a self-signed successor of old for key, with the same
+// subject, constraints and validity period starting at now
+func renewCACert(old *x509.Certificate, key crypto.Signer, now time.Time) (*x509.Certificate, error) {
+	template := &x509.Certificate{
+		Subject:               old.Subject,
+		NotBefore:             now,
+		NotAfter:              now.Add(old.NotAfter.Sub(old.NotBefore)),
+		IsCA:                  true,
+		KeyUsage:              old.KeyUsage,
+		BasicConstraintsValid: true,
+		MaxPathLen:            old.MaxPathLen,
+		MaxPathLenZero:        old.MaxPathLenZero,
+	}
+	return createSelfSignedCA(template, key)
+}
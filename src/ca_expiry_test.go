@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// newExpiringTestCA creates a self-signed ECDSA CA valid from an hour ago until notAfter
+func newExpiringTestCA(notAfter time.Time) *CAHelper {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	cert, err := createSelfSignedCA(&x509.Certificate{
+		Subject:               pkix.Name{CommonName: "Expiring CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, key)
+	Expect(err).NotTo(HaveOccurred())
+	return &CAHelper{Cert: cert, Key: key}
+}
+
+func TestCAExpiryMonitor_GaugeAndWarnings(t *testing.T) {
+	RegisterTestingT(t)
+
+	now := time.Now()
+	ca := newExpiringTestCA(now.Add(10 * 24 * time.Hour))
+	recorder := &captureRecorder{}
+	m := &CAExpiryMonitor{
+		CA:       ca,
+		Config:   &Config{CASecretName: "ca", CASecretNamespace: "signer", CAExpiryWarnings: []time.Duration{720 * time.Hour, 168 * time.Hour, 24 * time.Hour}},
+		Recorder: recorder,
+	}
+
+	Expect(m.check(context.Background(), now)).To(Succeed())
+	Expect(getGaugeValue(CAExpiryTimestamp)).To(Equal(float64(ca.Cert.NotAfter.Unix())))
+	Expect(recorder.events).To(HaveLen(1))
+	Expect(recorder.events[0].reason).To(Equal("CAExpiring"))
+	Expect(recorder.events[0].note).To(ContainSubstring("in less than 720h0m0s"))
+
+	// Each threshold warns once
+	Expect(m.check(context.Background(), now.Add(time.Hour))).To(Succeed())
+	Expect(recorder.events).To(HaveLen(1))
+
+	Expect(m.check(context.Background(), now.Add(9*24*time.Hour+time.Hour))).To(Succeed())
+	Expect(recorder.events).To(HaveLen(2))
+	Expect(recorder.events[1].note).To(ContainSubstring("in less than 24h0m0s"))
+}
+
+func TestCAExpiryMonitor_RenewsInMemoryCA(t *testing.T) {
+	RegisterTestingT(t)
+
+	now := time.Now()
+	ca := newExpiringTestCA(now.Add(24 * time.Hour))
+	old := ca.GetCert()
+	m := &CAExpiryMonitor{
+		CA:     ca,
+		Config: &Config{CAAutoRenew: CARenewSameKey, CARenewBefore: 48 * time.Hour},
+	}
+
+	// A leaf issued by the old certificate
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	leafDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "leaf"},
+		NotBefore:    now.Add(-time.Minute),
+		NotAfter:     now.Add(time.Hour),
+	}, old, leafKey.Public(), ca.GetKey())
+	Expect(err).NotTo(HaveOccurred())
+	leaf, err := x509.ParseCertificate(leafDER)
+	Expect(err).NotTo(HaveOccurred())
+
+	Expect(m.check(context.Background(), now)).To(Succeed())
+	renewed := ca.GetCert()
+	Expect(renewed.Equal(old)).To(BeFalse())
+	Expect(renewed.Subject.String()).To(Equal(old.Subject.String()))
+	Expect(renewed.NotAfter.Sub(renewed.NotBefore)).To(Equal(old.NotAfter.Sub(old.NotBefore)))
+
+	// The renewed certificate keeps the key, so existing leaves stay valid
+	roots := x509.NewCertPool()
+	roots.AddCert(renewed)
+	_, err = leaf.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+	Expect(err).NotTo(HaveOccurred())
+}
+
+func TestCAExpiryMonitor_RenewsManagedSecretWithNewKey(t *testing.T) {
+	RegisterTestingT(t)
+
+	now := time.Now()
+	ca := newExpiringTestCA(now.Add(24 * time.Hour))
+	certPEM, keyPEM, err := ca.EncodePEM()
+	Expect(err).NotTo(HaveOccurred())
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ca", Namespace: "signer", Labels: map[string]string{managedByLabel: managedByValue}},
+		Data:       map[string][]byte{"ca.crt": certPEM, "ca.key": keyPEM},
+	}
+	c := newGeneratorTestClient(secret)
+	m := &CAExpiryMonitor{
+		Client: c,
+		CA:     ca,
+		Config: &Config{
+			CASecretName: "ca", CASecretNamespace: "signer", CACertKey: "ca.crt", CAKeyKey: "ca.key",
+			CAKeyAlgorithm: CAKeyAlgorithmECDSAP256, CAAutoRenew: CARenewNewKey, CARenewBefore: 48 * time.Hour,
+		},
+	}
+
+	Expect(m.check(context.Background(), now)).To(Succeed())
+	Expect(c.Get(context.Background(), types.NamespacedName{Name: "ca", Namespace: "signer"}, secret)).To(Succeed())
+	Expect(secret.Data["ca.crt"]).To(Equal(certPEM))
+	Expect(secret.Data).To(HaveKey(caNextCertKey))
+	Expect(secret.Data).To(HaveKey(caNextKeyKey))
+
+	// The staged CA is loadable and uses a new key
+	next, err := parseCAKeyPair(secret.Data, caNextCertKey, caNextKeyKey, "")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(next.key.Public()).NotTo(Equal(ca.GetKey().Public()))
+	block, _ := pem.Decode(secret.Data[caNextKeyKey])
+	Expect(block.Type).To(Equal("PRIVATE KEY"))
+}
+
+func TestCAExpiryMonitor_SkipsUnmanagedSecret(t *testing.T) {
+	RegisterTestingT(t)
+
+	now := time.Now()
+	ca := newExpiringTestCA(now.Add(24 * time.Hour))
+	certPEM, keyPEM, err := ca.EncodePEM()
+	Expect(err).NotTo(HaveOccurred())
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ca", Namespace: "signer"},
+		Data:       map[string][]byte{"ca.crt": certPEM, "ca.key": keyPEM},
+	}
+	c := newGeneratorTestClient(secret)
+	m := &CAExpiryMonitor{
+		Client: c,
+		CA:     ca,
+		Config: &Config{CASecretName: "ca", CASecretNamespace: "signer", CACertKey: "ca.crt", CAAutoRenew: CARenewSameKey, CARenewBefore: 48 * time.Hour},
+	}
+
+	var logged []string
+	ctx := log.IntoContext(context.Background(), funcr.New(func(prefix, args string) {
+		logged = append(logged, args)
+	}, funcr.Options{}))
+	Expect(m.check(ctx, now)).To(Succeed())
+	Expect(c.Get(ctx, types.NamespacedName{Name: "ca", Namespace: "signer"}, secret)).To(Succeed())
+	Expect(secret.Data["ca.crt"]).To(Equal(certPEM))
+	Expect(logged).To(ConsistOf(ContainSubstring("CA secret is not managed by the signer")))
+
+	// The skip is reported once per CA, not on every check
+	Expect(m.check(ctx, now.Add(time.Minute))).To(Succeed())
+	Expect(logged).To(HaveLen(1))
+}
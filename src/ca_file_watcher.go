@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/fsnotify/fsnotify"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// CAFileWatcher reloads the CA from CA_CERT_FILE and CA_KEY_FILE when they
+// change, so the controller needs no access to Secrets. Kubernetes updates
+// Secret, ConfigMap and projected volumes by atomically swapping a ..data
+// symlink, which replaces the files without writing to them, so the watcher
+// watches their directories and re-reads the files on every event.
+type CAFileWatcher struct {
+	CA       *CAHelper
+	CertFile string
+	KeyFile  string
+	// LoadFailurePolicy is only logged; the LocalSigningBackend enforces it
+	LoadFailurePolicy string
+}
+
+// Start watches the CA files until the context is cancelled
+func (w *CAFileWatcher) Start(ctx context.Context) error {
+	log := log.FromContext(ctx).WithName("ca-file-watcher")
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create CA file watcher: %w", err)
+	}
+	defer func() { _ = watcher.Close() }()
+
+	var dirs []string
+	for _, file := range []string{w.CertFile, w.KeyFile} {
+		if dir := filepath.Dir(file); !slices.Contains(dirs, dir) {
+			if err := watcher.Add(dir); err != nil {
+				return fmt.Errorf("failed to watch CA directory %s: %w", dir, err)
+			}
+			dirs = append(dirs, dir)
+		}
+	}
+
+	// Pick up a change made between loading the CA and starting the watch
+	w.reload(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write) {
+				continue
+			}
+			w.reload(ctx)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Error(err, "CA file watch error")
+		}
+	}
+}
+
+// NeedLeaderElection is false: every replica signs with the CA
+func (w *CAFileWatcher) NeedLeaderElection() bool {
+	return false
+}
+
+// reload replaces the CA when the files hold a different one. A broken or
+// half-written pair keeps the last loaded CA and marks it failed, like a
+// broken CA Secret; the next event retries.
+func (w *CAFileWatcher) reload(ctx context.Context) {
+	log := log.FromContext(ctx).WithName("ca-file-watcher")
+
+	active, err := readCAFiles(w.CertFile, w.KeyFile)
+	if err != nil {
+		if w.CA.LoadError() == nil {
+			log.Error(err, "Failed to reload CA from files, keeping the last loaded CA", "policy", w.LoadFailurePolicy)
+			CALoadErrors.Inc()
+		}
+		w.CA.setLoadError(err)
+		return
+	}
+	unchanged := w.CA.GetCert() != nil && w.CA.GetCert().Equal(active.cert) &&
+		slices.EqualFunc(w.CA.GetChain(), active.chain, (*x509.Certificate).Equal)
+	if unchanged && w.CA.LoadError() == nil {
+		return
+	}
+
+	w.CA.set(active, nil)
+	log.Info("CA reloaded from files", "subject", active.cert.Subject.String(), "notAfter", active.cert.NotAfter)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+// writeAtomicCAFiles updates dir the way kubelet updates a Secret volume: the
+// files are written to a new directory, and the ..data symlink, which ca.crt
+// and ca.key point through, is swapped to it with a rename.
+func writeAtomicCAFiles(t *testing.T, dir string, files map[string][]byte) {
+	version := filepath.Join(dir, fmt.Sprintf("..%d", time.Now().UnixNano()))
+	Expect(os.Mkdir(version, 0o755)).To(Succeed())
+	for name, content := range files {
+		Expect(os.WriteFile(filepath.Join(version, name), content, 0o600)).To(Succeed())
+		if _, err := os.Lstat(filepath.Join(dir, name)); os.IsNotExist(err) {
+			Expect(os.Symlink(filepath.Join("..data", name), filepath.Join(dir, name))).To(Succeed())
+		}
+	}
+
+	old, _ := os.Readlink(filepath.Join(dir, "..data"))
+	Expect(os.Symlink(filepath.Base(version), filepath.Join(dir, "..data_tmp"))).To(Succeed())
+	Expect(os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data"))).To(Succeed())
+	if old != "" {
+		Expect(os.RemoveAll(filepath.Join(dir, old))).To(Succeed())
+	}
+}
+
+func TestCAFileWatcher_ReloadsOnSymlinkSwap(t *testing.T) {
+	RegisterTestingT(t)
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	certPEM, keyPEM := generateSelfSignedCert(t)
+	writeAtomicCAFiles(t, dir, map[string][]byte{"ca.crt": certPEM, "ca.key": keyPEM})
+
+	ca, err := NewCAFromFiles(certFile, keyFile)
+	Expect(err).NotTo(HaveOccurred())
+	first := ca.GetCert()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watcher := &CAFileWatcher{CA: ca, CertFile: certFile, KeyFile: keyFile}
+	Expect(watcher.NeedLeaderElection()).To(BeFalse())
+	go func() { _ = watcher.Start(ctx) }()
+
+	// A new CA is picked up once the symlink is swapped
+	newCertPEM, newKeyPEM := generateSelfSignedCert(t)
+	writeAtomicCAFiles(t, dir, map[string][]byte{"ca.crt": newCertPEM, "ca.key": newKeyPEM})
+	Eventually(func() bool { return ca.GetCert().Equal(first) }, 5*time.Second).Should(BeFalse())
+	second := ca.GetCert()
+
+	// A mismatched pair keeps the last loaded CA and marks it failed
+	writeAtomicCAFiles(t, dir, map[string][]byte{"ca.crt": certPEM, "ca.key": newKeyPEM})
+	Eventually(ca.LoadError, 5*time.Second).Should(MatchError(ContainSubstring("does not match")))
+	Expect(ca.GetCert().Equal(second)).To(BeTrue())
+
+	writeAtomicCAFiles(t, dir, map[string][]byte{"ca.crt": certPEM, "ca.key": keyPEM})
+	Eventually(ca.LoadError, 5*time.Second).Should(Succeed())
+	Expect(ca.GetCert().Equal(first)).To(BeTrue())
+}
+
+func TestNewCAFromFiles_ChainFile(t *testing.T) {
+	RegisterTestingT(t)
+
+	rootKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	root := newTestCACert("Root", rootKey, nil, nil)
+	intKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	intermediate := newTestCACert("Intermediate", intKey, root, rootKey)
+	_, keyPEM, err := (&CAHelper{Cert: intermediate, Key: intKey}).EncodePEM()
+	Expect(err).NotTo(HaveOccurred())
+
+	dir := t.TempDir()
+	Expect(os.WriteFile(filepath.Join(dir, "tls.crt"), encodeTestCerts(intermediate), 0o600)).To(Succeed())
+	Expect(os.WriteFile(filepath.Join(dir, "tls.key"), keyPEM, 0o600)).To(Succeed())
+	Expect(os.WriteFile(filepath.Join(dir, caChainKey), encodeTestCerts(root), 0o600)).To(Succeed())
+
+	ca, err := NewCAFromFiles(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(ca.GetChain()).To(HaveLen(1))
+	Expect(string(ca.TrustAnchorsPEM())).To(Equal(string(encodeTestCerts(root))))
+
+	_, err = NewCAFromFiles(filepath.Join(dir, "missing.crt"), filepath.Join(dir, "tls.key"))
+	Expect(err).To(MatchError(ContainSubstring("failed to read CA file")))
+}
@@ -1,14 +1,24 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"errors"
 	"fmt"
+	"io/fs"
 	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
 	"sync"
 	"time"
 
@@ -17,11 +27,38 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// CA key algorithms for the in-memory CA, named like the PodCertificate projection key types
+const (
+	CAKeyAlgorithmRSA2048   = "RSA2048"
+	CAKeyAlgorithmRSA3072   = "RSA3072"
+	CAKeyAlgorithmRSA4096   = "RSA4096"
+	CAKeyAlgorithmECDSAP256 = "ECDSAP256"
+	CAKeyAlgorithmECDSAP384 = "ECDSAP384"
+	CAKeyAlgorithmEd25519   = "ED25519"
+)
+
+// caChainKey is the optional Secret key holding the CA's issuer chain, in
+// addition to any certificates following the CA certificate in the cert key.
+const caChainKey = "chain.crt"
+
 // CAHelper holds our Authority
 type CAHelper struct {
 	Cert *x509.Certificate
-	Key  *rsa.PrivateKey
-	mu   sync.RWMutex
+	// Key is an *rsa.PrivateKey, *ecdsa.PrivateKey or ed25519.PrivateKey
+	Key crypto.Signer
+	// Chain holds the certificates above Cert, ordered towards the root
+	Chain []*x509.Certificate
+	// chainPEM is the PEM bundle appended after every issued leaf: Cert and
+	// Chain without self-signed roots, which belong in trust bundles instead.
+	chainPEM []byte
+	// trust holds additional trust anchors, i.e. the other CA during a rotation
+	trust []*x509.Certificate
+	// loadErr is the last failure to reload the CA, cleared by a successful load
+	loadErr error
+	mu      sync.RWMutex
+
+	// listeners are called after the CA has been replaced
+	listeners []func()
 }
 
 // GetCert returns the current certificate in a thread-safe way
@@ -32,12 +69,61 @@ func (c *CAHelper) GetCert() *x509.Certificate {
 }
 
 // GetKey returns the current private key in a thread-safe way
-func (c *CAHelper) GetKey() *rsa.PrivateKey {
+func (c *CAHelper) GetKey() crypto.Signer {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	return c.Key
 }
 
+// GetChain returns the certificates above the current certificate in a thread-safe way
+func (c *CAHelper) GetChain() []*x509.Certificate {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Chain
+}
+
+// GetChainPEM returns the intermediate certificates to append after an issued leaf
+func (c *CAHelper) GetChainPEM() []byte {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.chainPEM
+}
+
+// caSnapshot is the CA as of one instant, so a concurrent reload cannot pair
+// the certificate of one CA with the key or chain of another
+type caSnapshot struct {
+	caKeyPair
+	// chainPEM is the PEM bundle appended after every issued leaf
+	chainPEM []byte
+	// trust holds the additional trust anchors
+	trust []*x509.Certificate
+}
+
+// snapshot returns the current CA read under a single lock, or nil while no CA is loaded
+func (c *CAHelper) snapshot() *caSnapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.Cert == nil {
+		return nil
+	}
+	return &caSnapshot{
+		caKeyPair: caKeyPair{cert: c.Cert, chain: c.Chain, key: c.Key},
+		chainPEM:  c.chainPEM,
+		trust:     c.trust,
+	}
+}
+
+// trustAnchorsPEM returns the anchor of the CA's chain plus the additional trust anchors
+func (s *caSnapshot) trustAnchorsPEM() []byte {
+	anchors := []*x509.Certificate{s.anchor()}
+	for _, cert := range s.trust {
+		if !slices.ContainsFunc(anchors, cert.Equal) {
+			anchors = append(anchors, cert)
+		}
+	}
+	return encodeCertificatesPEM(anchors)
+}
+
 // NewCAFromSecret loads CA from a secret.
 func NewCAFromSecret(ctx context.Context, apiReader client.Reader, secretName, secretNamespace, certKey, keyKey string) (*CAHelper, error) {
 	ca := &CAHelper{}
@@ -47,94 +133,368 @@ func NewCAFromSecret(ctx context.Context, apiReader client.Reader, secretName, s
 	return ca, nil
 }
 
-// LoadFromSecret updates the CAHelper from a Kubernetes Secret.
+// LoadFromSecret updates the CAHelper from a Kubernetes Secret. When the Secret
+// also holds a staged next CA, both CAs are trusted and the rotation phase
+// annotation decides which one signs.
 func (c *CAHelper) LoadFromSecret(ctx context.Context, apiReader client.Reader, secretName, secretNamespace, certKey, keyKey string) error {
 	var secret corev1.Secret
 	if err := apiReader.Get(ctx, types.NamespacedName{Name: secretName, Namespace: secretNamespace}, &secret); err != nil {
 		return fmt.Errorf("failed to get CA secret %s/%s: %w", secretNamespace, secretName, err)
 	}
 
-	certBytes, ok := secret.Data[certKey]
+	active, trust, err := parseCASecret(&secret, certKey, keyKey)
+	if err != nil {
+		return err
+	}
+	c.set(active, trust)
+	return nil
+}
+
+// parseCASecret returns the signing CA of a CA Secret and the extra trust
+// anchors of a staged rotation, swapped once the rotation has switched
+func parseCASecret(secret *corev1.Secret, certKey, keyKey string) (*caKeyPair, []*x509.Certificate, error) {
+	active, err := parseCAKeyPair(secret.Data, certKey, keyKey, caChainKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	var trust []*x509.Certificate
+	if _, staged := secret.Data[caNextCertKey]; staged {
+		next, err := parseCAKeyPair(secret.Data, caNextCertKey, caNextKeyKey, "")
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid next CA: %w", err)
+		}
+		if secret.Annotations[caRotationPhaseAnnotation] == CARotationPhaseSwitched {
+			active, next = next, active
+		}
+		trust = append(trust, next.anchor())
+	}
+	return active, trust, nil
+}
+
+// NewCAFromFiles loads the CA from PEM files, e.g. a mounted Secret or CSI volume
+func NewCAFromFiles(certFile, keyFile string) (*CAHelper, error) {
+	ca := &CAHelper{}
+	if err := ca.LoadFromFiles(certFile, keyFile); err != nil {
+		return nil, err
+	}
+	return ca, nil
+}
+
+// LoadFromFiles updates the CAHelper from PEM files. Like in a CA Secret, a
+// chain.crt next to the certificate file extends the chain.
+func (c *CAHelper) LoadFromFiles(certFile, keyFile string) error {
+	active, err := readCAFiles(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	c.set(active, nil)
+	return nil
+}
+
+// readCAFiles parses and verifies the CA in certFile and keyFile
+func readCAFiles(certFile, keyFile string) (*caKeyPair, error) {
+	data := map[string][]byte{}
+	chainFile := filepath.Join(filepath.Dir(certFile), caChainKey)
+	for _, file := range []string{certFile, keyFile, chainFile} {
+		content, err := os.ReadFile(file)
+		if errors.Is(err, fs.ErrNotExist) && file == chainFile {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		data[file] = content
+	}
+	return parseCAKeyPair(data, certFile, keyFile, chainFile)
+}
+
+// set replaces the signing CA and the additional trust anchors, then notifies the listeners
+func (c *CAHelper) set(active *caKeyPair, trust []*x509.Certificate) {
+	c.mu.Lock()
+	c.Cert = active.cert
+	c.Key = active.key
+	c.Chain = active.chain
+	c.chainPEM = encodeIntermediatesPEM(append([]*x509.Certificate{active.cert}, active.chain...))
+	c.trust = trust
+	c.loadErr = nil
+	listeners := slices.Clone(c.listeners)
+	c.mu.Unlock()
+
+	for _, listener := range listeners {
+		listener()
+	}
+}
+
+// caKeyPair is a CA certificate with its issuer chain and private key
+type caKeyPair struct {
+	cert  *x509.Certificate
+	chain []*x509.Certificate
+	key   crypto.Signer
+}
+
+// anchor returns the top of the chain, i.e. the root when the chain includes it
+func (p *caKeyPair) anchor() *x509.Certificate {
+	if len(p.chain) > 0 {
+		return p.chain[len(p.chain)-1]
+	}
+	return p.cert
+}
+
+// parseCAKeyPair parses and verifies a CA from Secret data. Certificates after
+// the first one in certKey, followed by those in chainKey (if set), form its chain.
+func parseCAKeyPair(data map[string][]byte, certKey, keyKey, chainKey string) (*caKeyPair, error) {
+	certBytes, ok := data[certKey]
 	if !ok {
-		return fmt.Errorf("certificate key %s not found in secret", certKey)
+		return nil, fmt.Errorf("certificate key %s not found in secret", certKey)
 	}
-	keyBytes, ok := secret.Data[keyKey]
+	keyBytes, ok := data[keyKey]
 	if !ok {
-		return fmt.Errorf("private key key %s not found in secret", keyKey)
+		return nil, fmt.Errorf("private key key %s not found in secret", keyKey)
 	}
 
-	// Parse PEM
-	block, _ := pem.Decode(certBytes)
-	if block == nil {
-		return fmt.Errorf("failed to decode certificate PEM")
-	}
-	cert, err := x509.ParseCertificate(block.Bytes)
+	// Parse PEM; certificates after the first one are its issuer chain
+	certs, err := parseCertificatesPEM(certBytes)
 	if err != nil {
-		return fmt.Errorf("failed to parse certificate: %w", err)
+		return nil, err
+	}
+	cert, chain := certs[0], certs[1:]
+	if chainBytes, ok := data[chainKey]; ok && chainKey != "" {
+		extra, err := parseCertificatesPEM(chainBytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", chainKey, err)
+		}
+		chain = append(chain, extra...)
+	}
+	if err := verifyChain(cert, chain); err != nil {
+		return nil, err
 	}
 
 	// Parse Key
-	keyBlock, _ := pem.Decode(keyBytes)
-	if keyBlock == nil {
-		return fmt.Errorf("failed to decode private key PEM")
-	}
-
-	var key *rsa.PrivateKey
-	if keyBlock.Type == "RSA PRIVATE KEY" {
-		key, err = x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
-	} else if keyBlock.Type == "PRIVATE KEY" {
-		// Try PKCS#8
-		pk, err2 := x509.ParsePKCS8PrivateKey(keyBlock.Bytes)
-		if err2 == nil {
-			var ok bool
-			key, ok = pk.(*rsa.PrivateKey)
-			if !ok {
-				return fmt.Errorf("private key is not RSA")
-			}
-		} else {
-			err = err2
-		}
-	} else {
-		err = fmt.Errorf("unknown private key type: %s", keyBlock.Type)
+	key, err := parsePrivateKeyPEM(keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	if err := validateCA(cert, key, time.Now()); err != nil {
+		return nil, fmt.Errorf("invalid CA in %s/%s: %w", certKey, keyKey, err)
+	}
+	return &caKeyPair{cert: cert, chain: chain, key: key}, nil
+}
+
+// caClockSkew tolerates a CA generated moments ago by a replica whose clock is ahead
+const caClockSkew = 5 * time.Minute
+
+// validateCA checks that cert is a CA certificate valid at now whose public key belongs to key
+func validateCA(cert *x509.Certificate, key crypto.Signer, now time.Time) error {
+	if !keyMatchesCert(cert, key) {
+		return fmt.Errorf("private key does not match the public key of certificate %q", cert.Subject)
+	}
+	if !cert.BasicConstraintsValid {
+		return fmt.Errorf("certificate %q has no basic constraints extension", cert.Subject)
+	}
+	if !cert.IsCA {
+		return fmt.Errorf("certificate %q is not a CA (basic constraints CA:FALSE)", cert.Subject)
+	}
+	if cert.KeyUsage&x509.KeyUsageCertSign == 0 {
+		return fmt.Errorf("certificate %q lacks the certSign key usage", cert.Subject)
+	}
+	if now.Add(caClockSkew).Before(cert.NotBefore) {
+		return fmt.Errorf("certificate %q is not valid before %s", cert.Subject, cert.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if now.After(cert.NotAfter) {
+		return fmt.Errorf("certificate %q expired at %s", cert.Subject, cert.NotAfter.UTC().Format(time.RFC3339))
 	}
+	return nil
+}
+
+// keyMatchesCert reports whether key is the private key for cert's public key
+func keyMatchesCert(cert *x509.Certificate, key crypto.Signer) bool {
+	pub, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	return ok && pub.Equal(cert.PublicKey)
+}
 
+// LoadError returns why the last reload failed, or nil if the CA in use is current
+func (c *CAHelper) LoadError() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.loadErr
+}
+
+// setLoadError records a failed reload; the previously loaded CA stays in use
+func (c *CAHelper) setLoadError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadErr = err
+}
+
+// EncodePEM returns the CA certificate followed by its chain, and the PKCS#8 private key, as PEM
+func (c *CAHelper) EncodePEM() (certPEM, keyPEM []byte, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.Cert == nil || c.Key == nil {
+		return nil, nil, fmt.Errorf("CA not initialized")
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(c.Key)
 	if err != nil {
-		return fmt.Errorf("failed to parse private key: %w", err)
+		return nil, nil, fmt.Errorf("failed to marshal CA private key: %w", err)
 	}
+	certPEM = encodeCertificatesPEM(append([]*x509.Certificate{c.Cert}, c.Chain...))
+	return certPEM, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), nil
+}
 
+// OnChange registers fn to be called whenever the CA is reloaded. fn must not block.
+func (c *CAHelper) OnChange(fn func()) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.Cert = cert
-	c.Key = key
+	c.listeners = append(c.listeners, fn)
+}
 
+// TrustAnchorsPEM returns the PEM certificates clients should trust: the top of
+// the CA's chain, i.e. the root when the chain includes it, plus the anchor of
+// the other CA while a rotation is in progress.
+func (c *CAHelper) TrustAnchorsPEM() []byte {
+	snap := c.snapshot()
+	if snap == nil {
+		return nil
+	}
+	return snap.trustAnchorsPEM()
+}
+
+// parseCertificatesPEM parses every CERTIFICATE block of a PEM bundle
+func parseCertificatesPEM(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("failed to decode certificate PEM")
+	}
+	return certs, nil
+}
+
+// verifyChain checks that cert is signed by chain[0], chain[0] by chain[1] and so on
+func verifyChain(cert *x509.Certificate, chain []*x509.Certificate) error {
+	child := cert
+	for i, parent := range chain {
+		if err := child.CheckSignatureFrom(parent); err != nil {
+			return fmt.Errorf("CA chain certificate %d (%s) did not issue %s: %w", i, parent.Subject, child.Subject, err)
+		}
+		child = parent
+	}
 	return nil
 }
 
-// NewCA generates an in-memory self-signed root CA certificate for the novog93.ghcr/signer.
-// It creates an RSA 2048-bit private key and a corresponding x509 certificate
-// with 10 year validity period. The certificate is suitable for signing child certificates.
+// encodeIntermediatesPEM encodes the certificates that are not self-signed roots
+func encodeIntermediatesPEM(certs []*x509.Certificate) []byte {
+	return encodeCertificatesPEM(slices.DeleteFunc(slices.Clone(certs), isSelfSigned))
+}
+
+// encodeCertificatesPEM encodes certificates as a PEM bundle
+func encodeCertificatesPEM(certs []*x509.Certificate) []byte {
+	var out []byte
+	for _, cert := range certs {
+		out = append(out, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	return out
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) &&
+		cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
+
+// parsePrivateKeyPEM decodes a PKCS#1 ("RSA PRIVATE KEY"), SEC1 ("EC PRIVATE KEY")
+// or PKCS#8 ("PRIVATE KEY") PEM private key. An "EC PARAMETERS" block in front of
+// the key, as written by `openssl ecparam -genkey`, is skipped.
+func parsePrivateKeyPEM(keyBytes []byte) (crypto.Signer, error) {
+	keyBlock, rest := pem.Decode(keyBytes)
+	for keyBlock != nil && keyBlock.Type == "EC PARAMETERS" {
+		keyBlock, rest = pem.Decode(rest)
+	}
+	if keyBlock == nil {
+		return nil, fmt.Errorf("failed to decode private key PEM")
+	}
+
+	switch keyBlock.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(keyBlock.Bytes)
+	case "PRIVATE KEY":
+		pk, err := x509.ParsePKCS8PrivateKey(keyBlock.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		switch key := pk.(type) {
+		case *rsa.PrivateKey:
+			return key, nil
+		case *ecdsa.PrivateKey:
+			return key, nil
+		case ed25519.PrivateKey:
+			return key, nil
+		default:
+			return nil, fmt.Errorf("unsupported PKCS#8 private key type %T", pk)
+		}
+	default:
+		return nil, fmt.Errorf("unknown private key type: %s", keyBlock.Type)
+	}
+}
+
+// generateCAKey creates a private key for the given CA key algorithm
+func generateCAKey(keyAlgorithm string) (crypto.Signer, error) {
+	switch keyAlgorithm {
+	case "", CAKeyAlgorithmRSA2048:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case CAKeyAlgorithmRSA3072:
+		return rsa.GenerateKey(rand.Reader, 3072)
+	case CAKeyAlgorithmRSA4096:
+		return rsa.GenerateKey(rand.Reader, 4096)
+	case CAKeyAlgorithmECDSAP256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case CAKeyAlgorithmECDSAP384:
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case CAKeyAlgorithmEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	default:
+		return nil, fmt.Errorf("unknown CA key algorithm %q", keyAlgorithm)
+	}
+}
+
+// NewCA generates an in-memory self-signed root CA with an RSA 2048-bit key.
+// See NewCAWithKeyAlgorithm.
+func NewCA() (*CAHelper, error) {
+	return NewCAWithKeyAlgorithm(CAKeyAlgorithmRSA2048)
+}
+
+// NewCAWithKeyAlgorithm generates an in-memory self-signed root CA certificate for the novog93.ghcr/signer.
+// It creates a private key of the given algorithm (RSA2048 when empty) and a corresponding
+// x509 certificate with 10 year validity period. The certificate is suitable for signing child certificates.
 //
 // Returns:
-// - *CAHelper: Contains the generated x509.Certificate and RSA private key
-// - error: If key generation or certificate creation fails
-func NewCA() (*CAHelper, error) {
-	// Step 1: Generate RSA 2048-bit private key
-	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+// - *CAHelper: Contains the generated x509.Certificate and private key
+// - error: If the algorithm is unknown or key generation or certificate creation fails
+func NewCAWithKeyAlgorithm(keyAlgorithm string) (*CAHelper, error) {
+	// Step 1: Generate the private key
+	privateKey, err := generateCAKey(keyAlgorithm)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate RSA private key: %w", err)
+		return nil, fmt.Errorf("failed to generate CA private key: %w", err)
 	}
 
 	// Step 2: Create the root CA certificate template
 	now := time.Now()
-	// Use small number logic from previous implementation
-	// Note: previous implementation used big.Int Lsh 128.
-	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate serial number: %w", err)
-	}
-
 	template := x509.Certificate{
-		SerialNumber: serialNumber,
 		Subject: pkix.Name{
 			CommonName: "NovoG93 Signer CA",
 		},
@@ -147,25 +507,42 @@ func NewCA() (*CAHelper, error) {
 	}
 
 	// Step 3: Create the self-signed certificate
+	cert, err := createSelfSignedCA(&template, privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CAHelper{
+		Cert: cert,
+		Key:  privateKey,
+	}, nil
+}
+
+// createSelfSignedCA signs template with key, using a fresh random serial number
+func createSelfSignedCA(template *x509.Certificate, key crypto.Signer) (*x509.Certificate, error) {
+	// Use small number logic from previous implementation
+	// Note: previous implementation used big.Int Lsh 128.
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	template.SerialNumber = serialNumber
+
 	certBytes, err := x509.CreateCertificate(
 		rand.Reader,
-		&template,
-		&template, // Self-signed: parent is same as template
-		&privateKey.PublicKey,
-		privateKey,
+		template,
+		template, // Self-signed: parent is same as template
+		key.Public(),
+		key,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create certificate: %w", err)
 	}
 
-	// Step 4: Parse the created certificate bytes back into x509.Certificate struct
+	// Parse the created certificate bytes back into x509.Certificate struct
 	cert, err := x509.ParseCertificate(certBytes)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse created certificate: %w", err)
 	}
-
-	return &CAHelper{
-		Cert: cert,
-		Key:  privateKey,
-	}, nil
+	return cert, nil
 }
@@ -2,6 +2,10 @@ package main
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -37,12 +41,13 @@ func generateSelfSignedCert(t *testing.T) ([]byte, []byte) {
 	}
 
 	template := x509.Certificate{
-		SerialNumber: big.NewInt(1),
-		Subject:      pkix.Name{CommonName: "Test CA"},
-		NotBefore:    time.Now(),
-		NotAfter:     time.Now().Add(time.Hour),
-		IsCA:         true,
-		KeyUsage:     x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
 	}
 
 	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
@@ -197,3 +202,313 @@ func TestCAHelper_ReloadsOnSecretChange(t *testing.T) {
 	// In production, the SecretReconciler watches for Secret updates and calls
 	// LoadFromSecret() to hot-reload without pod restart
 }
+
+func TestNewCAWithKeyAlgorithm(t *testing.T) {
+	RegisterTestingT(t)
+
+	for _, tc := range []struct {
+		algorithm string
+		check     func(crypto.Signer)
+	}{
+		{CAKeyAlgorithmRSA3072, func(k crypto.Signer) { Expect(k.(*rsa.PrivateKey).N.BitLen()).To(Equal(3072)) }},
+		{CAKeyAlgorithmECDSAP256, func(k crypto.Signer) { Expect(k.(*ecdsa.PrivateKey).Curve).To(Equal(elliptic.P256())) }},
+		{CAKeyAlgorithmECDSAP384, func(k crypto.Signer) { Expect(k.(*ecdsa.PrivateKey).Curve).To(Equal(elliptic.P384())) }},
+		{CAKeyAlgorithmEd25519, func(k crypto.Signer) { Expect(k).To(BeAssignableToTypeOf(ed25519.PrivateKey{})) }},
+	} {
+		ca, err := NewCAWithKeyAlgorithm(tc.algorithm)
+		Expect(err).NotTo(HaveOccurred(), tc.algorithm)
+		tc.check(ca.GetKey())
+		Expect(ca.Cert.IsCA).To(BeTrue())
+		// The certificate must be self-signed by the generated key
+		Expect(ca.Cert.CheckSignatureFrom(ca.Cert)).To(Succeed(), tc.algorithm)
+	}
+}
+
+func TestNewCAWithKeyAlgorithm_Unknown(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := NewCAWithKeyAlgorithm("DSA1024")
+	Expect(err).To(MatchError(ContainSubstring("unknown CA key algorithm")))
+}
+
+func TestNewCA_FromSecret_NonRSAKeys(t *testing.T) {
+	RegisterTestingT(t)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	sec1, err := x509.MarshalECPrivateKey(ecKey)
+	Expect(err).NotTo(HaveOccurred())
+	ecPKCS8, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	Expect(err).NotTo(HaveOccurred())
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	edPKCS8, err := x509.MarshalPKCS8PrivateKey(edKey)
+	Expect(err).NotTo(HaveOccurred())
+
+	// openssl ecparam -genkey writes the curve parameters in front of the key
+	ecParams := pem.EncodeToMemory(&pem.Block{Type: "EC PARAMETERS", Bytes: []byte{0x06, 0x05, 0x2b, 0x81, 0x04, 0x00, 0x22}})
+
+	for name, tc := range map[string]struct {
+		signer crypto.Signer
+		keyPEM []byte
+	}{
+		"sec1":          {ecKey, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1})},
+		"sec1-params":   {ecKey, append(ecParams, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1})...)},
+		"pkcs8-ecdsa":   {ecKey, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: ecPKCS8})},
+		"pkcs8-ed25519": {edKey, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: edPKCS8})},
+	} {
+		template := x509.Certificate{
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: "Test CA " + name},
+			NotBefore:             time.Now(),
+			NotAfter:              time.Now().Add(time.Hour),
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		}
+		derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, tc.signer.Public(), tc.signer)
+		Expect(err).NotTo(HaveOccurred())
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "ca-secret", Namespace: "default"},
+			Data: map[string][]byte{
+				"ca.crt": pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derBytes}),
+				"ca.key": tc.keyPEM,
+			},
+		}
+		scheme := runtime.NewScheme()
+		_ = clientgoscheme.AddToScheme(scheme)
+		fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+
+		ca, err := NewCAFromSecret(context.Background(), fakeClient, "ca-secret", "default", "ca.crt", "ca.key")
+		Expect(err).NotTo(HaveOccurred(), name)
+		Expect(ca.GetKey().Public()).To(Equal(tc.signer.Public()), name)
+	}
+}
+
+// newTestCACert creates a CA certificate for key, signed by parent/parentKey or self-signed when parent is nil
+func newTestCACert(cn string, key crypto.Signer, parent *x509.Certificate, parentKey crypto.Signer) *x509.Certificate {
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	Expect(err).NotTo(HaveOccurred())
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+	return cert
+}
+
+func encodeTestCerts(certs ...*x509.Certificate) []byte {
+	var out []byte
+	for _, cert := range certs {
+		out = append(out, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	return out
+}
+
+func loadTestCASecret(data map[string][]byte) (*CAHelper, error) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ca-secret", Namespace: "default"},
+		Data:       data,
+	}
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+	return NewCAFromSecret(context.Background(), fakeClient, "ca-secret", "default", "ca.crt", "ca.key")
+}
+
+func TestNewCA_FromSecret_ChainInCertKey(t *testing.T) {
+	RegisterTestingT(t)
+
+	rootKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	root := newTestCACert("Offline Root", rootKey, nil, nil)
+	intKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	intermediate := newTestCACert("Intermediate", intKey, root, rootKey)
+	keyDER, _ := x509.MarshalECPrivateKey(intKey)
+
+	ca, err := loadTestCASecret(map[string][]byte{
+		"ca.crt": encodeTestCerts(intermediate, root),
+		"ca.key": pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(ca.GetCert().Subject.CommonName).To(Equal("Intermediate"))
+	Expect(ca.Chain).To(HaveLen(1))
+	// The self-signed root is not part of issued bundles
+	Expect(ca.GetChainPEM()).To(Equal(encodeTestCerts(intermediate)))
+}
+
+func TestNewCA_FromSecret_ChainKey(t *testing.T) {
+	RegisterTestingT(t)
+
+	rootKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	root := newTestCACert("Offline Root", rootKey, nil, nil)
+	policyKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	policyCA := newTestCACert("Policy CA", policyKey, root, rootKey)
+	issuingKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	issuingCA := newTestCACert("Issuing CA", issuingKey, policyCA, policyKey)
+	keyDER, _ := x509.MarshalECPrivateKey(issuingKey)
+
+	ca, err := loadTestCASecret(map[string][]byte{
+		"ca.crt":    encodeTestCerts(issuingCA),
+		"chain.crt": encodeTestCerts(policyCA, root),
+		"ca.key":    pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(ca.Chain).To(HaveLen(2))
+	Expect(ca.GetChainPEM()).To(Equal(encodeTestCerts(issuingCA, policyCA)))
+}
+
+func TestNewCA_FromSecret_ChainMismatch(t *testing.T) {
+	RegisterTestingT(t)
+
+	rootKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	root := newTestCACert("Offline Root", rootKey, nil, nil)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	other := newTestCACert("Unrelated Root", otherKey, nil, nil)
+	intKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	intermediate := newTestCACert("Intermediate", intKey, root, rootKey)
+	keyDER, _ := x509.MarshalECPrivateKey(intKey)
+
+	_, err := loadTestCASecret(map[string][]byte{
+		"ca.crt":    encodeTestCerts(intermediate),
+		"chain.crt": encodeTestCerts(other),
+		"ca.key":    pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	})
+	Expect(err).To(MatchError(ContainSubstring("did not issue")))
+}
+
+func TestNewCA_FromSecret_SelfSignedHasNoChain(t *testing.T) {
+	RegisterTestingT(t)
+
+	certPEM, keyPEM := generateSelfSignedCert(t)
+	ca, err := loadTestCASecret(map[string][]byte{"ca.crt": certPEM, "ca.key": keyPEM})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(ca.Chain).To(BeEmpty())
+	Expect(ca.GetChainPEM()).To(BeEmpty())
+}
+
+func TestCAHelper_OnChangeAndTrustAnchors(t *testing.T) {
+	RegisterTestingT(t)
+
+	rootKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	root := newTestCACert("Offline Root", rootKey, nil, nil)
+	intKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	intermediate := newTestCACert("Intermediate", intKey, root, rootKey)
+	keyDER, _ := x509.MarshalECPrivateKey(intKey)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ca-secret", Namespace: "default"},
+		Data: map[string][]byte{
+			"ca.crt": encodeTestCerts(intermediate, root),
+			"ca.key": pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		},
+	}
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+
+	ca := &CAHelper{}
+	Expect(ca.TrustAnchorsPEM()).To(BeEmpty())
+
+	changes := 0
+	ca.OnChange(func() { changes++ })
+	Expect(ca.LoadFromSecret(context.Background(), c, "ca-secret", "default", "ca.crt", "ca.key")).To(Succeed())
+	Expect(changes).To(Equal(1))
+	// The root, not the signing intermediate, is the trust anchor
+	Expect(ca.TrustAnchorsPEM()).To(Equal(encodeTestCerts(root)))
+
+	// A failed reload leaves the CA untouched and does not notify
+	secret.Data["ca.key"] = []byte("garbage")
+	Expect(c.Update(context.Background(), secret)).To(Succeed())
+	Expect(ca.LoadFromSecret(context.Background(), c, "ca-secret", "default", "ca.crt", "ca.key")).NotTo(Succeed())
+	Expect(changes).To(Equal(1))
+}
+
+func TestNewCA_FromSecret_RejectsInvalidCA(t *testing.T) {
+	RegisterTestingT(t)
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	valid := func() *x509.Certificate {
+		return &x509.Certificate{
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: "Test CA"},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(time.Hour),
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		}
+	}
+
+	tests := map[string]struct {
+		mutate  func(*x509.Certificate)
+		certKey crypto.Signer
+		wantErr string
+	}{
+		"key mismatch":           {certKey: otherKey, wantErr: "does not match"},
+		"no basic constraints":   {mutate: func(c *x509.Certificate) { c.BasicConstraintsValid = false; c.IsCA = false }, wantErr: "no basic constraints"},
+		"not a CA":               {mutate: func(c *x509.Certificate) { c.IsCA = false }, wantErr: "is not a CA"},
+		"missing certSign usage": {mutate: func(c *x509.Certificate) { c.KeyUsage = x509.KeyUsageDigitalSignature }, wantErr: "certSign"},
+		"expired":                {mutate: func(c *x509.Certificate) { c.NotAfter = time.Now().Add(-time.Minute) }, wantErr: "expired at"},
+		"not yet valid":          {mutate: func(c *x509.Certificate) { c.NotBefore = time.Now().Add(time.Hour) }, wantErr: "not valid before"},
+	}
+
+	for name, tc := range tests {
+		template := valid()
+		if tc.mutate != nil {
+			tc.mutate(template)
+		}
+		certKey := tc.certKey
+		if certKey == nil {
+			certKey = key
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, certKey.Public(), certKey)
+		Expect(err).NotTo(HaveOccurred(), name)
+
+		_, err = loadTestCASecret(map[string][]byte{
+			"ca.crt": pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			"ca.key": keyPEM,
+		})
+		Expect(err).To(MatchError(ContainSubstring(tc.wantErr)), name)
+	}
+}
+
+func TestCAHelper_ReloadKeepsCAOnValidationFailure(t *testing.T) {
+	RegisterTestingT(t)
+
+	certPEM, keyPEM := generateSelfSignedCert(t)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ca-secret", Namespace: "default"},
+		Data:       map[string][]byte{"ca.crt": certPEM, "ca.key": keyPEM},
+	}
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+
+	ca, err := NewCAFromSecret(context.Background(), c, "ca-secret", "default", "ca.crt", "ca.key")
+	Expect(err).NotTo(HaveOccurred())
+	loaded := ca.GetCert()
+
+	// Replace only the key, so it no longer matches the certificate
+	_, otherKeyPEM := generateSelfSignedCert(t)
+	secret.Data["ca.key"] = otherKeyPEM
+	Expect(c.Update(context.Background(), secret)).To(Succeed())
+
+	err = ca.LoadFromSecret(context.Background(), c, "ca-secret", "default", "ca.crt", "ca.key")
+	Expect(err).To(MatchError(ContainSubstring("does not match")))
+	Expect(ca.GetCert()).To(Equal(loaded))
+}
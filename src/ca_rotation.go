@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Secret keys holding the staged next CA; next.crt may be followed by its issuer chain
+const (
+	caNextCertKey = "next.crt"
+	caNextKeyKey  = "next.key"
+)
+
+// Annotations on the CA Secret recording the rotation progress. Operators may
+// set the phase to Switched to skip the rest of the overlap period.
+const (
+	caRotationPhaseAnnotation      = "signer.novog93.ghcr/rotation-phase"
+	caRotationStagedAtAnnotation   = "signer.novog93.ghcr/rotation-staged-at"
+	caRotationSwitchedAtAnnotation = "signer.novog93.ghcr/rotation-switched-at"
+)
+
+// CA rotation phases
+const (
+	// CARotationPhaseIdle means no next CA is staged
+	CARotationPhaseIdle = "Idle"
+	// CARotationPhaseStaged means both CAs are trusted and the current CA signs
+	CARotationPhaseStaged = "Staged"
+	// CARotationPhaseSwitched means both CAs are trusted and the next CA signs
+	CARotationPhaseSwitched = "Switched"
+)
+
+var caRotationPhases = []string{CARotationPhaseIdle, CARotationPhaseStaged, CARotationPhaseSwitched}
+
+// caRotationStatus is the rotation state published in the status ConfigMap
+type caRotationStatus struct {
+	Phase          string
+	StagedAt       time.Time
+	SwitchedAt     time.Time
+	NextTransition time.Time
+}
+
+// caRotationStatusName is the name of the ConfigMap reporting the rotation of a CA Secret
+func caRotationStatusName(secretName string) string {
+	return secretName + "-rotation"
+}
+
+// ReconcileRotation advances the rotation of a valid CA Secret. It writes the
+// Secret, so unlike the reload it runs only on the leader.
+func (r *SecretReconciler) ReconcileRotation(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var secret corev1.Secret
+	if err := r.Get(ctx, req.NamespacedName, &secret); err != nil {
+		// A deleted Secret is reported by the reload
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if _, _, err := parseCASecret(&secret, r.Config.CACertKey, r.Config.CAKeyKey); err != nil {
+		// The reload reports the broken Secret; rotate once it is fixed
+		return ctrl.Result{}, nil
+	}
+	return r.reconcileRotation(ctx, req, time.Now())
+}
+
+// reconcileRotation advances the rotation of the CA Secret and publishes its
+// status. Phase changes are written to the Secret, whose update event then
+// reloads the CA with the new phase.
+func (r *SecretReconciler) reconcileRotation(ctx context.Context, req ctrl.Request, now time.Time) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	var secret corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Name: r.Config.CASecretName, Namespace: r.Config.CASecretNamespace}, &secret); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get CA secret: %w", err)
+	}
+
+	previous := secret.Annotations[caRotationPhaseAnnotation]
+	status, changed := advanceRotation(&secret, r.Config, now)
+	if changed {
+		if err := r.Update(ctx, &secret); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to update CA secret rotation state: %w", err)
+		}
+		if status.Phase != previous {
+			log.Info("CA rotation phase changed", "phase", status.Phase)
+			r.recordEvent(&secret, corev1.EventTypeNormal, "CARotation", eventActionRotate, fmt.Sprintf("CA rotation phase is now %s", status.Phase))
+		}
+	}
+
+	for _, phase := range caRotationPhases {
+		value := 0.0
+		if phase == status.Phase {
+			value = 1
+		}
+		CARotationPhaseGauge.WithLabelValues(phase).Set(value)
+	}
+
+	if err := r.writeRotationStatus(ctx, status); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if status.NextTransition.IsZero() {
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{RequeueAfter: max(status.NextTransition.Sub(now), time.Second)}, nil
+}
+
+// advanceRotation moves the Secret to the rotation phase due at now and reports
+// whether it was modified. Once the grace period has passed, the next CA
+// replaces the current one and the Secret returns to Idle.
+func advanceRotation(secret *corev1.Secret, config *Config, now time.Time) (caRotationStatus, bool) {
+	annotations := secret.Annotations
+	if _, staged := secret.Data[caNextCertKey]; !staged {
+		// Nothing staged, or the rotation was aborted by removing the next CA
+		changed := false
+		for _, key := range []string{caRotationPhaseAnnotation, caRotationStagedAtAnnotation, caRotationSwitchedAtAnnotation} {
+			if _, ok := annotations[key]; ok {
+				delete(annotations, key)
+				changed = true
+			}
+		}
+		return caRotationStatus{Phase: CARotationPhaseIdle}, changed
+	}
+
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	annotations = secret.Annotations
+	changed := false
+	timestamp := func(key string) time.Time {
+		t, err := time.Parse(time.RFC3339, annotations[key])
+		if err != nil {
+			t = now
+			annotations[key] = now.UTC().Format(time.RFC3339)
+			changed = true
+		}
+		return t
+	}
+
+	status := caRotationStatus{StagedAt: timestamp(caRotationStagedAtAnnotation)}
+	if annotations[caRotationPhaseAnnotation] != CARotationPhaseSwitched {
+		if annotations[caRotationPhaseAnnotation] != CARotationPhaseStaged {
+			annotations[caRotationPhaseAnnotation] = CARotationPhaseStaged
+			changed = true
+		}
+		status.Phase = CARotationPhaseStaged
+		status.NextTransition = status.StagedAt.Add(config.CARotationOverlap)
+		if now.Before(status.NextTransition) {
+			return status, changed
+		}
+		annotations[caRotationPhaseAnnotation] = CARotationPhaseSwitched
+		annotations[caRotationSwitchedAtAnnotation] = now.UTC().Format(time.RFC3339)
+		changed = true
+	}
+
+	status.Phase = CARotationPhaseSwitched
+	status.SwitchedAt = timestamp(caRotationSwitchedAtAnnotation)
+	status.NextTransition = status.SwitchedAt.Add(config.CARotationGrace)
+	if now.Before(status.NextTransition) {
+		return status, changed
+	}
+
+	// Grace period over: promote the next CA and drop the old one
+	secret.Data[config.CACertKey] = secret.Data[caNextCertKey]
+	secret.Data[config.CAKeyKey] = secret.Data[caNextKeyKey]
+	delete(secret.Data, caNextCertKey)
+	delete(secret.Data, caNextKeyKey)
+	delete(secret.Data, caChainKey) // belonged to the old CA; the next CA's chain is in its cert key
+	delete(annotations, caRotationPhaseAnnotation)
+	delete(annotations, caRotationStagedAtAnnotation)
+	delete(annotations, caRotationSwitchedAtAnnotation)
+	return caRotationStatus{Phase: CARotationPhaseIdle}, true
+}
+
+// writeRotationStatus creates or updates the rotation status ConfigMap next to the CA Secret
+func (r *SecretReconciler) writeRotationStatus(ctx context.Context, status caRotationStatus) error {
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+	data := map[string]string{
+		"phase":          status.Phase,
+		"stagedAt":       formatTime(status.StagedAt),
+		"switchedAt":     formatTime(status.SwitchedAt),
+		"nextTransition": formatTime(status.NextTransition),
+	}
+	if cert := r.CA.GetCert(); cert != nil {
+		data["signingCA"] = fmt.Sprintf("%s (serial %x)", cert.Subject, cert.SerialNumber)
+	}
+
+	name := types.NamespacedName{Name: caRotationStatusName(r.Config.CASecretName), Namespace: r.Config.CASecretNamespace}
+	var cm corev1.ConfigMap
+	err := r.APIReader.Get(ctx, name, &cm)
+	if apierrors.IsNotFound(err) {
+		cm = corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name.Name,
+				Namespace: name.Namespace,
+				Labels:    map[string]string{managedByLabel: managedByValue},
+			},
+			Data: data,
+		}
+		if err := r.Create(ctx, &cm); err != nil {
+			return fmt.Errorf("failed to create CA rotation status ConfigMap: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get CA rotation status ConfigMap: %w", err)
+	}
+	if maps.Equal(cm.Data, data) {
+		return nil
+	}
+	cm.Data = data
+	if err := r.Update(ctx, &cm); err != nil {
+		return fmt.Errorf("failed to update CA rotation status ConfigMap: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var rotationTestConfig = &Config{
+	CASecretName:      "ca-secret",
+	CASecretNamespace: "default",
+	CACertKey:         "ca.crt",
+	CAKeyKey:          "ca.key",
+	CARotationOverlap: time.Hour,
+	CARotationGrace:   2 * time.Hour,
+}
+
+func newRotationTestSecret(t *testing.T) (*corev1.Secret, []byte, []byte) {
+	currentCert, currentKey := generateSelfSignedCert(t)
+	nextCert, nextKey := generateSelfSignedCert(t)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ca-secret", Namespace: "default"},
+		Data: map[string][]byte{
+			"ca.crt":   currentCert,
+			"ca.key":   currentKey,
+			"next.crt": nextCert,
+			"next.key": nextKey,
+		},
+	}
+	return secret, currentCert, nextCert
+}
+
+func TestAdvanceRotation(t *testing.T) {
+	RegisterTestingT(t)
+
+	secret, _, nextCert := newRotationTestSecret(t)
+	nextKey := secret.Data["next.key"]
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Staging records the start of the overlap period
+	status, changed := advanceRotation(secret, rotationTestConfig, start)
+	Expect(changed).To(BeTrue())
+	Expect(status.Phase).To(Equal(CARotationPhaseStaged))
+	Expect(status.NextTransition).To(Equal(start.Add(time.Hour)))
+	Expect(secret.Annotations).To(HaveKeyWithValue(caRotationPhaseAnnotation, CARotationPhaseStaged))
+	Expect(secret.Annotations).To(HaveKeyWithValue(caRotationStagedAtAnnotation, "2026-01-01T00:00:00Z"))
+
+	status, changed = advanceRotation(secret, rotationTestConfig, start.Add(30*time.Minute))
+	Expect(changed).To(BeFalse())
+	Expect(status.Phase).To(Equal(CARotationPhaseStaged))
+
+	// After the overlap the next CA signs
+	status, changed = advanceRotation(secret, rotationTestConfig, start.Add(time.Hour))
+	Expect(changed).To(BeTrue())
+	Expect(status.Phase).To(Equal(CARotationPhaseSwitched))
+	Expect(status.NextTransition).To(Equal(start.Add(3 * time.Hour)))
+	Expect(secret.Annotations).To(HaveKeyWithValue(caRotationSwitchedAtAnnotation, "2026-01-01T01:00:00Z"))
+
+	status, changed = advanceRotation(secret, rotationTestConfig, start.Add(2*time.Hour))
+	Expect(changed).To(BeFalse())
+	Expect(status.Phase).To(Equal(CARotationPhaseSwitched))
+
+	// After the grace period the next CA replaces the current one
+	status, changed = advanceRotation(secret, rotationTestConfig, start.Add(3*time.Hour))
+	Expect(changed).To(BeTrue())
+	Expect(status.Phase).To(Equal(CARotationPhaseIdle))
+	Expect(secret.Data["ca.crt"]).To(Equal(nextCert))
+	Expect(secret.Data["ca.key"]).To(Equal(nextKey))
+	Expect(secret.Data).NotTo(HaveKey("next.crt"))
+	Expect(secret.Data).NotTo(HaveKey("next.key"))
+	Expect(secret.Annotations).To(BeEmpty())
+
+	status, changed = advanceRotation(secret, rotationTestConfig, start.Add(4*time.Hour))
+	Expect(changed).To(BeFalse())
+	Expect(status.Phase).To(Equal(CARotationPhaseIdle))
+}
+
+func TestAdvanceRotation_Aborted(t *testing.T) {
+	RegisterTestingT(t)
+
+	secret, _, _ := newRotationTestSecret(t)
+	secret.Annotations = map[string]string{
+		caRotationPhaseAnnotation:    CARotationPhaseStaged,
+		caRotationStagedAtAnnotation: "2026-01-01T00:00:00Z",
+	}
+	delete(secret.Data, "next.crt")
+	delete(secret.Data, "next.key")
+
+	status, changed := advanceRotation(secret, rotationTestConfig, time.Now())
+	Expect(changed).To(BeTrue())
+	Expect(status.Phase).To(Equal(CARotationPhaseIdle))
+	Expect(secret.Annotations).To(BeEmpty())
+}
+
+func TestCAHelper_LoadFromSecret_Rotation(t *testing.T) {
+	RegisterTestingT(t)
+
+	secret, currentCert, nextCert := newRotationTestSecret(t)
+	current, err := parseCertificatesPEM(currentCert)
+	Expect(err).NotTo(HaveOccurred())
+	next, err := parseCertificatesPEM(nextCert)
+	Expect(err).NotTo(HaveOccurred())
+
+	// Staged: the current CA signs, both are trusted
+	ca, err := loadTestCASecret(secret.Data)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(ca.GetCert().Equal(current[0])).To(BeTrue())
+	Expect(string(ca.TrustAnchorsPEM())).To(Equal(string(currentCert) + string(nextCert)))
+
+	// Switched: the next CA signs, both are still trusted
+	secret.Annotations = map[string]string{caRotationPhaseAnnotation: CARotationPhaseSwitched}
+	scheme := runtime.NewScheme()
+	Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+	Expect(ca.LoadFromSecret(context.Background(), fakeClient, "ca-secret", "default", "ca.crt", "ca.key")).To(Succeed())
+	Expect(ca.GetCert().Equal(next[0])).To(BeTrue())
+	Expect(string(ca.TrustAnchorsPEM())).To(Equal(string(nextCert) + string(currentCert)))
+}
+
+func TestSecretReconciler_Rotation(t *testing.T) {
+	RegisterTestingT(t)
+
+	secret, _, nextCert := newRotationTestSecret(t)
+	scheme := runtime.NewScheme()
+	Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+	r := &SecretReconciler{Client: c, APIReader: c, CA: &CAHelper{}, Config: rotationTestConfig}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "ca-secret", Namespace: "default"}}
+
+	_, err := r.Reconcile(context.Background(), req)
+	Expect(err).NotTo(HaveOccurred())
+	result, err := r.ReconcileRotation(context.Background(), req)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(result.RequeueAfter).To(BeNumerically("~", time.Hour, time.Minute))
+	Expect(getGaugeValue(CARotationPhaseGauge.WithLabelValues(CARotationPhaseStaged))).To(Equal(1.0))
+	Expect(getGaugeValue(CARotationPhaseGauge.WithLabelValues(CARotationPhaseIdle))).To(Equal(0.0))
+
+	var status corev1.ConfigMap
+	Expect(c.Get(context.Background(), types.NamespacedName{Name: "ca-secret-rotation", Namespace: "default"}, &status)).To(Succeed())
+	Expect(status.Data).To(HaveKeyWithValue("phase", CARotationPhaseStaged))
+	Expect(status.Data["stagedAt"]).NotTo(BeEmpty())
+	Expect(status.Data["signingCA"]).To(ContainSubstring("Test CA"))
+
+	// Skip the overlap: the operator switches early
+	Expect(c.Get(context.Background(), req.NamespacedName, secret)).To(Succeed())
+	secret.Annotations[caRotationPhaseAnnotation] = CARotationPhaseSwitched
+	Expect(c.Update(context.Background(), secret)).To(Succeed())
+
+	_, err = r.Reconcile(context.Background(), req)
+	Expect(err).NotTo(HaveOccurred())
+	_, err = r.ReconcileRotation(context.Background(), req)
+	Expect(err).NotTo(HaveOccurred())
+	next, err := parseCertificatesPEM(nextCert)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(r.CA.GetCert().Equal(next[0])).To(BeTrue())
+	Expect(c.Get(context.Background(), types.NamespacedName{Name: "ca-secret-rotation", Namespace: "default"}, &status)).To(Succeed())
+	Expect(status.Data).To(HaveKeyWithValue("phase", CARotationPhaseSwitched))
+	Expect(getGaugeValue(CARotationPhaseGauge.WithLabelValues(CARotationPhaseSwitched))).To(Equal(1.0))
+}
@@ -2,19 +2,25 @@ package main
 
 import (
 	"context"
+	"crypto"
 	"crypto/ecdsa"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
-	"encoding/pem"
+	"errors"
 	"fmt"
 	"math/big"
+	"net/url"
 	"time"
 
 	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
 	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -28,8 +34,27 @@ type SignerReconciler struct {
 	CA         *CAHelper
 	SignerName string
 	Config     *Config
+	// Backend signs the certificates; nil signs in process with CA
+	Backend SigningBackend
+	// AdmissionRules are CEL rules a request must pass before it is signed
+	AdmissionRules *AdmissionRules
+	// Recorder emits events regarding the PCR and its Pod; nil disables events
+	Recorder events.EventRecorder
+	// RequestMetrics is refreshed after every reconcile; nil leaves the gauges to their interval
+	RequestMetrics *RequestMetricsRunnable
 }
 
+const (
+	// eventRecorderName is the reporting controller of emitted events
+	eventRecorderName = "signer-controller"
+
+	eventActionSign     = "Sign"
+	eventActionReload   = "Reload"
+	eventActionRotate   = "Rotate"
+	eventActionGenerate = "Generate"
+	eventActionRenew    = "Renew"
+)
+
 // Reconcile is the loop. It receives a Name/Namespace and decides what to do.
 func (r *SignerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	start := time.Now()
@@ -40,6 +65,11 @@ func (r *SignerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 
 	log := log.FromContext(ctx)
 
+	// Refresh the request gauges with whatever this reconcile changed
+	if r.RequestMetrics != nil {
+		defer r.RequestMetrics.Refresh()
+	}
+
 	var pcr certificatesv1beta1.PodCertificateRequest
 	if err := r.Get(ctx, req.NamespacedName, &pcr); err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
@@ -51,11 +81,15 @@ func (r *SignerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return ctrl.Result{}, nil
 	}
 
-	// 2. Filter: Is it already signed?
+	// 2. Filter: Is it already signed, denied or failed?
 	if len(pcr.Status.CertificateChain) > 0 {
 		log.V(1).Info("Certificate already exists", "name", req.Name)
 		return ctrl.Result{}, nil
 	}
+	if condition := terminalCondition(&pcr); condition != nil {
+		log.V(1).Info("Request already finished", "name", req.Name, "condition", condition.Type, "reason", condition.Reason)
+		return ctrl.Result{}, nil
+	}
 
 	// 3. Parse the Public Key from the PCR
 	log.V(1).Info("Parsing public key...", "name", req.Name)
@@ -64,9 +98,8 @@ func (r *SignerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	pub, err := x509.ParsePKIXPublicKey(pcr.Spec.PKIXPublicKey)
 	if err != nil {
 		log.Error(err, "Failed to parse PKIX public key")
-		// Set Failed condition and update status
-		r.setFailedCondition(ctx, &pcr, "InvalidPublicKey", fmt.Sprintf("Failed to parse public key: %v", err), req)
-		return ctrl.Result{}, fmt.Errorf("failed to parse PKIX public key: %w", err)
+		// A malformed key will never parse, so deny instead of retrying
+		return ctrl.Result{}, r.setDeniedCondition(ctx, &pcr, "InvalidPublicKey", fmt.Sprintf("Failed to parse public key: %v", err))
 	}
 
 	// Validate public key type (RSA or ECDSA)
@@ -76,8 +109,50 @@ func (r *SignerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	default:
 		errMsg := fmt.Sprintf("unsupported public key type: %T", pub)
 		log.Error(fmt.Errorf("unsupported key type"), errMsg)
-		r.setFailedCondition(ctx, &pcr, "UnsupportedKeyType", errMsg, req)
-		return ctrl.Result{}, fmt.Errorf("%s", errMsg)
+		return ctrl.Result{}, r.setDeniedCondition(ctx, &pcr, certificatesv1beta1.PodCertificateRequestConditionUnsupportedKeyType, errMsg)
+	}
+
+	// Evaluate CEL admission rules against the request, its Pod and Namespace
+	if r.AdmissionRules.Len() > 0 {
+		rule, err := r.evaluateAdmissionRules(ctx, &pcr, pub)
+		if rule != nil {
+			// A rule that errors on this input, e.g. on a missing label, does not
+			// hold and errors on every retry, so it denies like a false rule
+			if err != nil {
+				log.Info("Admission rule failed to evaluate", "rule", rule.Expression, "error", err.Error())
+			}
+			log.Info("Request denied by admission rule", "rule", rule.Expression)
+			return ctrl.Result{}, r.setDeniedCondition(ctx, &pcr, "AdmissionRuleFailed", rule.Message)
+		}
+		if err != nil {
+			log.Error(err, "Failed to evaluate admission rules")
+			return r.podLookupFailed(ctx, &pcr, err)
+		}
+	}
+
+	// Select the SignerPolicy governing this request, if policies are enabled
+	var policy *SignerPolicy
+	if r.Config != nil && r.Config.SignerPolicies {
+		policy, err = matchSignerPolicy(ctx, r.Client, &pcr)
+		if err != nil {
+			log.Error(err, "Failed to evaluate SignerPolicies")
+			return ctrl.Result{}, err
+		}
+		if policy != nil {
+			log.V(1).Info("Applying SignerPolicy", "policy", policy.Name)
+			// A broken policy is the admin's to fix; keep the request pending until then
+			if err := policy.Spec.validate(); err != nil {
+				errMsg := fmt.Sprintf("SignerPolicy %s is invalid: %v", policy.Name, err)
+				log.Error(err, "Invalid SignerPolicy, requeueing", "policy", policy.Name)
+				r.recordEvent(&pcr, corev1.EventTypeWarning, "PolicyInvalid", errMsg)
+				return ctrl.Result{}, fmt.Errorf("%s", errMsg)
+			}
+			if err := policy.Spec.checkPublicKey(pub); err != nil {
+				errMsg := fmt.Sprintf("SignerPolicy %s: %v", policy.Name, err)
+				log.Error(err, "Public key rejected by SignerPolicy", "policy", policy.Name)
+				return ctrl.Result{}, r.setDeniedCondition(ctx, &pcr, "KeyNotAllowed", errMsg)
+			}
+		}
 	}
 
 	// NOTE: According to KEP-4317, "Signer implementations do not need to verify
@@ -101,12 +176,19 @@ func (r *SignerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 			refreshBefore = r.Config.CertRefreshBefore
 		}
 	}
+	if policy != nil {
+		if policy.Spec.Validity != nil {
+			validity = policy.Spec.Validity.Duration
+		}
+		if policy.Spec.RefreshBefore != nil {
+			refreshBefore = policy.Spec.RefreshBefore.Duration
+		}
+	}
 
 	// Validate minimum cert validity (must be >= 1h per Kubernetes PCR API spec)
-	const minValidity = time.Hour
-	if validity < minValidity {
-		log.Info("WARN: CertValidity too low, using minimum", "configured", validity, "minimum", minValidity)
-		validity = minValidity
+	if validity < minCertValidity {
+		log.Info("WARN: CertValidity too low, using minimum", "configured", validity, "minimum", minCertValidity)
+		validity = minCertValidity
 	}
 
 	// Validate minimum refresh time (should be >= 30m to be practical)
@@ -124,73 +206,151 @@ func (r *SignerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		}
 	}
 
+	// The backend clamps notAfter to the CA it signs with
 	notAfter := now.Add(validity)
-	refreshAt := notAfter.Add(-refreshBefore)
-
-	// RefreshAt must be between NotBefore (now) and NotAfter
-	if refreshAt.Before(now) {
-		refreshAt = now
-	}
-
-	// SANs and Key Usage
-	dnsName := fmt.Sprintf("%s.pod.cluster.local", pcr.Spec.PodName)
+	backend := r.backend()
 
 	template := x509.Certificate{
 		SerialNumber: serialNumber,
-		Subject: pkix.Name{
-			CommonName: dnsName,
-		},
-		DNSNames:  []string{dnsName},
-		NotBefore: now,
-		NotAfter:  notAfter,
+		NotBefore:    now,
+		NotAfter:     notAfter,
 		// usually serverAuth + clientAuth
 		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
 		KeyUsage:    x509.KeyUsageDigitalSignature,
 	}
 
+	// SANs and Subject
+	if r.issuanceMode() == IssuanceModeSPIFFE {
+		id, err := spiffeID(r.Config.SPIFFETrustDomain, pcr.Namespace, pcr.Spec.ServiceAccountName)
+		if err != nil {
+			log.Error(err, "Failed to build SPIFFE ID")
+			return ctrl.Result{}, r.setFailedCondition(ctx, &pcr, "InvalidSPIFFEID", fmt.Sprintf("Failed to build SPIFFE ID: %v", err))
+		}
+		// X.509-SVID: exactly one URI SAN, empty subject, and an explicit
+		// non-CA basic constraints extension.
+		template.URIs = []*url.URL{id}
+		template.BasicConstraintsValid = true
+		template.IsCA = false
+	} else {
+		// Qualified by namespace, so same-named Pods in different namespaces get distinct names
+		dnsName := fmt.Sprintf("%s.%s.pod.%s", pcr.Spec.PodName, pcr.Namespace, r.clusterDomain())
+		template.Subject = pkix.Name{
+			CommonName: dnsName,
+		}
+		template.DNSNames = []string{dnsName}
+
+		if r.Config != nil && (r.Config.ServiceSANs || r.Config.PodIPSANs) {
+			pod, err := lookupPod(ctx, r.Client, &pcr)
+			if err != nil {
+				log.Error(err, "Failed to look up Pod for SANs")
+				return r.podLookupFailed(ctx, &pcr, err)
+			}
+
+			if r.Config.ServiceSANs {
+				resolver := &SANResolver{Reader: r.Client, ClusterDomain: r.clusterDomain()}
+				names, err := resolver.Resolve(ctx, pod)
+				if err != nil {
+					log.Error(err, "Failed to resolve Service SANs")
+					return ctrl.Result{}, err
+				}
+				template.DNSNames = appendUnique(template.DNSNames, names...)
+			}
+
+			if r.Config.PodIPSANs {
+				ips := podIPs(pod)
+				if len(ips) == 0 {
+					// Never issue without the IPs the client asked for; wait for the
+					// CNI to assign them, but only up to the configured bound.
+					if waited := now.Sub(pcr.CreationTimestamp.Time); waited < r.podIPWaitTimeout() {
+						log.Info("Pod has no IP yet, requeueing", "pod", pcr.Spec.PodName, "waited", waited)
+						return ctrl.Result{RequeueAfter: podIPRequeueInterval}, nil
+					}
+					errMsg := fmt.Sprintf("pod %s/%s has no IP address after %s", pcr.Namespace, pcr.Spec.PodName, r.podIPWaitTimeout())
+					log.Error(fmt.Errorf("pod IP unavailable"), errMsg)
+					return ctrl.Result{}, r.setFailedCondition(ctx, &pcr, "PodIPUnavailable", errMsg)
+				}
+				template.IPAddresses = ips
+			}
+		}
+	}
+
+	// Apply SignerPolicy extended key usages and SAN templates
+	if policy != nil {
+		if err := r.applyPolicy(ctx, &template, policy, &pcr); err != nil {
+			errMsg := fmt.Sprintf("SignerPolicy %s is invalid: %v", policy.Name, err)
+			log.Error(err, "Invalid SignerPolicy, requeueing", "policy", policy.Name)
+			r.recordEvent(&pcr, corev1.EventTypeWarning, "PolicyInvalid", errMsg)
+			return ctrl.Result{}, fmt.Errorf("%s", errMsg)
+		}
+	}
+
 	// For RSA keys, we might want to add KeyEncipherment as well,
 	// but the requirement only specified DigitalSignature.
 	if _, ok := pub.(*rsa.PublicKey); ok {
 		template.KeyUsage |= x509.KeyUsageKeyEncipherment
 	}
 
-	// Check if CA is initialized
-	if r.CA == nil {
+	// Check if CA is initialized. The CA may still be loading, so requeue
+	// rather than failing the request.
+	if backend.Issuer() == nil {
 		errMsg := "CA not initialized"
 		log.Error(fmt.Errorf("nil CA"), errMsg)
-		r.setFailedCondition(ctx, &pcr, "SigningFailed", errMsg, req)
+		r.recordEvent(&pcr, corev1.EventTypeWarning, "SigningFailed", errMsg)
 		return ctrl.Result{}, fmt.Errorf("%s", errMsg)
 	}
 
-	certBytes, err := x509.CreateCertificate(rand.Reader, &template, r.CA.GetCert(), pub, r.CA.GetKey())
+	// The chain is the leaf followed by the intermediates, so clients can build a path to the root
+	certPEM, err := backend.Issue(ctx, &template, pub)
 	if err != nil {
 		log.Error(err, "Failed to create certificate")
-		r.setFailedCondition(ctx, &pcr, "SigningFailed", fmt.Sprintf("Failed to create certificate: %v", err), req)
-		return ctrl.Result{}, err
+		r.recordEvent(&pcr, corev1.EventTypeWarning, "SigningFailed", fmt.Sprintf("Failed to create certificate: %v", err))
+		return ctrl.Result{}, fmt.Errorf("failed to create certificate: %w", err)
 	}
 
-	// Encode to PEM
-	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certBytes})
+	// The status times describe the issued leaf, which backends like Vault may
+	// backdate or clamp, rather than the template
+	chain, err := parseCertificatesPEM(certPEM)
+	if err != nil {
+		log.Error(err, "Signing backend returned an invalid chain")
+		r.recordEvent(&pcr, corev1.EventTypeWarning, "SigningFailed", fmt.Sprintf("Signing backend returned an invalid chain: %v", err))
+		return ctrl.Result{}, fmt.Errorf("signing backend returned an invalid chain: %w", err)
+	}
+	leaf := chain[0]
+	if leaf.NotAfter.Before(notAfter) {
+		log.Info("WARN: Certificate validity clamped by the signing backend", "notAfter", leaf.NotAfter)
+	}
+	notAfter = leaf.NotAfter
+	refreshAt := notAfter.Add(-refreshBefore)
+
+	// RefreshAt must be between NotBefore and NotAfter
+	if refreshAt.Before(leaf.NotBefore) {
+		refreshAt = leaf.NotBefore
+	}
 
 	// 5. Update Status
 	// Note: status.certificateChain expects RAW PEM string, not base64 encoded
 	pcr.Status.CertificateChain = string(certPEM)
 
 	// Set the required time fields
-	metaNow := metav1.NewTime(now)
+	metaBefore := metav1.NewTime(leaf.NotBefore)
 	metaRefresh := metav1.NewTime(refreshAt)
 	metaAfter := metav1.NewTime(notAfter)
 
-	pcr.Status.NotBefore = &metaNow
+	pcr.Status.NotBefore = &metaBefore
 	pcr.Status.NotAfter = &metaAfter
 	pcr.Status.BeginRefreshAt = &metaRefresh
 
+	issuedMessage := "Signed by NovoG93 Signer Controller"
+	if policy != nil {
+		issuedMessage = fmt.Sprintf("%s (policy: %s)", issuedMessage, policy.Name)
+	}
+
 	pcr.Status.Conditions = []metav1.Condition{
 		{
-			Type:               "Issued",
+			Type:               certificatesv1beta1.PodCertificateRequestConditionTypeIssued,
 			Status:             metav1.ConditionTrue,
 			Reason:             "IssuedByGoController",
-			Message:            "Signed by NovoG93 Signer Controller",
+			Message:            issuedMessage,
 			LastTransitionTime: metav1.Now(),
 		},
 	}
@@ -209,6 +369,7 @@ func (r *SignerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	}
 
 	log.Info("Certificate issued", "pod", req.Name, "node", pcr.Spec.NodeName)
+	r.recordEvent(&pcr, corev1.EventTypeNormal, "Issued", fmt.Sprintf("%s, valid until %s", issuedMessage, notAfter.UTC().Format(time.RFC3339)))
 
 	// Record metrics
 	validityStr := validity.String()
@@ -217,14 +378,154 @@ func (r *SignerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	return ctrl.Result{}, nil
 }
 
-// setFailedCondition sets a Failed condition on the PCR and updates its status
-func (r *SignerReconciler) setFailedCondition(ctx context.Context, pcr *certificatesv1beta1.PodCertificateRequest, reason, message string, req ctrl.Request) {
-	log := log.FromContext(ctx)
+// publicKeyInfo returns the algorithm name and size in bits (RSA modulus or ECDSA curve) of a public key
+func publicKeyInfo(pub crypto.PublicKey) (string, int32, error) {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return "RSA", int32(k.N.BitLen()), nil
+	case *ecdsa.PublicKey:
+		return "ECDSA", int32(k.Curve.Params().BitSize), nil
+	default:
+		return "", 0, fmt.Errorf("unsupported public key type: %T", pub)
+	}
+}
+
+// backend returns the configured signing backend, defaulting to signing in process with CA
+func (r *SignerReconciler) backend() SigningBackend {
+	if r.Backend != nil {
+		return r.Backend
+	}
+	policy := ""
+	if r.Config != nil {
+		policy = r.Config.CALoadFailurePolicy
+	}
+	return &LocalSigningBackend{CA: r.CA, LoadFailurePolicy: policy}
+}
+
+// issuanceMode returns the configured issuance mode, defaulting to pod DNS names
+func (r *SignerReconciler) issuanceMode() string {
+	if r.Config == nil || r.Config.IssuanceMode == "" {
+		return IssuanceModePodDNS
+	}
+	return r.Config.IssuanceMode
+}
+
+// clusterDomain returns the configured cluster DNS domain
+func (r *SignerReconciler) clusterDomain() string {
+	if r.Config == nil || r.Config.ClusterDomain == "" {
+		return defaultClusterDomain
+	}
+	return r.Config.ClusterDomain
+}
+
+// podIPWaitTimeout returns how long a request may wait for its Pod to get an IP address
+func (r *SignerReconciler) podIPWaitTimeout() time.Duration {
+	if r.Config == nil || r.Config.PodIPWaitTimeout <= 0 {
+		return defaultPodIPWaitTimeout
+	}
+	return r.Config.PodIPWaitTimeout
+}
+
+// applyPolicy applies a SignerPolicy's extended key usages and SAN templates to the certificate template
+func (r *SignerReconciler) applyPolicy(ctx context.Context, template *x509.Certificate, policy *SignerPolicy, pcr *certificatesv1beta1.PodCertificateRequest) error {
+	usages, err := policy.Spec.extKeyUsages()
+	if err != nil {
+		return err
+	}
+	dnsNames, uris, err := policy.Spec.renderSANs(sanTemplateData{
+		PodName:            pcr.Spec.PodName,
+		PodUID:             string(pcr.Spec.PodUID),
+		Namespace:          pcr.Namespace,
+		ServiceAccountName: pcr.Spec.ServiceAccountName,
+		NodeName:           string(pcr.Spec.NodeName),
+		ClusterDomain:      r.clusterDomain(),
+	})
+	if err != nil {
+		return err
+	}
 
+	if usages != nil {
+		template.ExtKeyUsage = usages
+	}
+	template.DNSNames = appendUnique(template.DNSNames, dnsNames...)
+	if len(uris) > 0 && r.issuanceMode() == IssuanceModeSPIFFE {
+		// An X.509-SVID must carry exactly one URI SAN
+		log.FromContext(ctx).Info("Ignoring SignerPolicy URI SANs in SPIFFE mode", "policy", policy.Name)
+		return nil
+	}
+	template.URIs = append(template.URIs, uris...)
+	return nil
+}
+
+// evaluateAdmissionRules looks up the Pod and Namespace of the request and runs the admission rules
+func (r *SignerReconciler) evaluateAdmissionRules(ctx context.Context, pcr *certificatesv1beta1.PodCertificateRequest, pub crypto.PublicKey) (*AdmissionRule, error) {
+	pod, err := lookupPod(ctx, r.Client, pcr)
+	if err != nil {
+		return nil, err
+	}
+	var ns corev1.Namespace
+	if err := r.Get(ctx, types.NamespacedName{Name: pcr.Namespace}, &ns); err != nil {
+		return nil, fmt.Errorf("failed to get namespace %s: %w", pcr.Namespace, err)
+	}
+	algorithm, size, err := publicKeyInfo(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.AdmissionRules.Evaluate(admissionInput{
+		PCR:          pcr,
+		Pod:          pod,
+		Namespace:    &ns,
+		KeyAlgorithm: algorithm,
+		KeySize:      size,
+	})
+}
+
+// podLookupFailed fails the PCR when its Pod is gone or was recreated, once the
+// cache had podLookupGracePeriod to catch up. Other errors are retried with backoff.
+func (r *SignerReconciler) podLookupFailed(ctx context.Context, pcr *certificatesv1beta1.PodCertificateRequest, err error) (ctrl.Result, error) {
+	var lookupErr *podLookupError
+	if !errors.As(err, &lookupErr) {
+		return ctrl.Result{}, err
+	}
+	if time.Since(pcr.CreationTimestamp.Time) < podLookupGracePeriod {
+		return ctrl.Result{RequeueAfter: podIPRequeueInterval}, nil
+	}
+	return ctrl.Result{}, r.setFailedCondition(ctx, pcr, lookupErr.Reason, lookupErr.Error())
+}
+
+// terminalCondition returns the Denied or Failed condition of a PCR, if any.
+// Per the PodCertificateRequest API these are final, so such requests are never retried.
+func terminalCondition(pcr *certificatesv1beta1.PodCertificateRequest) *metav1.Condition {
+	for _, conditionType := range []string{
+		certificatesv1beta1.PodCertificateRequestConditionTypeDenied,
+		certificatesv1beta1.PodCertificateRequestConditionTypeFailed,
+	} {
+		if condition := meta.FindStatusCondition(pcr.Status.Conditions, conditionType); condition != nil && condition.Status == metav1.ConditionTrue {
+			return condition
+		}
+	}
+	return nil
+}
+
+// setDeniedCondition marks the PCR as denied: the signer refuses to issue for it.
+// Denials are final; the returned error is only set if the status update failed.
+func (r *SignerReconciler) setDeniedCondition(ctx context.Context, pcr *certificatesv1beta1.PodCertificateRequest, reason, message string) error {
+	return r.setTerminalCondition(ctx, pcr, certificatesv1beta1.PodCertificateRequestConditionTypeDenied, reason, message)
+}
+
+// setFailedCondition marks the PCR as failed: the signer accepted the request but
+// can never issue for it. Failures are final; the returned error is only set if
+// the status update failed.
+func (r *SignerReconciler) setFailedCondition(ctx context.Context, pcr *certificatesv1beta1.PodCertificateRequest, reason, message string) error {
+	return r.setTerminalCondition(ctx, pcr, certificatesv1beta1.PodCertificateRequestConditionTypeFailed, reason, message)
+}
+
+func (r *SignerReconciler) setTerminalCondition(ctx context.Context, pcr *certificatesv1beta1.PodCertificateRequest, conditionType, reason, message string) error {
 	pcr.Status.Conditions = []metav1.Condition{
 		{
-			Type:               "Issued",
-			Status:             metav1.ConditionFalse,
+			Type:               conditionType,
+			Status:             metav1.ConditionTrue,
 			Reason:             reason,
 			Message:            message,
 			LastTransitionTime: metav1.Now(),
@@ -232,11 +533,30 @@ func (r *SignerReconciler) setFailedCondition(ctx context.Context, pcr *certific
 	}
 
 	if err := r.Status().Update(ctx, pcr); err != nil {
-		log.Error(err, "Failed to update status with error condition", "reason", reason)
+		log.FromContext(ctx).Error(err, "Failed to update status with terminal condition", "condition", conditionType, "reason", reason)
+		return fmt.Errorf("failed to update status with %s condition: %w", conditionType, err)
 	}
 
+	r.recordEvent(pcr, corev1.EventTypeWarning, reason, message)
+
 	// Record metrics
 	FailedCounter.WithLabelValues(reason).Inc()
+	return nil
+}
+
+// recordEvent emits an event regarding the PCR, related to the Pod it was requested for
+func (r *SignerReconciler) recordEvent(pcr *certificatesv1beta1.PodCertificateRequest, eventType, reason, note string) {
+	if r.Recorder == nil {
+		return
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pcr.Spec.PodName,
+			Namespace: pcr.Namespace,
+			UID:       pcr.Spec.PodUID,
+		},
+	}
+	r.Recorder.Eventf(pcr, pod, eventType, reason, eventActionSign, "%s", note)
 }
 
 // Boilerplate to setup the watch
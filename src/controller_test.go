@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
@@ -18,6 +19,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -113,7 +115,7 @@ var _ = Describe("CA Helper", func() {
 			Expect(ca.Key).NotTo(BeNil())
 
 			// Verify key size is 2048 bits
-			Expect(ca.Key.PublicKey.N.BitLen()).To(Equal(2048))
+			Expect(ca.Key.(*rsa.PrivateKey).N.BitLen()).To(Equal(2048))
 		})
 	})
 
@@ -442,7 +444,7 @@ var _ = Describe("Reconciler Error Handling", func() {
 	})
 
 	Describe("TestReconcile_InvalidPublicKey", func() {
-		It("should return an error when public key is invalid", func() {
+		It("should deny without requeueing when public key is invalid", func() {
 			pcr := &certificatesv1beta1.PodCertificateRequest{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "test-pcr-invalid-key",
@@ -465,6 +467,7 @@ var _ = Describe("Reconciler Error Handling", func() {
 			fakeClient := fake.NewClientBuilder().
 				WithScheme(scheme).
 				WithObjects(pcr).
+				WithStatusSubresource(pcr).
 				Build()
 
 			// Create reconciler with fake client
@@ -485,9 +488,8 @@ var _ = Describe("Reconciler Error Handling", func() {
 				},
 			})
 
-			// Should return error
-			Expect(err).To(HaveOccurred())
-			Expect(err.Error()).To(ContainSubstring("failed to parse PKIX public key"))
+			// A malformed key is terminal, so no error is returned
+			Expect(err).NotTo(HaveOccurred())
 		})
 	})
 
@@ -600,7 +602,7 @@ var _ = Describe("Reconciler Error Handling", func() {
 	})
 
 	Describe("TestReconcile_ParseKeyError_SetsCondition", func() {
-		It("should set Failed condition when public key parsing fails", func() {
+		It("should set Denied condition when public key parsing fails", func() {
 			pcr := &certificatesv1beta1.PodCertificateRequest{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "test-pcr-invalid-key-condition",
@@ -644,8 +646,8 @@ var _ = Describe("Reconciler Error Handling", func() {
 				},
 			})
 
-			// Should return error but also set condition
-			Expect(err).To(HaveOccurred())
+			// Should not requeue but set condition
+			Expect(err).NotTo(HaveOccurred())
 
 			// Retrieve the PCR and check condition was set
 			retrieved := &certificatesv1beta1.PodCertificateRequest{}
@@ -655,22 +657,22 @@ var _ = Describe("Reconciler Error Handling", func() {
 			}, retrieved)
 			Expect(errGet).NotTo(HaveOccurred())
 
-			// Status should have a Failed condition
+			// Status should have a Denied condition
 			Expect(retrieved.Status.Conditions).NotTo(HaveLen(0))
 			foundCondition := false
 			for _, cond := range retrieved.Status.Conditions {
-				if cond.Type == "Issued" && cond.Status == metav1.ConditionFalse {
+				if cond.Type == certificatesv1beta1.PodCertificateRequestConditionTypeDenied && cond.Status == metav1.ConditionTrue {
 					foundCondition = true
 					Expect(cond.Reason).To(Equal("InvalidPublicKey"))
 					break
 				}
 			}
-			Expect(foundCondition).To(BeTrue(), "Expected to find Failed Issued condition with InvalidPublicKey reason")
+			Expect(foundCondition).To(BeTrue(), "Expected to find Denied condition with InvalidPublicKey reason")
 		})
 	})
 
-	Describe("TestReconcile_SigningError_SetsCondition", func() {
-		It("should set Failed condition when certificate signing fails", func() {
+	Describe("TestReconcile_NilCA_Requeues", func() {
+		It("should requeue without a condition when the CA is not ready", func() {
 			pubKeyDER, privKey, err := generateTestPublicKeyDER()
 			Expect(err).NotTo(HaveOccurred())
 			pop, err := generateRSASignature(pubKeyDER, privKey)
@@ -726,17 +728,9 @@ var _ = Describe("Reconciler Error Handling", func() {
 			}, retrieved)
 			Expect(errGet).NotTo(HaveOccurred())
 
-			// Status should have a Failed condition
-			Expect(retrieved.Status.Conditions).NotTo(HaveLen(0))
-			foundCondition := false
-			for _, cond := range retrieved.Status.Conditions {
-				if cond.Type == "Issued" && cond.Status == metav1.ConditionFalse {
-					foundCondition = true
-					Expect(cond.Reason).To(Equal("SigningFailed"))
-					break
-				}
-			}
-			Expect(foundCondition).To(BeTrue(), "Expected to find Failed Issued condition with SigningFailed reason")
+			// A transient failure must not leave a terminal condition behind
+			Expect(retrieved.Status.Conditions).To(BeEmpty())
+			Expect(retrieved.Status.CertificateChain).To(BeEmpty())
 		})
 	})
 
@@ -812,6 +806,127 @@ var _ = Describe("Reconciler Error Handling", func() {
 			Expect(callCount).To(BeNumerically(">=", 1), "Mock should be called at least once")
 		})
 	})
+
+	Describe("TestReconcile_UnsupportedKeyType_Denies", func() {
+		It("should set a Denied condition with the API's UnsupportedKeyType reason", func() {
+			edPub, _, err := ed25519.GenerateKey(rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+			pubKeyDER, err := x509.MarshalPKIXPublicKey(edPub)
+			Expect(err).NotTo(HaveOccurred())
+
+			pcr := &certificatesv1beta1.PodCertificateRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-pcr-ed25519",
+					Namespace: "default",
+				},
+				Spec: certificatesv1beta1.PodCertificateRequestSpec{
+					SignerName:         "novog93.ghcr/signer",
+					PodName:            "test-pod",
+					PodUID:             "test-uid",
+					NodeName:           "test-node",
+					NodeUID:            "test-node-uid",
+					ServiceAccountName: "default",
+					ServiceAccountUID:  "sa-uid",
+					PKIXPublicKey:      pubKeyDER,
+					ProofOfPossession:  []byte("some-proof"),
+				},
+			}
+
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(pcr).
+				WithStatusSubresource(pcr).
+				Build()
+
+			ca, err := NewCA()
+			Expect(err).NotTo(HaveOccurred())
+
+			reconciler := &SignerReconciler{
+				Client:     fakeClient,
+				CA:         ca,
+				SignerName: "novog93.ghcr/signer",
+			}
+
+			result, err := reconciler.Reconcile(ctx, ctrl.Request{
+				NamespacedName: types.NamespacedName{Name: "test-pcr-ed25519", Namespace: "default"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(ctrl.Result{}))
+
+			retrieved := &certificatesv1beta1.PodCertificateRequest{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "test-pcr-ed25519", Namespace: "default"}, retrieved)).To(Succeed())
+			Expect(retrieved.Status.Conditions).To(HaveLen(1))
+			Expect(retrieved.Status.Conditions[0].Type).To(Equal(certificatesv1beta1.PodCertificateRequestConditionTypeDenied))
+			Expect(retrieved.Status.Conditions[0].Status).To(Equal(metav1.ConditionTrue))
+			Expect(retrieved.Status.Conditions[0].Reason).To(Equal(certificatesv1beta1.PodCertificateRequestConditionUnsupportedKeyType))
+		})
+	})
+
+	Describe("TestReconcile_SkipsTerminalConditions", func() {
+		It("should not sign or update a request that already failed", func() {
+			pubKeyDER, privKey, err := generateTestPublicKeyDER()
+			Expect(err).NotTo(HaveOccurred())
+			pop, err := generateRSASignature(pubKeyDER, privKey)
+			Expect(err).NotTo(HaveOccurred())
+
+			pcr := &certificatesv1beta1.PodCertificateRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-pcr-already-failed",
+					Namespace: "default",
+				},
+				Spec: certificatesv1beta1.PodCertificateRequestSpec{
+					SignerName:         "novog93.ghcr/signer",
+					PodName:            "test-pod",
+					PodUID:             "test-uid",
+					NodeName:           "test-node",
+					NodeUID:            "test-node-uid",
+					ServiceAccountName: "default",
+					ServiceAccountUID:  "sa-uid",
+					PKIXPublicKey:      pubKeyDER,
+					ProofOfPossession:  []byte(pop),
+				},
+				Status: certificatesv1beta1.PodCertificateRequestStatus{
+					Conditions: []metav1.Condition{{
+						Type:               certificatesv1beta1.PodCertificateRequestConditionTypeFailed,
+						Status:             metav1.ConditionTrue,
+						Reason:             "PodIPUnavailable",
+						LastTransitionTime: metav1.Now(),
+					}},
+				},
+			}
+
+			innerClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(pcr).
+				WithStatusSubresource(pcr).
+				Build()
+
+			updates := 0
+			mockClient := &MockClient{
+				Client: innerClient,
+				MockStatusUpdate: func(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+					updates++
+					return innerClient.Status().Update(ctx, obj, opts...)
+				},
+			}
+
+			ca, err := NewCA()
+			Expect(err).NotTo(HaveOccurred())
+
+			reconciler := &SignerReconciler{
+				Client:     mockClient,
+				CA:         ca,
+				SignerName: "novog93.ghcr/signer",
+			}
+
+			result, err := reconciler.Reconcile(ctx, ctrl.Request{
+				NamespacedName: types.NamespacedName{Name: "test-pcr-already-failed", Namespace: "default"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(ctrl.Result{}))
+			Expect(updates).To(BeZero())
+		})
+	})
 })
 
 var _ = Describe("Reconciler Policy", func() {
@@ -1247,7 +1362,44 @@ var _ = Describe("SANs and Key Types", func() {
 		Expect(err).NotTo(HaveOccurred())
 
 		// Verify DNS SANs
-		Expect(cert.DNSNames).To(ContainElement("test-pod-dns.pod.cluster.local"))
+		Expect(cert.DNSNames).To(ContainElement("test-pod-dns.default.pod.cluster.local"))
+	})
+
+	It("SignCertificate_DNSSANIsNamespaceQualified", func() {
+		// Same-named Pods in different namespaces must not share a DNS SAN
+		var names []string
+		for _, namespace := range []string{"team-a", "team-b"} {
+			pubKey, privKey, err := generateTestPublicKeyDER()
+			Expect(err).NotTo(HaveOccurred())
+			pop, err := generateRSASignature(pubKey, privKey)
+			Expect(err).NotTo(HaveOccurred())
+
+			pcr := &certificatesv1beta1.PodCertificateRequest{
+				ObjectMeta: metav1.ObjectMeta{Name: "nstest", Namespace: namespace},
+				Spec: certificatesv1beta1.PodCertificateRequestSpec{
+					SignerName:         "novog93.ghcr/signer",
+					PodName:            "api-0",
+					PKIXPublicKey:      pubKey,
+					NodeName:           "node1",
+					NodeUID:            "node-uid",
+					PodUID:             "pod-uid",
+					ServiceAccountName: "sa",
+					ServiceAccountUID:  "sa-uid",
+					ProofOfPossession:  []byte(pop),
+				},
+			}
+
+			reconciler.Client = clientFunc(pcr)
+			_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
+			Expect(err).NotTo(HaveOccurred())
+
+			retrieved := &certificatesv1beta1.PodCertificateRequest{}
+			Expect(reconciler.Client.Get(ctx, types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}, retrieved)).To(Succeed())
+			cert, err := parseCertificateFromStatus(retrieved.Status.CertificateChain)
+			Expect(err).NotTo(HaveOccurred())
+			names = append(names, cert.DNSNames...)
+		}
+		Expect(names).To(Equal([]string{"api-0.team-a.pod.cluster.local", "api-0.team-b.pod.cluster.local"}))
 	})
 
 	It("SignCertificate_SetsSubjectCNToPodName", func() {
@@ -1285,7 +1437,7 @@ var _ = Describe("SANs and Key Types", func() {
 		Expect(err).NotTo(HaveOccurred())
 
 		// Verify CommonName
-		Expect(cert.Subject.CommonName).To(Equal("my-pod-cn.pod.cluster.local"))
+		Expect(cert.Subject.CommonName).To(Equal("my-pod-cn.default.pod.cluster.local"))
 	})
 
 	It("SignCertificate_SupportsRSAKeys", func() {
@@ -1538,12 +1690,9 @@ var _ = Describe("Metrics", func() {
 			WithStatusSubresource(pcr).
 			Build()
 
-		// Reconciliation will fail with InvalidPublicKey
+		// Reconciliation will deny the request with InvalidPublicKey
 		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
-
-		// Verify the error occurred
-		Expect(err).To(HaveOccurred())
-		Expect(err.Error()).To(ContainSubstring("failed to parse PKIX public key"))
+		Expect(err).NotTo(HaveOccurred())
 
 		// Retrieve the PCR to get the actual reason from the condition
 		retrieved := &certificatesv1beta1.PodCertificateRequest{}
@@ -1558,3 +1707,986 @@ var _ = Describe("Metrics", func() {
 		Expect(endVal).To(BeNumerically(">", 0))
 	})
 })
+
+var _ = Describe("SPIFFE Issuance", func() {
+	var (
+		scheme     *runtime.Scheme
+		ctx        context.Context
+		reconciler *SignerReconciler
+		pcr        *certificatesv1beta1.PodCertificateRequest
+	)
+
+	BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		utilruntime.Must(certificatesv1beta1.AddToScheme(scheme))
+		utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+		ctx = context.Background()
+
+		ca, err := NewCA()
+		Expect(err).NotTo(HaveOccurred())
+
+		reconciler = &SignerReconciler{
+			CA:         ca,
+			SignerName: "novog93.ghcr/signer",
+			Config: &Config{
+				IssuanceMode:      IssuanceModeSPIFFE,
+				SPIFFETrustDomain: "example.org",
+			},
+		}
+
+		pubKey, privKey, err := generateTestPublicKeyDERECDSA()
+		Expect(err).NotTo(HaveOccurred())
+		pop, err := generateECDSASignature(pubKey, privKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		pcr = &certificatesv1beta1.PodCertificateRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "spiffe-pcr", Namespace: "payments"},
+			Spec: certificatesv1beta1.PodCertificateRequestSpec{
+				SignerName:         "novog93.ghcr/signer",
+				PodName:            "api-0",
+				PKIXPublicKey:      pubKey,
+				NodeName:           "node1",
+				NodeUID:            "node-uid",
+				PodUID:             "pod-uid",
+				ServiceAccountName: "api",
+				ServiceAccountUID:  "sa-uid",
+				ProofOfPossession:  []byte(pop),
+			},
+		}
+	})
+
+	It("SignCertificate_SPIFFE_SetsURISANAndEmptySubject", func() {
+		reconciler.Client = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(pcr).
+			WithStatusSubresource(pcr).
+			Build()
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
+		Expect(err).NotTo(HaveOccurred())
+
+		retrieved := &certificatesv1beta1.PodCertificateRequest{}
+		Expect(reconciler.Client.Get(ctx, types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}, retrieved)).To(Succeed())
+
+		cert, err := parseCertificateFromStatus(retrieved.Status.CertificateChain)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(cert.URIs).To(HaveLen(1))
+		Expect(cert.URIs[0].String()).To(Equal("spiffe://example.org/ns/payments/sa/api"))
+		Expect(cert.DNSNames).To(BeEmpty())
+		Expect(cert.Subject.String()).To(BeEmpty())
+		Expect(cert.BasicConstraintsValid).To(BeTrue())
+		Expect(cert.IsCA).To(BeFalse())
+		Expect(cert.KeyUsage & x509.KeyUsageDigitalSignature).To(Equal(x509.KeyUsageDigitalSignature))
+		Expect(cert.KeyUsage & (x509.KeyUsageCertSign | x509.KeyUsageCRLSign)).To(BeZero())
+	})
+
+	It("SignCertificate_SPIFFE_InvalidTrustDomain_SetsCondition", func() {
+		reconciler.Config.SPIFFETrustDomain = "Invalid Domain"
+		reconciler.Client = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(pcr).
+			WithStatusSubresource(pcr).
+			Build()
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
+		Expect(err).NotTo(HaveOccurred())
+
+		retrieved := &certificatesv1beta1.PodCertificateRequest{}
+		Expect(reconciler.Client.Get(ctx, types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}, retrieved)).To(Succeed())
+		Expect(retrieved.Status.CertificateChain).To(BeEmpty())
+		Expect(retrieved.Status.Conditions).To(HaveLen(1))
+		Expect(retrieved.Status.Conditions[0].Type).To(Equal(certificatesv1beta1.PodCertificateRequestConditionTypeFailed))
+		Expect(retrieved.Status.Conditions[0].Reason).To(Equal("InvalidSPIFFEID"))
+	})
+})
+
+var _ = Describe("Service SANs", func() {
+	var (
+		scheme *runtime.Scheme
+		ctx    context.Context
+		pcr    *certificatesv1beta1.PodCertificateRequest
+		pod    *corev1.Pod
+	)
+
+	BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		utilruntime.Must(certificatesv1beta1.AddToScheme(scheme))
+		utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+		ctx = context.Background()
+
+		pubKey, privKey, err := generateTestPublicKeyDER()
+		Expect(err).NotTo(HaveOccurred())
+		pop, err := generateRSASignature(pubKey, privKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		pod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "web-1",
+				Namespace: "shop",
+				UID:       "pod-uid",
+				Labels:    map[string]string{"app": "web"},
+			},
+		}
+		pcr = &certificatesv1beta1.PodCertificateRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "svc-sans", Namespace: "shop"},
+			Spec: certificatesv1beta1.PodCertificateRequestSpec{
+				SignerName:         "novog93.ghcr/signer",
+				PodName:            "web-1",
+				PodUID:             "pod-uid",
+				PKIXPublicKey:      pubKey,
+				NodeName:           "node1",
+				NodeUID:            "node-uid",
+				ServiceAccountName: "sa",
+				ServiceAccountUID:  "sa-uid",
+				ProofOfPossession:  []byte(pop),
+			},
+		}
+	})
+
+	It("SignCertificate_AddsServiceDNSSANs", func() {
+		svc := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "web"}},
+		}
+
+		ca, err := NewCA()
+		Expect(err).NotTo(HaveOccurred())
+
+		reconciler := &SignerReconciler{
+			Client: fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(pcr, pod, svc).
+				WithStatusSubresource(pcr).
+				Build(),
+			CA:         ca,
+			SignerName: "novog93.ghcr/signer",
+			Config:     &Config{ServiceSANs: true, ClusterDomain: "corp.local"},
+		}
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
+		Expect(err).NotTo(HaveOccurred())
+
+		retrieved := &certificatesv1beta1.PodCertificateRequest{}
+		Expect(reconciler.Client.Get(ctx, types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}, retrieved)).To(Succeed())
+
+		cert, err := parseCertificateFromStatus(retrieved.Status.CertificateChain)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cert.Subject.CommonName).To(Equal("web-1.shop.pod.corp.local"))
+		Expect(cert.DNSNames).To(Equal([]string{
+			"web-1.shop.pod.corp.local",
+			"web.shop.svc",
+			"web.shop.svc.corp.local",
+		}))
+	})
+
+	It("SignCertificate_MissingPod_Requeues", func() {
+		// A young request may just be ahead of the Pod cache
+		pcr.CreationTimestamp = metav1.Now()
+		ca, err := NewCA()
+		Expect(err).NotTo(HaveOccurred())
+
+		reconciler := &SignerReconciler{
+			Client: fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(pcr).
+				WithStatusSubresource(pcr).
+				Build(),
+			CA:         ca,
+			SignerName: "novog93.ghcr/signer",
+			Config:     &Config{ServiceSANs: true},
+		}
+
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(podIPRequeueInterval))
+
+		retrieved := &certificatesv1beta1.PodCertificateRequest{}
+		Expect(reconciler.Client.Get(ctx, types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}, retrieved)).To(Succeed())
+		Expect(retrieved.Status.CertificateChain).To(BeEmpty())
+		Expect(retrieved.Status.Conditions).To(BeEmpty())
+	})
+
+	It("SignCertificate_MissingOrRecreatedPod_Fails", func() {
+		pcr.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Minute))
+		recreated := pod.DeepCopy()
+		recreated.UID = "new-pod-uid"
+		ca, err := NewCA()
+		Expect(err).NotTo(HaveOccurred())
+
+		for reason, objs := range map[string][]client.Object{
+			"PodNotFound":    {pcr.DeepCopy()},
+			"PodUIDMismatch": {pcr.DeepCopy(), recreated},
+		} {
+			reconciler := &SignerReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(objs...).
+					WithStatusSubresource(pcr).
+					Build(),
+				CA:         ca,
+				SignerName: "novog93.ghcr/signer",
+				Config:     &Config{ServiceSANs: true},
+			}
+
+			result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
+			Expect(err).NotTo(HaveOccurred(), reason)
+			Expect(result).To(Equal(ctrl.Result{}), reason)
+
+			retrieved := &certificatesv1beta1.PodCertificateRequest{}
+			Expect(reconciler.Client.Get(ctx, types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}, retrieved)).To(Succeed())
+			Expect(retrieved.Status.Conditions).To(HaveLen(1), reason)
+			Expect(retrieved.Status.Conditions[0].Type).To(Equal(certificatesv1beta1.PodCertificateRequestConditionTypeFailed), reason)
+			Expect(retrieved.Status.Conditions[0].Reason).To(Equal(reason))
+		}
+	})
+})
+
+var _ = Describe("Pod IP SANs", func() {
+	var (
+		scheme *runtime.Scheme
+		ctx    context.Context
+		pcr    *certificatesv1beta1.PodCertificateRequest
+		pod    *corev1.Pod
+	)
+
+	BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		utilruntime.Must(certificatesv1beta1.AddToScheme(scheme))
+		utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+		ctx = context.Background()
+
+		pubKey, privKey, err := generateTestPublicKeyDER()
+		Expect(err).NotTo(HaveOccurred())
+		pop, err := generateRSASignature(pubKey, privKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		pod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "grpc-0", Namespace: "default", UID: "pod-uid"},
+		}
+		pcr = &certificatesv1beta1.PodCertificateRequest{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "pod-ip-sans",
+				Namespace:         "default",
+				CreationTimestamp: metav1.Now(),
+			},
+			Spec: certificatesv1beta1.PodCertificateRequestSpec{
+				SignerName:         "novog93.ghcr/signer",
+				PodName:            "grpc-0",
+				PodUID:             "pod-uid",
+				PKIXPublicKey:      pubKey,
+				NodeName:           "node1",
+				NodeUID:            "node-uid",
+				ServiceAccountName: "sa",
+				ServiceAccountUID:  "sa-uid",
+				ProofOfPossession:  []byte(pop),
+			},
+		}
+	})
+
+	newReconciler := func(objs ...client.Object) *SignerReconciler {
+		ca, err := NewCA()
+		Expect(err).NotTo(HaveOccurred())
+		return &SignerReconciler{
+			Client: fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(objs...).
+				WithStatusSubresource(pcr).
+				Build(),
+			CA:         ca,
+			SignerName: "novog93.ghcr/signer",
+			Config:     &Config{PodIPSANs: true, PodIPWaitTimeout: time.Minute},
+		}
+	}
+
+	It("SignCertificate_AddsPodIPSANs", func() {
+		pod.Status.PodIPs = []corev1.PodIP{{IP: "10.1.2.3"}, {IP: "fd00::1:2:3"}}
+		reconciler := newReconciler(pcr, pod)
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
+		Expect(err).NotTo(HaveOccurred())
+
+		retrieved := &certificatesv1beta1.PodCertificateRequest{}
+		Expect(reconciler.Client.Get(ctx, types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}, retrieved)).To(Succeed())
+
+		cert, err := parseCertificateFromStatus(retrieved.Status.CertificateChain)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cert.IPAddresses).To(HaveLen(2))
+		Expect(cert.IPAddresses[0].String()).To(Equal("10.1.2.3"))
+		Expect(cert.IPAddresses[1].String()).To(Equal("fd00::1:2:3"))
+	})
+
+	It("SignCertificate_RequeuesWhilePodHasNoIP", func() {
+		reconciler := newReconciler(pcr, pod)
+
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(podIPRequeueInterval))
+
+		retrieved := &certificatesv1beta1.PodCertificateRequest{}
+		Expect(reconciler.Client.Get(ctx, types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}, retrieved)).To(Succeed())
+		Expect(retrieved.Status.CertificateChain).To(BeEmpty())
+		Expect(retrieved.Status.Conditions).To(BeEmpty())
+	})
+
+	It("SignCertificate_FailsAfterPodIPWaitTimeout", func() {
+		pcr.CreationTimestamp = metav1.NewTime(time.Now().Add(-2 * time.Minute))
+		reconciler := newReconciler(pcr, pod)
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
+		Expect(err).NotTo(HaveOccurred())
+
+		retrieved := &certificatesv1beta1.PodCertificateRequest{}
+		Expect(reconciler.Client.Get(ctx, types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}, retrieved)).To(Succeed())
+		Expect(retrieved.Status.CertificateChain).To(BeEmpty())
+		Expect(retrieved.Status.Conditions).To(HaveLen(1))
+		Expect(retrieved.Status.Conditions[0].Type).To(Equal(certificatesv1beta1.PodCertificateRequestConditionTypeFailed))
+		Expect(retrieved.Status.Conditions[0].Reason).To(Equal("PodIPUnavailable"))
+	})
+})
+
+var _ = Describe("SignerPolicy", func() {
+	var (
+		scheme *runtime.Scheme
+		ctx    context.Context
+		pcr    *certificatesv1beta1.PodCertificateRequest
+	)
+
+	BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		utilruntime.Must(certificatesv1beta1.AddToScheme(scheme))
+		utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+		utilruntime.Must(AddSignerPolicyToScheme(scheme))
+		ctx = context.Background()
+
+		pubKey, privKey, err := generateTestPublicKeyDER()
+		Expect(err).NotTo(HaveOccurred())
+		pop, err := generateRSASignature(pubKey, privKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		pcr = &certificatesv1beta1.PodCertificateRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "policy-pcr", Namespace: "default"},
+			Spec: certificatesv1beta1.PodCertificateRequestSpec{
+				SignerName:         "novog93.ghcr/signer",
+				PodName:            "app-0",
+				PodUID:             "pod-uid",
+				PKIXPublicKey:      pubKey,
+				NodeName:           "node1",
+				NodeUID:            "node-uid",
+				ServiceAccountName: "api",
+				ServiceAccountUID:  "sa-uid",
+				ProofOfPossession:  []byte(pop),
+			},
+		}
+	})
+
+	newReconciler := func(objs ...client.Object) *SignerReconciler {
+		ca, err := NewCA()
+		Expect(err).NotTo(HaveOccurred())
+		return &SignerReconciler{
+			Client: fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(objs...).
+				WithStatusSubresource(pcr).
+				Build(),
+			CA:         ca,
+			SignerName: "novog93.ghcr/signer",
+			Config:     &Config{CertValidity: time.Hour, SignerPolicies: true},
+		}
+	}
+
+	It("Reconcile_AppliesMatchingPolicy", func() {
+		policy := &SignerPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-policy"},
+			Spec: SignerPolicySpec{
+				ServiceAccountNames: []string{"api"},
+				Validity:            &metav1.Duration{Duration: 3 * time.Hour},
+				RefreshBefore:       &metav1.Duration{Duration: time.Hour},
+				ExtendedKeyUsages:   []string{"ClientAuth"},
+				SANs: &SANTemplates{
+					DNSNames: []string{"{{ .ServiceAccountName }}.{{ .Namespace }}.mesh"},
+				},
+			},
+		}
+		reconciler := newReconciler(pcr, policy)
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
+		Expect(err).NotTo(HaveOccurred())
+
+		retrieved := &certificatesv1beta1.PodCertificateRequest{}
+		Expect(reconciler.Client.Get(ctx, types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}, retrieved)).To(Succeed())
+
+		cert, err := parseCertificateFromStatus(retrieved.Status.CertificateChain)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cert.NotAfter.Sub(time.Now())).To(BeNumerically("~", 3*time.Hour, 5*time.Second))
+		Expect(retrieved.Status.NotAfter.Sub(retrieved.Status.BeginRefreshAt.Time)).To(Equal(time.Hour))
+		Expect(cert.ExtKeyUsage).To(Equal([]x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}))
+		Expect(cert.DNSNames).To(ContainElement("api.default.mesh"))
+		Expect(retrieved.Status.Conditions[0].Message).To(ContainSubstring("policy: api-policy"))
+	})
+
+	It("Reconcile_RejectsKeyNotAllowedByPolicy", func() {
+		policy := &SignerPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "strong-keys"},
+			Spec: SignerPolicySpec{
+				AllowedKeys: []AllowedKey{{Algorithm: "RSA", Sizes: []int32{3072, 4096}}},
+			},
+		}
+		reconciler := newReconciler(pcr, policy)
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
+		Expect(err).NotTo(HaveOccurred())
+
+		retrieved := &certificatesv1beta1.PodCertificateRequest{}
+		Expect(reconciler.Client.Get(ctx, types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}, retrieved)).To(Succeed())
+		Expect(retrieved.Status.CertificateChain).To(BeEmpty())
+		Expect(retrieved.Status.Conditions).To(HaveLen(1))
+		Expect(retrieved.Status.Conditions[0].Type).To(Equal(certificatesv1beta1.PodCertificateRequestConditionTypeDenied))
+		Expect(retrieved.Status.Conditions[0].Reason).To(Equal("KeyNotAllowed"))
+	})
+
+	It("Reconcile_InvalidPolicy_RequeuesUntilFixed", func() {
+		policy := &SignerPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "typo"},
+			Spec: SignerPolicySpec{
+				SANs: &SANTemplates{DNSNames: []string{"{{ .ServiceAcountName }}.mesh"}},
+			},
+		}
+		reconciler := newReconciler(pcr, policy)
+		recorder := &captureRecorder{}
+		reconciler.Recorder = recorder
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}}
+
+		// The request stays pending instead of failing for good
+		_, err := reconciler.Reconcile(ctx, req)
+		Expect(err).To(MatchError(ContainSubstring("SignerPolicy typo is invalid")))
+		retrieved := &certificatesv1beta1.PodCertificateRequest{}
+		Expect(reconciler.Client.Get(ctx, req.NamespacedName, retrieved)).To(Succeed())
+		Expect(retrieved.Status.Conditions).To(BeEmpty())
+		Expect(recorder.events[0].reason).To(Equal("PolicyInvalid"))
+
+		// Once the admin fixes the policy the request is issued
+		Expect(reconciler.Client.Get(ctx, types.NamespacedName{Name: policy.Name}, policy)).To(Succeed())
+		policy.Spec.SANs.DNSNames = []string{"{{ .ServiceAccountName }}.mesh"}
+		Expect(reconciler.Client.Update(ctx, policy)).To(Succeed())
+		_, err = reconciler.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reconciler.Client.Get(ctx, req.NamespacedName, retrieved)).To(Succeed())
+		Expect(retrieved.Status.CertificateChain).NotTo(BeEmpty())
+	})
+
+	It("Reconcile_NoMatchingPolicy_UsesGlobalConfig", func() {
+		reconciler := newReconciler(pcr)
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
+		Expect(err).NotTo(HaveOccurred())
+
+		retrieved := &certificatesv1beta1.PodCertificateRequest{}
+		Expect(reconciler.Client.Get(ctx, types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}, retrieved)).To(Succeed())
+		Expect(retrieved.Status.NotAfter.Sub(retrieved.Status.NotBefore.Time)).To(Equal(time.Hour))
+		Expect(retrieved.Status.Conditions[0].Message).To(Equal("Signed by NovoG93 Signer Controller"))
+	})
+})
+
+var _ = Describe("Admission Rules", func() {
+	var (
+		scheme *runtime.Scheme
+		ctx    context.Context
+		pcr    *certificatesv1beta1.PodCertificateRequest
+		pod    *corev1.Pod
+	)
+
+	BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		utilruntime.Must(certificatesv1beta1.AddToScheme(scheme))
+		utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+		ctx = context.Background()
+
+		pubKey, privKey, err := generateTestPublicKeyDER()
+		Expect(err).NotTo(HaveOccurred())
+		pop, err := generateRSASignature(pubKey, privKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		pod = &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "app-0", Namespace: "default", UID: "pod-uid"}}
+		pcr = &certificatesv1beta1.PodCertificateRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "cel-pcr", Namespace: "default"},
+			Spec: certificatesv1beta1.PodCertificateRequestSpec{
+				SignerName:         "novog93.ghcr/signer",
+				PodName:            "app-0",
+				PodUID:             "pod-uid",
+				PKIXPublicKey:      pubKey,
+				NodeName:           "node1",
+				NodeUID:            "node-uid",
+				ServiceAccountName: "sa",
+				ServiceAccountUID:  "sa-uid",
+				ProofOfPossession:  []byte(pop),
+			},
+		}
+	})
+
+	newReconciler := func(nsLabels map[string]string, rules ...AdmissionRule) *SignerReconciler {
+		ca, err := NewCA()
+		Expect(err).NotTo(HaveOccurred())
+		admission, err := NewAdmissionRules(rules)
+		Expect(err).NotTo(HaveOccurred())
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Labels: nsLabels}}
+		return &SignerReconciler{
+			Client: fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(pcr, pod, ns).
+				WithStatusSubresource(pcr).
+				Build(),
+			CA:             ca,
+			SignerName:     "novog93.ghcr/signer",
+			Config:         &Config{},
+			AdmissionRules: admission,
+		}
+	}
+
+	It("Reconcile_DeniesRequestFailingRule", func() {
+		reconciler := newReconciler(nil, AdmissionRule{
+			Expression: "request.keySize >= 3072",
+			Message:    "RSA keys must be at least 3072 bits",
+		})
+
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{}))
+
+		retrieved := &certificatesv1beta1.PodCertificateRequest{}
+		Expect(reconciler.Client.Get(ctx, types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}, retrieved)).To(Succeed())
+		Expect(retrieved.Status.CertificateChain).To(BeEmpty())
+		Expect(retrieved.Status.Conditions).To(HaveLen(1))
+		Expect(retrieved.Status.Conditions[0].Type).To(Equal(certificatesv1beta1.PodCertificateRequestConditionTypeDenied))
+		Expect(retrieved.Status.Conditions[0].Status).To(Equal(metav1.ConditionTrue))
+		Expect(retrieved.Status.Conditions[0].Reason).To(Equal("AdmissionRuleFailed"))
+		Expect(retrieved.Status.Conditions[0].Message).To(Equal("RSA keys must be at least 3072 bits"))
+	})
+
+	It("Reconcile_SignsRequestPassingRules", func() {
+		reconciler := newReconciler(map[string]string{"mesh": "enabled"}, AdmissionRule{
+			Expression: "namespaceObject.labels['mesh'] == 'enabled'",
+		})
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
+		Expect(err).NotTo(HaveOccurred())
+
+		retrieved := &certificatesv1beta1.PodCertificateRequest{}
+		Expect(reconciler.Client.Get(ctx, types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}, retrieved)).To(Succeed())
+		Expect(retrieved.Status.CertificateChain).To(ContainSubstring("BEGIN CERTIFICATE"))
+	})
+
+	It("Reconcile_DeniesRequestWhenRuleErrors", func() {
+		// Indexing a missing label is a CEL runtime error for an unlabeled namespace
+		reconciler := newReconciler(nil, AdmissionRule{
+			Expression: "namespaceObject.labels['mesh'] == 'enabled'",
+			Message:    "only mesh namespaces may get certificates",
+		})
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
+		Expect(err).NotTo(HaveOccurred())
+
+		retrieved := &certificatesv1beta1.PodCertificateRequest{}
+		Expect(reconciler.Client.Get(ctx, types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}, retrieved)).To(Succeed())
+		Expect(retrieved.Status.Conditions).To(HaveLen(1))
+		Expect(retrieved.Status.Conditions[0].Type).To(Equal(certificatesv1beta1.PodCertificateRequestConditionTypeDenied))
+		Expect(retrieved.Status.Conditions[0].Reason).To(Equal("AdmissionRuleFailed"))
+		Expect(retrieved.Status.Conditions[0].Message).To(Equal("only mesh namespaces may get certificates"))
+	})
+})
+
+// captureRecorder records emitted events together with the objects they reference
+type captureRecorder struct {
+	events []capturedEvent
+}
+
+type capturedEvent struct {
+	regarding runtime.Object
+	related   runtime.Object
+	eventType string
+	reason    string
+	note      string
+}
+
+func (c *captureRecorder) Eventf(regarding runtime.Object, related runtime.Object, eventtype, reason, action, note string, args ...interface{}) {
+	c.events = append(c.events, capturedEvent{
+		regarding: regarding,
+		related:   related,
+		eventType: eventtype,
+		reason:    reason,
+		note:      fmt.Sprintf(note, args...),
+	})
+}
+
+var _ = Describe("Events", func() {
+	var (
+		scheme   *runtime.Scheme
+		ctx      context.Context
+		recorder *captureRecorder
+	)
+
+	BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		utilruntime.Must(certificatesv1beta1.AddToScheme(scheme))
+		utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+		ctx = context.Background()
+		recorder = &captureRecorder{}
+	})
+
+	newPCR := func(name string, pubKey []byte) *certificatesv1beta1.PodCertificateRequest {
+		return &certificatesv1beta1.PodCertificateRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: certificatesv1beta1.PodCertificateRequestSpec{
+				SignerName:         "novog93.ghcr/signer",
+				PodName:            "app-0",
+				PodUID:             "pod-uid",
+				PKIXPublicKey:      pubKey,
+				NodeName:           "node1",
+				NodeUID:            "node-uid",
+				ServiceAccountName: "sa",
+				ServiceAccountUID:  "sa-uid",
+				ProofOfPossession:  []byte("pop"),
+			},
+		}
+	}
+
+	newReconciler := func(pcr *certificatesv1beta1.PodCertificateRequest, ca *CAHelper) *SignerReconciler {
+		return &SignerReconciler{
+			Client: fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(pcr).
+				WithStatusSubresource(pcr).
+				Build(),
+			CA:         ca,
+			SignerName: "novog93.ghcr/signer",
+			Recorder:   recorder,
+		}
+	}
+
+	expectPCRAndPod := func(event capturedEvent, pcrName string) {
+		regarding, ok := event.regarding.(*certificatesv1beta1.PodCertificateRequest)
+		Expect(ok).To(BeTrue())
+		Expect(regarding.Name).To(Equal(pcrName))
+		related, ok := event.related.(*corev1.Pod)
+		Expect(ok).To(BeTrue())
+		Expect(related.Name).To(Equal("app-0"))
+		Expect(related.Namespace).To(Equal("default"))
+		Expect(related.UID).To(Equal(types.UID("pod-uid")))
+	}
+
+	It("Events_IssuedCertificate", func() {
+		pubKey, _, err := generateTestPublicKeyDER()
+		Expect(err).NotTo(HaveOccurred())
+		ca, err := NewCA()
+		Expect(err).NotTo(HaveOccurred())
+		pcr := newPCR("issued-pcr", pubKey)
+		reconciler := newReconciler(pcr, ca)
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(recorder.events).To(HaveLen(1))
+		Expect(recorder.events[0].eventType).To(Equal(corev1.EventTypeNormal))
+		Expect(recorder.events[0].reason).To(Equal("Issued"))
+		Expect(recorder.events[0].note).To(ContainSubstring("valid until"))
+		expectPCRAndPod(recorder.events[0], "issued-pcr")
+	})
+
+	It("Events_DeniedRequest", func() {
+		ca, err := NewCA()
+		Expect(err).NotTo(HaveOccurred())
+		pcr := newPCR("denied-pcr", []byte("invalid-key"))
+		reconciler := newReconciler(pcr, ca)
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(recorder.events).To(HaveLen(1))
+		Expect(recorder.events[0].eventType).To(Equal(corev1.EventTypeWarning))
+		Expect(recorder.events[0].reason).To(Equal("InvalidPublicKey"))
+		expectPCRAndPod(recorder.events[0], "denied-pcr")
+	})
+
+	It("Events_SigningFailure", func() {
+		pubKey, _, err := generateTestPublicKeyDER()
+		Expect(err).NotTo(HaveOccurred())
+		pcr := newPCR("unsigned-pcr", pubKey)
+		reconciler := newReconciler(pcr, nil)
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
+		Expect(err).To(HaveOccurred())
+
+		Expect(recorder.events).To(HaveLen(1))
+		Expect(recorder.events[0].eventType).To(Equal(corev1.EventTypeWarning))
+		Expect(recorder.events[0].reason).To(Equal("SigningFailed"))
+		expectPCRAndPod(recorder.events[0], "unsigned-pcr")
+	})
+})
+
+var _ = Describe("Non-RSA CA", func() {
+	var (
+		scheme *runtime.Scheme
+		ctx    context.Context
+	)
+
+	BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		utilruntime.Must(certificatesv1beta1.AddToScheme(scheme))
+		utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+		ctx = context.Background()
+	})
+
+	for _, algorithm := range []string{CAKeyAlgorithmECDSAP256, CAKeyAlgorithmECDSAP384, CAKeyAlgorithmEd25519} {
+		It("SignCertificate_With"+algorithm+"CA", func() {
+			pubKey, _, err := generateTestPublicKeyDER()
+			Expect(err).NotTo(HaveOccurred())
+			ca, err := NewCAWithKeyAlgorithm(algorithm)
+			Expect(err).NotTo(HaveOccurred())
+
+			pcr := &certificatesv1beta1.PodCertificateRequest{
+				ObjectMeta: metav1.ObjectMeta{Name: "pcr-" + algorithm, Namespace: "default"},
+				Spec: certificatesv1beta1.PodCertificateRequestSpec{
+					SignerName:         "novog93.ghcr/signer",
+					PodName:            "app-0",
+					PodUID:             "pod-uid",
+					PKIXPublicKey:      pubKey,
+					NodeName:           "node1",
+					NodeUID:            "node-uid",
+					ServiceAccountName: "sa",
+					ServiceAccountUID:  "sa-uid",
+					ProofOfPossession:  []byte("pop"),
+				},
+			}
+			reconciler := &SignerReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(pcr).
+					WithStatusSubresource(pcr).
+					Build(),
+				CA:         ca,
+				SignerName: "novog93.ghcr/signer",
+			}
+
+			_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
+			Expect(err).NotTo(HaveOccurred())
+
+			retrieved := &certificatesv1beta1.PodCertificateRequest{}
+			Expect(reconciler.Client.Get(ctx, types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}, retrieved)).To(Succeed())
+			cert, err := parseCertificateFromStatus(retrieved.Status.CertificateChain)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cert.CheckSignatureFrom(ca.GetCert())).To(Succeed())
+		})
+	}
+})
+
+var _ = Describe("Intermediate CA", func() {
+	It("SignCertificate_AppendsIntermediates", func() {
+		scheme := runtime.NewScheme()
+		utilruntime.Must(certificatesv1beta1.AddToScheme(scheme))
+		utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+		ctx := context.Background()
+
+		rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		root := newTestCACert("Offline Root", rootKey, nil, nil)
+		intKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		intermediate := newTestCACert("Intermediate", intKey, root, rootKey)
+		keyDER, err := x509.MarshalECPrivateKey(intKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		ca, err := loadTestCASecret(map[string][]byte{
+			"ca.crt": encodeTestCerts(intermediate, root),
+			"ca.key": pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		pubKey, _, err := generateTestPublicKeyDER()
+		Expect(err).NotTo(HaveOccurred())
+		pcr := &certificatesv1beta1.PodCertificateRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "chain-pcr", Namespace: "default"},
+			Spec: certificatesv1beta1.PodCertificateRequestSpec{
+				SignerName:         "novog93.ghcr/signer",
+				PodName:            "app-0",
+				PodUID:             "pod-uid",
+				PKIXPublicKey:      pubKey,
+				NodeName:           "node1",
+				NodeUID:            "node-uid",
+				ServiceAccountName: "sa",
+				ServiceAccountUID:  "sa-uid",
+				ProofOfPossession:  []byte("pop"),
+			},
+		}
+		reconciler := &SignerReconciler{
+			Client: fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(pcr).
+				WithStatusSubresource(pcr).
+				Build(),
+			CA:         ca,
+			SignerName: "novog93.ghcr/signer",
+		}
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
+		Expect(err).NotTo(HaveOccurred())
+
+		retrieved := &certificatesv1beta1.PodCertificateRequest{}
+		Expect(reconciler.Client.Get(ctx, types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}, retrieved)).To(Succeed())
+		certs, err := parseCertificatesPEM([]byte(retrieved.Status.CertificateChain))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(certs).To(HaveLen(2))
+		Expect(certs[1].Equal(intermediate)).To(BeTrue())
+
+		// A client trusting only the root can build the path from the bundle
+		roots := x509.NewCertPool()
+		roots.AddCert(root)
+		intermediates := x509.NewCertPool()
+		intermediates.AddCert(certs[1])
+		_, err = certs[0].Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
+
+var _ = Describe("CA expiry", func() {
+	var (
+		scheme *runtime.Scheme
+		ctx    context.Context
+		pcr    *certificatesv1beta1.PodCertificateRequest
+	)
+
+	BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		utilruntime.Must(certificatesv1beta1.AddToScheme(scheme))
+		utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+		ctx = context.Background()
+
+		pubKey, _, err := generateTestPublicKeyDER()
+		Expect(err).NotTo(HaveOccurred())
+		pcr = &certificatesv1beta1.PodCertificateRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "expiry-pcr", Namespace: "default"},
+			Spec: certificatesv1beta1.PodCertificateRequestSpec{
+				SignerName:         "novog93.ghcr/signer",
+				PodName:            "app-0",
+				PodUID:             "pod-uid",
+				PKIXPublicKey:      pubKey,
+				NodeName:           "node1",
+				NodeUID:            "node-uid",
+				ServiceAccountName: "sa",
+				ServiceAccountUID:  "sa-uid",
+				ProofOfPossession:  []byte("pop"),
+			},
+		}
+	})
+
+	newReconciler := func(ca *CAHelper) *SignerReconciler {
+		return &SignerReconciler{
+			Client: fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(pcr).
+				WithStatusSubresource(pcr).
+				Build(),
+			CA:         ca,
+			SignerName: "novog93.ghcr/signer",
+			Config:     &Config{CertValidity: 48 * time.Hour},
+		}
+	}
+
+	It("SignCertificate_ClampsNotAfterToCA", func() {
+		ca := newExpiringTestCA(time.Now().Add(24 * time.Hour))
+		reconciler := newReconciler(ca)
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
+		Expect(err).NotTo(HaveOccurred())
+
+		retrieved := &certificatesv1beta1.PodCertificateRequest{}
+		Expect(reconciler.Client.Get(ctx, types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}, retrieved)).To(Succeed())
+		Expect(retrieved.Status.NotAfter.Time).To(BeTemporally("==", ca.Cert.NotAfter))
+		certs, err := parseCertificatesPEM([]byte(retrieved.Status.CertificateChain))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(certs[0].NotAfter).To(BeTemporally("==", ca.Cert.NotAfter))
+	})
+
+	It("SignCertificate_RequeuesWhenCAExpiresTooSoon", func() {
+		reconciler := newReconciler(newExpiringTestCA(time.Now().Add(30 * time.Minute)))
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
+		Expect(err).To(MatchError(ContainSubstring("within the minimum certificate validity")))
+
+		retrieved := &certificatesv1beta1.PodCertificateRequest{}
+		Expect(reconciler.Client.Get(ctx, types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}, retrieved)).To(Succeed())
+		Expect(retrieved.Status.CertificateChain).To(BeEmpty())
+		Expect(retrieved.Status.Conditions).To(BeEmpty())
+	})
+})
+
+var _ = Describe("CA load failures", func() {
+	var (
+		scheme *runtime.Scheme
+		ctx    context.Context
+		pcr    *certificatesv1beta1.PodCertificateRequest
+	)
+
+	BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		utilruntime.Must(certificatesv1beta1.AddToScheme(scheme))
+		utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+		ctx = context.Background()
+
+		pubKey, _, err := generateTestPublicKeyDER()
+		Expect(err).NotTo(HaveOccurred())
+		pcr = &certificatesv1beta1.PodCertificateRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "stale-pcr", Namespace: "default"},
+			Spec: certificatesv1beta1.PodCertificateRequestSpec{
+				SignerName:         "novog93.ghcr/signer",
+				PodName:            "app-0",
+				PodUID:             "pod-uid",
+				PKIXPublicKey:      pubKey,
+				NodeName:           "node1",
+				NodeUID:            "node-uid",
+				ServiceAccountName: "sa",
+				ServiceAccountUID:  "sa-uid",
+				ProofOfPossession:  []byte("pop"),
+			},
+		}
+	})
+
+	reconcileWithPolicy := func(policy string) (*SignerReconciler, error) {
+		ca, err := NewCA()
+		Expect(err).NotTo(HaveOccurred())
+		ca.setLoadError(fmt.Errorf("CA secret deleted"))
+		reconciler := &SignerReconciler{
+			Client: fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(pcr).
+				WithStatusSubresource(pcr).
+				Build(),
+			CA:         ca,
+			SignerName: "novog93.ghcr/signer",
+			Config:     &Config{CALoadFailurePolicy: policy},
+		}
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
+		return reconciler, err
+	}
+
+	It("SignCertificate_PausedWhileCAFailsToLoad", func() {
+		reconciler, err := reconcileWithPolicy(CALoadFailurePause)
+		Expect(err).To(MatchError(ContainSubstring("issuance paused")))
+
+		retrieved := &certificatesv1beta1.PodCertificateRequest{}
+		Expect(reconciler.Client.Get(ctx, types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}, retrieved)).To(Succeed())
+		Expect(retrieved.Status.CertificateChain).To(BeEmpty())
+	})
+
+	It("SignCertificate_UnreadyPolicyKeepsSigning", func() {
+		reconciler, err := reconcileWithPolicy(CALoadFailureUnready)
+		Expect(err).NotTo(HaveOccurred())
+
+		retrieved := &certificatesv1beta1.PodCertificateRequest{}
+		Expect(reconciler.Client.Get(ctx, types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}, retrieved)).To(Succeed())
+		Expect(retrieved.Status.CertificateChain).NotTo(BeEmpty())
+	})
+})
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+// leaderStatusPath is served by the metrics server when leader election is enabled
+const leaderStatusPath = "/leader"
+
+// caReadyzCheck fails until a usable CA is loaded: the last reload succeeded
+// and the certificate passes validateCA.
+func caReadyzCheck(ca *CAHelper) healthz.Checker {
+	return func(_ *http.Request) error {
+		if err := ca.LoadError(); err != nil {
+			return fmt.Errorf("CA failed to load: %w", err)
+		}
+		snap := ca.snapshot()
+		if snap == nil || snap.key == nil {
+			return fmt.Errorf("CA not loaded")
+		}
+		if err := validateCA(snap.cert, snap.key, time.Now()); err != nil {
+			return fmt.Errorf("CA not usable: %w", err)
+		}
+		return nil
+	}
+}
+
+// issuerReadyzCheck fails until a backend that holds no local key, like Vault,
+// knows its issuing CA and that CA is within its validity window.
+func issuerReadyzCheck(backend SigningBackend) healthz.Checker {
+	return func(_ *http.Request) error {
+		cert := backend.Issuer()
+		if cert == nil {
+			return fmt.Errorf("issuing CA not loaded")
+		}
+		now := time.Now()
+		if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+			return fmt.Errorf("issuing CA %q is only valid from %s to %s", cert.Subject, cert.NotBefore.UTC().Format(time.RFC3339), cert.NotAfter.UTC().Format(time.RFC3339))
+		}
+		return nil
+	}
+}
+
+// informerSyncedCheck fails until the informer for obj has synced. It does not
+// block, and starts the informer on replicas that are not leading yet, so they
+// take over with a warm cache.
+func informerSyncedCheck(informers cache.Informers, obj client.Object) healthz.Checker {
+	return func(req *http.Request) error {
+		informer, err := informers.GetInformer(req.Context(), obj, cache.BlockUntilSynced(false))
+		if err != nil {
+			return fmt.Errorf("failed to get %T informer: %w", obj, err)
+		}
+		if !informer.HasSynced() {
+			return fmt.Errorf("%T informer not synced", obj)
+		}
+		return nil
+	}
+}
+
+// leaderStatusHandler reports whether this replica currently holds the leader lease
+func leaderStatusHandler(elected <-chan struct{}) http.Handler {
+	identity, _ := os.Hostname()
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		leader := false
+		select {
+		case <-elected:
+			leader = true
+		default:
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"identity": identity, "leader": leader})
+	})
+}
+
+// remoteSignerReadyzCheck fails while the serve-signing process is unreachable or reports itself unhealthy
+func remoteSignerReadyzCheck(backend *RemoteSigningBackend) healthz.Checker {
+	return func(req *http.Request) error {
+		return backend.Health(req.Context())
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllertest"
+)
+
+func TestCAReadyzCheck(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(caReadyzCheck(&CAHelper{})(nil)).To(MatchError(ContainSubstring("CA not loaded")))
+
+	ca, err := NewCA()
+	Expect(err).NotTo(HaveOccurred())
+	Expect(caReadyzCheck(ca)(nil)).To(Succeed())
+
+	expired := newExpiringTestCA(time.Now().Add(-time.Minute))
+	Expect(caReadyzCheck(expired)(nil)).To(MatchError(ContainSubstring("expired at")))
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	mismatched := &CAHelper{Cert: ca.Cert, Key: otherKey}
+	Expect(caReadyzCheck(mismatched)(nil)).To(MatchError(ContainSubstring("does not match")))
+}
+
+func TestInformerSyncedCheck(t *testing.T) {
+	RegisterTestingT(t)
+
+	gvk := schema.GroupVersionKind{Group: "certificates.k8s.io", Version: "v1beta1", Kind: "PodCertificateRequest"}
+	informer := &controllertest.FakeInformer{Synced: false}
+	informers := &informertest.FakeInformers{
+		Scheme:         scheme,
+		InformersByGVK: map[schema.GroupVersionKind]toolscache.SharedIndexInformer{gvk: informer},
+	}
+	check := informerSyncedCheck(informers, &certificatesv1beta1.PodCertificateRequest{})
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+
+	Expect(check(req)).To(MatchError(ContainSubstring("not synced")))
+
+	informer.Synced = true
+	Expect(check(req)).To(Succeed())
+}
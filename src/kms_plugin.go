@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bufio"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The KMS plugin protocol is one JSON object per line in each direction, over
+// the plugin's stdin/stdout or a Unix socket. Every request names the key:
+//
+//	{"op":"publicKey","keyId":"..."}
+//	  -> {"publicKey":"<base64 PKIX DER>"}
+//	{"op":"sign","keyId":"...","digest":"<base64>","hash":"SHA-256","pssSaltLength":32}
+//	  -> {"signature":"<base64>"}
+//
+// hash is empty for Ed25519, which signs the whole message passed as digest, and
+// pssSaltLength is only set for RSA-PSS. ECDSA signatures are ASN.1 DER, like
+// Go's crypto.Signer. Failures are reported as {"error":"..."}.
+const (
+	kmsOpPublicKey = "publicKey"
+	kmsOpSign      = "sign"
+	// kmsPluginTimeout bounds every exchange with the plugin
+	kmsPluginTimeout = 30 * time.Second
+)
+
+// kmsPluginRequest is a request to the KMS plugin
+type kmsPluginRequest struct {
+	Op            string `json:"op"`
+	KeyID         string `json:"keyId,omitempty"`
+	Digest        []byte `json:"digest,omitempty"`
+	Hash          string `json:"hash,omitempty"`
+	PSSSaltLength int    `json:"pssSaltLength,omitempty"`
+}
+
+// kmsPluginResponse is the KMS plugin's answer to a request
+type kmsPluginResponse struct {
+	PublicKey []byte `json:"publicKey,omitempty"`
+	Signature []byte `json:"signature,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// kmsPluginConn is a connection to the plugin: a child process or a Unix socket
+type kmsPluginConn struct {
+	w      io.Writer
+	r      *bufio.Reader
+	closer func() error
+	// setDeadline bounds the next exchange
+	setDeadline func(time.Time) error
+}
+
+// kmsPluginSigner is a crypto.Signer whose private key lives in a KMS, reached
+// through the plugin. It connects again after a failed exchange, restarting
+// the plugin process if needed.
+type kmsPluginSigner struct {
+	keyID string
+	dial  func() (*kmsPluginConn, error)
+	pub   crypto.PublicKey
+
+	// mu serializes exchanges, the protocol has one request in flight at a time
+	mu   sync.Mutex
+	conn *kmsPluginConn
+}
+
+// newKMSCA loads the CA for a key held in a KMS: the certificate (and chain)
+// from config.KMSCACert and the public key from the plugin. The private key
+// never leaves the KMS; CAHelper.Key signs through the plugin.
+func newKMSCA(config *Config) (*CAHelper, error) {
+	if config.KMSCACert == "" {
+		return nil, fmt.Errorf("KMS_CA_CERT is required for the kms signing backend")
+	}
+	certPEM, err := os.ReadFile(config.KMSCACert)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read KMS CA certificate: %w", err)
+	}
+	certs, err := parseCertificatesPEM(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid KMS CA certificate: %w", err)
+	}
+	cert, chain := certs[0], certs[1:]
+	if err := verifyChain(cert, chain); err != nil {
+		return nil, fmt.Errorf("invalid KMS CA certificate: %w", err)
+	}
+
+	signer, err := newKMSPluginSigner(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateCA(cert, signer, time.Now()); err != nil {
+		_ = signer.Close()
+		return nil, fmt.Errorf("invalid CA for KMS key %q: %w", config.KMSKeyID, err)
+	}
+	ca := &CAHelper{}
+	ca.set(&caKeyPair{cert: cert, chain: chain, key: signer}, nil)
+	return ca, nil
+}
+
+// newKMSPluginSigner connects to the plugin named by config and fetches the public key
+func newKMSPluginSigner(config *Config) (*kmsPluginSigner, error) {
+	var dial func() (*kmsPluginConn, error)
+	switch {
+	case config.KMSPluginSocket != "":
+		dial = func() (*kmsPluginConn, error) { return dialKMSPluginSocket(config.KMSPluginSocket) }
+	case config.KMSPlugin != "":
+		args := strings.Fields(config.KMSPlugin)
+		dial = func() (*kmsPluginConn, error) { return startKMSPlugin(args[0], args[1:]...) }
+	default:
+		return nil, fmt.Errorf("KMS_PLUGIN or KMS_PLUGIN_SOCKET is required for the kms signing backend")
+	}
+
+	s := &kmsPluginSigner{keyID: config.KMSKeyID, dial: dial}
+	resp, err := s.call(&kmsPluginRequest{Op: kmsOpPublicKey, KeyID: s.keyID})
+	if err == nil {
+		s.pub, err = x509.ParsePKIXPublicKey(resp.PublicKey)
+		if err != nil {
+			err = fmt.Errorf("invalid public key from KMS plugin: %w", err)
+		}
+	}
+	if err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// startKMSPlugin runs the plugin command, talking to it over its stdin and stdout
+func startKMSPlugin(name string, args ...string) (*kmsPluginConn, error) {
+	cmd := exec.Command(name, args...)
+	// The plugin logs to stderr, next to the controller's logs
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start KMS plugin %s: %w", name, err)
+	}
+	// StdoutPipe returns an *os.File, whose reads honour deadlines
+	setDeadline := func(time.Time) error { return nil }
+	if f, ok := stdout.(*os.File); ok {
+		setDeadline = f.SetReadDeadline
+	}
+	return &kmsPluginConn{
+		w: stdin,
+		r: bufio.NewReader(stdout),
+		closer: func() error {
+			_ = stdin.Close()
+			_ = cmd.Process.Kill()
+			return cmd.Wait()
+		},
+		setDeadline: setDeadline,
+	}, nil
+}
+
+// dialKMSPluginSocket connects to a plugin listening on a Unix socket
+func dialKMSPluginSocket(path string) (*kmsPluginConn, error) {
+	conn, err := net.DialTimeout("unix", path, kmsPluginTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to KMS plugin socket %s: %w", path, err)
+	}
+	return &kmsPluginConn{w: conn, r: bufio.NewReader(conn), closer: conn.Close, setDeadline: conn.SetDeadline}, nil
+}
+
+// Close stops the plugin process or disconnects from the plugin socket
+func (s *kmsPluginSigner) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.closer()
+	s.conn = nil
+	return err
+}
+
+// Public returns the KMS key's public key
+func (s *kmsPluginSigner) Public() crypto.PublicKey {
+	return s.pub
+}
+
+// Sign has the KMS sign digest, which was hashed with opts.HashFunc()
+func (s *kmsPluginSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	req := &kmsPluginRequest{Op: kmsOpSign, KeyID: s.keyID, Digest: digest}
+	if hash := opts.HashFunc(); hash != 0 {
+		req.Hash = hash.String()
+	}
+	if pss, ok := opts.(*rsa.PSSOptions); ok {
+		// x509 signs with the salt as long as the hash
+		req.PSSSaltLength = pss.SaltLength
+		if req.PSSSaltLength == rsa.PSSSaltLengthEqualsHash || req.PSSSaltLength == rsa.PSSSaltLengthAuto {
+			req.PSSSaltLength = opts.HashFunc().Size()
+		}
+	}
+	resp, err := s.call(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Signature) == 0 {
+		return nil, fmt.Errorf("KMS plugin returned an empty signature")
+	}
+	return resp.Signature, nil
+}
+
+// call sends req and reads the response, connecting again once when the connection broke
+func (s *kmsPluginSigner) call(req *kmsPluginRequest) (*kmsPluginResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp, err := s.exchange(req)
+	var pluginErr *kmsPluginError
+	if err != nil && !errors.As(err, &pluginErr) {
+		// The connection broke, e.g. the plugin crashed: retry once on a new one
+		resp, err = s.exchange(req)
+	}
+	return resp, err
+}
+
+// exchange does one request/response round trip, dropping the connection on I/O errors
+func (s *kmsPluginSigner) exchange(req *kmsPluginRequest) (*kmsPluginResponse, error) {
+	if s.conn == nil {
+		conn, err := s.dial()
+		if err != nil {
+			return nil, err
+		}
+		s.conn = conn
+	}
+	_ = s.conn.setDeadline(time.Now().Add(kmsPluginTimeout))
+
+	line, err := json.Marshal(req)
+	if err == nil {
+		_, err = s.conn.w.Write(append(line, '\n'))
+	}
+	var resp kmsPluginResponse
+	if err == nil {
+		line, err = s.conn.r.ReadBytes('\n')
+		if err == nil {
+			err = json.Unmarshal(line, &resp)
+		}
+	}
+	if err != nil {
+		_ = s.conn.closer()
+		s.conn = nil
+		return nil, fmt.Errorf("KMS plugin %s failed: %w", req.Op, err)
+	}
+	if resp.Error != "" {
+		return nil, &kmsPluginError{op: req.Op, msg: resp.Error}
+	}
+	return &resp, nil
+}
+
+// kmsPluginError is an error reported by the plugin itself, which retrying will not fix
+type kmsPluginError struct {
+	op, msg string
+}
+
+func (e *kmsPluginError) Error() string {
+	return fmt.Sprintf("KMS plugin %s failed: %s", e.op, e.msg)
+}
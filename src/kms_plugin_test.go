@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+// fakeKMSKeyEnv names the key file of the fake plugin process started by TestKMSPluginHelperProcess
+const fakeKMSKeyEnv = "SIGNER_FAKE_KMS_KEY"
+
+// serveFakeKMSPlugin answers KMS plugin requests for keyID with key until r is closed
+func serveFakeKMSPlugin(key crypto.Signer, keyID string, r io.Reader, w io.Writer) {
+	hashes := map[string]crypto.Hash{"": 0}
+	for _, hash := range []crypto.Hash{crypto.SHA256, crypto.SHA384, crypto.SHA512} {
+		hashes[hash.String()] = hash
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var req kmsPluginRequest
+		var resp kmsPluginResponse
+		_ = json.Unmarshal(scanner.Bytes(), &req)
+		switch {
+		case req.KeyID != keyID:
+			resp.Error = "unknown key " + req.KeyID
+		case req.Op == kmsOpPublicKey:
+			resp.PublicKey, _ = x509.MarshalPKIXPublicKey(key.Public())
+		case req.Op == kmsOpSign:
+			var opts crypto.SignerOpts = hashes[req.Hash]
+			if req.PSSSaltLength != 0 {
+				opts = &rsa.PSSOptions{SaltLength: req.PSSSaltLength, Hash: hashes[req.Hash]}
+			}
+			signature, err := key.Sign(rand.Reader, req.Digest, opts)
+			if err != nil {
+				resp.Error = err.Error()
+			}
+			resp.Signature = signature
+		default:
+			resp.Error = "unknown op " + req.Op
+		}
+		line, _ := json.Marshal(resp)
+		_, _ = w.Write(append(line, '\n'))
+	}
+}
+
+// TestKMSPluginHelperProcess is the fake plugin process, not a real test
+func TestKMSPluginHelperProcess(t *testing.T) {
+	keyFile := os.Getenv(fakeKMSKeyEnv)
+	if keyFile == "" {
+		return
+	}
+	keyPEM, _ := os.ReadFile(keyFile)
+	key, err := parsePrivateKeyPEM(keyPEM)
+	if err != nil {
+		os.Exit(1)
+	}
+	serveFakeKMSPlugin(key, "ca-key", os.Stdin, os.Stdout)
+	os.Exit(0)
+}
+
+// writeTestKMSFiles writes the key for the fake plugin and a CA certificate for it
+func writeTestKMSFiles(t *testing.T, key crypto.Signer) (keyFile, certFile string) {
+	dir := t.TempDir()
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+	keyFile, certFile = filepath.Join(dir, "ca.key"), filepath.Join(dir, "ca.crt")
+	Expect(os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600)).To(Succeed())
+	Expect(os.WriteFile(certFile, encodeTestCerts(newTestCACert("KMS CA", key, nil, nil)), 0o600)).To(Succeed())
+	return keyFile, certFile
+}
+
+func TestKMSCA_SignsThroughPluginProcess(t *testing.T) {
+	RegisterTestingT(t)
+
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	for name, key := range map[string]crypto.Signer{"ecdsa": ecKey, "rsa": rsaKey} {
+		keyFile, certFile := writeTestKMSFiles(t, key)
+		t.Setenv(fakeKMSKeyEnv, keyFile)
+
+		ca, err := newKMSCA(&Config{
+			KMSPlugin: os.Args[0] + " -test.run=^TestKMSPluginHelperProcess$",
+			KMSKeyID:  "ca-key",
+			KMSCACert: certFile,
+		})
+		Expect(err).NotTo(HaveOccurred(), name)
+		Expect(ca.GetKey()).To(BeAssignableToTypeOf(&kmsPluginSigner{}), name)
+		Expect(ca.GetKey().Public()).To(Equal(key.Public()), name)
+
+		leafKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		backend := &LocalSigningBackend{CA: ca}
+		chainPEM, err := backend.Issue(context.Background(), testLeafTemplate(), leafKey.Public())
+		Expect(err).NotTo(HaveOccurred(), name)
+		leaves, err := parseCertificatesPEM(chainPEM)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(leaves[0].CheckSignatureFrom(ca.GetCert())).To(Succeed(), name)
+
+		// A crashed plugin is started again for the next signature
+		signer := ca.GetKey().(*kmsPluginSigner)
+		crashed := signer.conn
+		_ = crashed.closer()
+		_, err = backend.Issue(context.Background(), testLeafTemplate(), leafKey.Public())
+		Expect(err).NotTo(HaveOccurred(), name)
+		Expect(signer.conn).NotTo(BeIdenticalTo(crashed), name)
+		_ = signer.Close()
+	}
+}
+
+func TestKMSCA_SignsThroughPluginSocket(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	_, certFile := writeTestKMSFiles(t, key)
+	socket := filepath.Join(t.TempDir(), "kms.sock")
+	lis, err := net.Listen("unix", socket)
+	Expect(err).NotTo(HaveOccurred())
+	t.Cleanup(func() { _ = lis.Close() })
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go serveFakeKMSPlugin(key, "ca-key", conn, conn)
+		}
+	}()
+
+	ca, err := newKMSCA(&Config{KMSPluginSocket: socket, KMSKeyID: "ca-key", KMSCACert: certFile})
+	Expect(err).NotTo(HaveOccurred())
+
+	leafKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	chainPEM, err := (&LocalSigningBackend{CA: ca}).Issue(context.Background(), testLeafTemplate(), leafKey.Public())
+	Expect(err).NotTo(HaveOccurred())
+	leaves, err := parseCertificatesPEM(chainPEM)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(leaves[0].CheckSignatureFrom(ca.GetCert())).To(Succeed())
+}
+
+func TestKMSCA_Errors(t *testing.T) {
+	RegisterTestingT(t)
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	keyFile, _ := writeTestKMSFiles(t, key)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, otherCertFile := writeTestKMSFiles(t, otherKey)
+	t.Setenv(fakeKMSKeyEnv, keyFile)
+	plugin := os.Args[0] + " -test.run=^TestKMSPluginHelperProcess$"
+
+	// The certificate must belong to the KMS key
+	_, err := newKMSCA(&Config{KMSPlugin: plugin, KMSKeyID: "ca-key", KMSCACert: otherCertFile})
+	Expect(err).To(MatchError(ContainSubstring("does not match")))
+
+	// Errors reported by the plugin are returned as they are
+	_, err = newKMSPluginSigner(&Config{KMSPlugin: plugin, KMSKeyID: "other-key"})
+	Expect(err).To(MatchError(ContainSubstring("unknown key other-key")))
+
+	_, err = newKMSCA(&Config{KMSPlugin: plugin})
+	Expect(err).To(MatchError(ContainSubstring("KMS_CA_CERT")))
+	_, err = newKMSPluginSigner(&Config{})
+	Expect(err).To(MatchError(ContainSubstring("KMS_PLUGIN")))
+	_, err = newKMSPluginSigner(&Config{KMSPlugin: filepath.Join(t.TempDir(), "missing")})
+	Expect(err).To(MatchError(ContainSubstring("failed to start KMS plugin")))
+}
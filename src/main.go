@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"go.uber.org/zap/zapcore"
@@ -26,7 +27,56 @@ type Config struct {
 	CASecretNamespace       string
 	CACertKey               string
 	CAKeyKey                string
+	CACertFile              string
+	CAKeyFile               string
+	CAKeyAlgorithm          string
+	CASecretCreate          bool
+	CALoadFailurePolicy     string
+	CARotationOverlap       time.Duration
+	CARotationGrace         time.Duration
+	CAExpiryWarnings        []time.Duration
+	CAAutoRenew             string
+	CARenewBefore           time.Duration
+	SigningBackend          string
+	PKCS11Module            string
+	PKCS11Slot              int
+	PKCS11TokenLabel        string
+	PKCS11KeyLabel          string
+	PKCS11PIN               string
+	PKCS11PINFile           string
+	KMSPlugin               string
+	KMSPluginSocket         string
+	KMSKeyID                string
+	KMSCACert               string
+	VaultAddress            string
+	VaultPKIMount           string
+	VaultPKIRole            string
+	VaultAuthMethod         string
+	VaultToken              string
+	VaultKubernetesRole     string
+	VaultKubernetesMount    string
+	VaultCACert             string
+	SigningEndpoint         string
+	SigningServerAddress    string
+	SigningTLSCert          string
+	SigningTLSKey           string
+	SigningTLSCA            string
+	SigningTLSServerName    string
+	SigningMaxValidity      time.Duration
 	MaxConcurrentReconciles int
+	IssuanceMode            string
+	SPIFFETrustDomain       string
+	ClusterDomain           string
+	ServiceSANs             bool
+	PodIPSANs               bool
+	PodIPWaitTimeout        time.Duration
+	SignerPolicies          bool
+	AdmissionRules          string
+	ClusterTrustBundle      bool
+	TrustConfigMapName      string
+	TrustConfigMapKey       string
+	TrustNamespaceSelector  string
+	TrustExtraRoots         string
 }
 
 // TODO: Exchange for cli args
@@ -91,8 +141,11 @@ func LoadConfig(getEnv func(string) string) *Config {
 	// Parse CASecretName (default: "" = in-memory CA)
 	caSecretName := getEnv("CA_SECRET_NAME")
 
-	// Parse CASecretNamespace (default: "" = controller namespace)
+	// Parse CASecretNamespace (default: POD_NAMESPACE)
 	caSecretNamespace := getEnv("CA_SECRET_NAMESPACE")
+	if caSecretNamespace == "" {
+		caSecretNamespace = getEnv("POD_NAMESPACE")
+	}
 
 	// Parse CACertKey (default: "ca.crt")
 	caCertKey := getEnv("CA_CERT_KEY")
@@ -105,6 +158,164 @@ func LoadConfig(getEnv func(string) string) *Config {
 	if caKeyKey == "" {
 		caKeyKey = "ca.key"
 	}
+
+	// Parse CACertFile and CAKeyFile (default: ""); PEM files of a mounted CA, used instead of the CA Secret
+	caCertFile := getEnv("CA_CERT_FILE")
+	caKeyFile := getEnv("CA_KEY_FILE")
+
+	// Parse CAKeyAlgorithm (default: "RSA2048"); used for CAs the controller generates
+	caKeyAlgorithm := getEnv("CA_KEY_ALGORITHM")
+	if caKeyAlgorithm == "" {
+		caKeyAlgorithm = CAKeyAlgorithmRSA2048
+	}
+
+	// Parse CASecretCreate (default: false); generate the CA Secret if it does not exist
+	caSecretCreate := false
+	if val := getEnv("CA_SECRET_CREATE"); val != "" {
+		caSecretCreate, _ = strconv.ParseBool(val)
+	}
+
+	// Parse CALoadFailurePolicy (default: "unready"); "unready" or "pause"
+	caLoadFailurePolicy := getEnv("CA_LOAD_FAILURE_POLICY")
+	if caLoadFailurePolicy == "" {
+		caLoadFailurePolicy = CALoadFailureUnready
+	}
+
+	// Parse CARotationOverlap (default: "24h"); how long a staged CA is trusted before it signs
+	caRotationOverlapStr := getEnv("CA_ROTATION_OVERLAP")
+	if caRotationOverlapStr == "" {
+		caRotationOverlapStr = "24h"
+	}
+	caRotationOverlap, _ := time.ParseDuration(caRotationOverlapStr)
+
+	// Parse CARotationGrace (default: "24h"); how long the old CA stays trusted after the switch
+	caRotationGraceStr := getEnv("CA_ROTATION_GRACE")
+	if caRotationGraceStr == "" {
+		caRotationGraceStr = "24h"
+	}
+	caRotationGrace, _ := time.ParseDuration(caRotationGraceStr)
+
+	// Parse CAExpiryWarnings (default: "720h,168h,24h"); comma-separated durations
+	caExpiryWarningsStr := getEnv("CA_EXPIRY_WARNING_THRESHOLDS")
+	if caExpiryWarningsStr == "" {
+		caExpiryWarningsStr = "720h,168h,24h"
+	}
+	var caExpiryWarnings []time.Duration
+	for _, s := range strings.Split(caExpiryWarningsStr, ",") {
+		if d, err := time.ParseDuration(strings.TrimSpace(s)); err == nil && d > 0 {
+			caExpiryWarnings = append(caExpiryWarnings, d)
+		}
+	}
+
+	// Parse CAAutoRenew (default: "" = no renewal); "same-key" or "new-key"
+	caAutoRenew := getEnv("CA_AUTO_RENEW")
+
+	// Parse CARenewBefore (default: "720h"); remaining CA lifetime that triggers renewal
+	caRenewBeforeStr := getEnv("CA_RENEW_BEFORE")
+	if caRenewBeforeStr == "" {
+		caRenewBeforeStr = "720h"
+	}
+	caRenewBefore, _ := time.ParseDuration(caRenewBeforeStr)
+
+	// Parse SigningBackend (default: "local")
+	signingBackend := getEnv("SIGNING_BACKEND")
+	if signingBackend == "" {
+		signingBackend = SigningBackendLocal
+	}
+
+	// Parse PKCS11Module (default: ""); path of the PKCS#11 library for SIGNING_BACKEND=pkcs11
+	pkcs11Module := getEnv("PKCS11_MODULE")
+
+	// Parse PKCS11Slot (default: -1 = select the token by PKCS11_TOKEN_LABEL)
+	pkcs11Slot := -1
+	if val := getEnv("PKCS11_SLOT"); val != "" {
+		if v, err := strconv.Atoi(val); err == nil {
+			pkcs11Slot = v
+		}
+	}
+
+	// Parse PKCS11TokenLabel (default: "")
+	pkcs11TokenLabel := getEnv("PKCS11_TOKEN_LABEL")
+
+	// Parse PKCS11KeyLabel (default: "signer-ca"); label of the CA key pair and certificate
+	pkcs11KeyLabel := getEnv("PKCS11_KEY_LABEL")
+	if pkcs11KeyLabel == "" {
+		pkcs11KeyLabel = "signer-ca"
+	}
+
+	// Parse PKCS11PIN and PKCS11PINFile (default: ""); the file wins when both are set
+	pkcs11PIN := getEnv("PKCS11_PIN")
+	pkcs11PINFile := getEnv("PKCS11_PIN_FILE")
+
+	// Parse KMSPlugin and KMSPluginSocket (default: ""); plugin command line, or the
+	// Unix socket of a running plugin, for SIGNING_BACKEND=kms. The socket wins when both are set.
+	kmsPlugin := getEnv("KMS_PLUGIN")
+	kmsPluginSocket := getEnv("KMS_PLUGIN_SOCKET")
+
+	// Parse KMSKeyID (default: ""); passed to the plugin with every request, e.g. a key ARN
+	kmsKeyID := getEnv("KMS_KEY_ID")
+
+	// Parse KMSCACert (default: ""); PEM file with the CA certificate of the KMS key and its chain
+	kmsCACert := getEnv("KMS_CA_CERT")
+
+	// Parse VaultAddress (default: ""); Vault server for SIGNING_BACKEND=vault
+	vaultAddress := getEnv("VAULT_ADDR")
+
+	// Parse VaultPKIMount (default: "pki")
+	vaultPKIMount := getEnv("VAULT_PKI_MOUNT")
+	if vaultPKIMount == "" {
+		vaultPKIMount = "pki"
+	}
+
+	// Parse VaultPKIRole (default: "" = sign-verbatim)
+	vaultPKIRole := getEnv("VAULT_PKI_ROLE")
+
+	// Parse VaultAuthMethod (default: "kubernetes")
+	vaultAuthMethod := getEnv("VAULT_AUTH_METHOD")
+	if vaultAuthMethod == "" {
+		vaultAuthMethod = VaultAuthKubernetes
+	}
+
+	// Parse VaultToken (default: ""); used with VAULT_AUTH_METHOD=token
+	vaultToken := getEnv("VAULT_TOKEN")
+
+	// Parse VaultKubernetesRole (default: "")
+	vaultKubernetesRole := getEnv("VAULT_KUBERNETES_ROLE")
+
+	// Parse VaultKubernetesMount (default: "kubernetes")
+	vaultKubernetesMount := getEnv("VAULT_KUBERNETES_MOUNT")
+	if vaultKubernetesMount == "" {
+		vaultKubernetesMount = "kubernetes"
+	}
+
+	// Parse VaultCACert (default: "" = system roots); PEM file to verify Vault's TLS certificate
+	vaultCACert := getEnv("VAULT_CACERT")
+
+	// Parse SigningEndpoint (default: ""); serve-signing address for SIGNING_BACKEND=grpc
+	signingEndpoint := getEnv("SIGNING_ENDPOINT")
+
+	// Parse SigningServerAddress (default: ":9443"); listen address of `signer serve-signing`
+	signingServerAddress := getEnv("SIGNING_SERVER_ADDRESS")
+	if signingServerAddress == "" {
+		signingServerAddress = ":9443"
+	}
+
+	// Parse SigningTLSCert, SigningTLSKey and SigningTLSCA (default: ""); mutual TLS
+	// between the controller and serve-signing. The CA verifies the other end.
+	signingTLSCert := getEnv("SIGNING_TLS_CERT")
+	signingTLSKey := getEnv("SIGNING_TLS_KEY")
+	signingTLSCA := getEnv("SIGNING_TLS_CA")
+
+	// Parse SigningTLSServerName (default: "" = host of SIGNING_ENDPOINT)
+	signingTLSServerName := getEnv("SIGNING_TLS_SERVER_NAME")
+
+	// Parse SigningMaxValidity (default: "24h"); longest validity `signer serve-signing` issues
+	signingMaxValidityStr := getEnv("SIGNING_MAX_VALIDITY")
+	if signingMaxValidityStr == "" {
+		signingMaxValidityStr = "24h"
+	}
+	signingMaxValidity, _ := time.ParseDuration(signingMaxValidityStr)
+
 	// Parse MaxConcurrentReconciles (default: 1)
 	maxConcurrentReconciles := 1
 	if val := getEnv("MAX_CONCURRENT_RECONCILES"); val != "" {
@@ -113,6 +324,73 @@ func LoadConfig(getEnv func(string) string) *Config {
 		}
 	}
 
+	// Parse IssuanceMode (default: "pod-dns")
+	issuanceMode := getEnv("ISSUANCE_MODE")
+	if issuanceMode == "" {
+		issuanceMode = IssuanceModePodDNS
+	}
+
+	// Parse SPIFFETrustDomain (default: "cluster.local")
+	spiffeTrustDomain := getEnv("SPIFFE_TRUST_DOMAIN")
+	if spiffeTrustDomain == "" {
+		spiffeTrustDomain = "cluster.local"
+	}
+
+	// Parse ClusterDomain (default: "cluster.local")
+	clusterDomain := getEnv("CLUSTER_DOMAIN")
+	if clusterDomain == "" {
+		clusterDomain = defaultClusterDomain
+	}
+
+	// Parse ServiceSANs (default: false)
+	serviceSANs := false
+	if val := getEnv("SERVICE_SANS"); val != "" {
+		serviceSANs, _ = strconv.ParseBool(val)
+	}
+
+	// Parse PodIPSANs (default: false)
+	podIPSANs := false
+	if val := getEnv("POD_IP_SANS"); val != "" {
+		podIPSANs, _ = strconv.ParseBool(val)
+	}
+
+	// Parse PodIPWaitTimeout (default: "1m")
+	podIPWaitTimeoutStr := getEnv("POD_IP_WAIT_TIMEOUT")
+	if podIPWaitTimeoutStr == "" {
+		podIPWaitTimeoutStr = "1m"
+	}
+	podIPWaitTimeout, _ := time.ParseDuration(podIPWaitTimeoutStr)
+
+	// Parse SignerPolicies (default: false)
+	signerPolicies := false
+	if val := getEnv("SIGNER_POLICIES"); val != "" {
+		signerPolicies, _ = strconv.ParseBool(val)
+	}
+
+	// Parse AdmissionRules (default: "" = no rules); JSON list of {expression, message}
+	admissionRules := getEnv("ADMISSION_RULES")
+
+	// Parse ClusterTrustBundle (default: false)
+	clusterTrustBundle := false
+	if val := getEnv("CLUSTER_TRUST_BUNDLE"); val != "" {
+		clusterTrustBundle, _ = strconv.ParseBool(val)
+	}
+
+	// Parse TrustConfigMapName (default: "" = no ConfigMap distribution)
+	trustConfigMapName := getEnv("TRUST_CONFIGMAP_NAME")
+
+	// Parse TrustConfigMapKey (default: "ca.crt")
+	trustConfigMapKey := getEnv("TRUST_CONFIGMAP_KEY")
+	if trustConfigMapKey == "" {
+		trustConfigMapKey = defaultTrustConfigMapKey
+	}
+
+	// Parse TrustNamespaceSelector (default: "" = all namespaces); label selector syntax
+	trustNamespaceSelector := getEnv("TRUST_NAMESPACE_SELECTOR")
+
+	// Parse TrustExtraRoots (default: ""); PEM certificates distributed with the CA
+	trustExtraRoots := getEnv("TRUST_EXTRA_ROOTS")
+
 	return &Config{
 		SignerName:              signerName,
 		LogLevel:                level,
@@ -127,7 +405,56 @@ func LoadConfig(getEnv func(string) string) *Config {
 		CASecretNamespace:       caSecretNamespace,
 		CACertKey:               caCertKey,
 		CAKeyKey:                caKeyKey,
+		CACertFile:              caCertFile,
+		CAKeyFile:               caKeyFile,
+		CAKeyAlgorithm:          caKeyAlgorithm,
+		CASecretCreate:          caSecretCreate,
+		CALoadFailurePolicy:     caLoadFailurePolicy,
+		CARotationOverlap:       caRotationOverlap,
+		CARotationGrace:         caRotationGrace,
+		CAExpiryWarnings:        caExpiryWarnings,
+		CAAutoRenew:             caAutoRenew,
+		CARenewBefore:           caRenewBefore,
+		SigningBackend:          signingBackend,
+		PKCS11Module:            pkcs11Module,
+		PKCS11Slot:              pkcs11Slot,
+		PKCS11TokenLabel:        pkcs11TokenLabel,
+		PKCS11KeyLabel:          pkcs11KeyLabel,
+		PKCS11PIN:               pkcs11PIN,
+		PKCS11PINFile:           pkcs11PINFile,
+		KMSPlugin:               kmsPlugin,
+		KMSPluginSocket:         kmsPluginSocket,
+		KMSKeyID:                kmsKeyID,
+		KMSCACert:               kmsCACert,
+		VaultAddress:            vaultAddress,
+		VaultPKIMount:           vaultPKIMount,
+		VaultPKIRole:            vaultPKIRole,
+		VaultAuthMethod:         vaultAuthMethod,
+		VaultToken:              vaultToken,
+		VaultKubernetesRole:     vaultKubernetesRole,
+		VaultKubernetesMount:    vaultKubernetesMount,
+		VaultCACert:             vaultCACert,
+		SigningEndpoint:         signingEndpoint,
+		SigningServerAddress:    signingServerAddress,
+		SigningTLSCert:          signingTLSCert,
+		SigningTLSKey:           signingTLSKey,
+		SigningTLSCA:            signingTLSCA,
+		SigningTLSServerName:    signingTLSServerName,
+		SigningMaxValidity:      signingMaxValidity,
 		MaxConcurrentReconciles: maxConcurrentReconciles,
+		IssuanceMode:            issuanceMode,
+		SPIFFETrustDomain:       spiffeTrustDomain,
+		ClusterDomain:           clusterDomain,
+		ServiceSANs:             serviceSANs,
+		PodIPSANs:               podIPSANs,
+		PodIPWaitTimeout:        podIPWaitTimeout,
+		SignerPolicies:          signerPolicies,
+		AdmissionRules:          admissionRules,
+		ClusterTrustBundle:      clusterTrustBundle,
+		TrustConfigMapName:      trustConfigMapName,
+		TrustConfigMapKey:       trustConfigMapKey,
+		TrustNamespaceSelector:  trustNamespaceSelector,
+		TrustExtraRoots:         trustExtraRoots,
 	}
 }
 
@@ -140,7 +467,18 @@ func main() {
 	}
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	// `signer serve-signing` runs the remote signer for SIGNING_BACKEND=grpc instead of the controller
+	if len(os.Args) > 1 && os.Args[1] == serveSigningCommand {
+		log.Printf("Serving signing requests on %s", config.SigningServerAddress)
+		if err := runSigningServer(ctrl.SetupSignalHandler(), config); err != nil {
+			log.Fatal(err, "problem running signing server")
+		}
+		return
+	}
+
 	log.Printf("Using signer name: %s", config.SignerName)
+	log.Printf("Issuance mode: %s", config.IssuanceMode)
+	log.Printf("Signing backend: %s", config.SigningBackend)
 	log.Printf("Leader election: %v (ID: %s)", config.LeaderElection, config.LeaderElectionID)
 	log.Printf("Metrics: %s, Health probes: %s", config.MetricsBindAddress, config.HealthProbeBindAddress)
 
@@ -109,6 +109,9 @@ func TestLoadConfig_PodNamespaceFallback(t *testing.T) {
 	if config.LeaderElectionNamespace != "fallback-ns" {
 		t.Errorf("expected LeaderElectionNamespace 'fallback-ns', got %q", config.LeaderElectionNamespace)
 	}
+	if config.CASecretNamespace != "fallback-ns" {
+		t.Errorf("expected CASecretNamespace 'fallback-ns', got %q", config.CASecretNamespace)
+	}
 
 	// Verify priority
 	env["LEADER_ELECTION_NAMESPACE"] = "explicit-ns"
@@ -150,3 +153,397 @@ func TestLoadConfig_CASecretOverrides(t *testing.T) {
 		t.Errorf("expected CAKeyKey 'my.key', got %q", config.CAKeyKey)
 	}
 }
+
+func TestLoadConfig_IssuanceModeDefaults(t *testing.T) {
+	getEnv := func(key string) string { return "" }
+	config := LoadConfig(getEnv)
+
+	if config.IssuanceMode != IssuanceModePodDNS {
+		t.Errorf("expected IssuanceMode %q, got %q", IssuanceModePodDNS, config.IssuanceMode)
+	}
+	if config.SPIFFETrustDomain != "cluster.local" {
+		t.Errorf("expected SPIFFETrustDomain 'cluster.local', got %q", config.SPIFFETrustDomain)
+	}
+}
+
+func TestLoadConfig_IssuanceModeOverrides(t *testing.T) {
+	env := map[string]string{
+		"ISSUANCE_MODE":       "spiffe",
+		"SPIFFE_TRUST_DOMAIN": "example.org",
+	}
+	getEnv := func(key string) string { return env[key] }
+	config := LoadConfig(getEnv)
+
+	if config.IssuanceMode != IssuanceModeSPIFFE {
+		t.Errorf("expected IssuanceMode %q, got %q", IssuanceModeSPIFFE, config.IssuanceMode)
+	}
+	if config.SPIFFETrustDomain != "example.org" {
+		t.Errorf("expected SPIFFETrustDomain 'example.org', got %q", config.SPIFFETrustDomain)
+	}
+}
+
+func TestLoadConfig_ServiceSANs(t *testing.T) {
+	config := LoadConfig(func(key string) string { return "" })
+	if config.ServiceSANs {
+		t.Errorf("expected ServiceSANs false by default")
+	}
+	if config.ClusterDomain != "cluster.local" {
+		t.Errorf("expected ClusterDomain 'cluster.local', got %q", config.ClusterDomain)
+	}
+
+	env := map[string]string{
+		"SERVICE_SANS":   "true",
+		"CLUSTER_DOMAIN": "corp.local",
+	}
+	config = LoadConfig(func(key string) string { return env[key] })
+	if !config.ServiceSANs {
+		t.Errorf("expected ServiceSANs true")
+	}
+	if config.ClusterDomain != "corp.local" {
+		t.Errorf("expected ClusterDomain 'corp.local', got %q", config.ClusterDomain)
+	}
+}
+
+func TestLoadConfig_PodIPSANs(t *testing.T) {
+	config := LoadConfig(func(key string) string { return "" })
+	if config.PodIPSANs {
+		t.Errorf("expected PodIPSANs false by default")
+	}
+	if config.PodIPWaitTimeout != time.Minute {
+		t.Errorf("expected PodIPWaitTimeout 1m, got %v", config.PodIPWaitTimeout)
+	}
+
+	env := map[string]string{
+		"POD_IP_SANS":         "true",
+		"POD_IP_WAIT_TIMEOUT": "30s",
+	}
+	config = LoadConfig(func(key string) string { return env[key] })
+	if !config.PodIPSANs {
+		t.Errorf("expected PodIPSANs true")
+	}
+	if config.PodIPWaitTimeout != 30*time.Second {
+		t.Errorf("expected PodIPWaitTimeout 30s, got %v", config.PodIPWaitTimeout)
+	}
+}
+
+func TestLoadConfig_SignerPolicies(t *testing.T) {
+	config := LoadConfig(func(key string) string { return "" })
+	if config.SignerPolicies {
+		t.Errorf("expected SignerPolicies false by default")
+	}
+
+	config = LoadConfig(func(key string) string {
+		if key == "SIGNER_POLICIES" {
+			return "true"
+		}
+		return ""
+	})
+	if !config.SignerPolicies {
+		t.Errorf("expected SignerPolicies true")
+	}
+}
+
+func TestLoadConfig_AdmissionRules(t *testing.T) {
+	rules := `[{"expression": "true"}]`
+	config := LoadConfig(func(key string) string {
+		if key == "ADMISSION_RULES" {
+			return rules
+		}
+		return ""
+	})
+	if config.AdmissionRules != rules {
+		t.Errorf("expected AdmissionRules %q, got %q", rules, config.AdmissionRules)
+	}
+}
+
+func TestLoadConfig_CAKeyAlgorithm(t *testing.T) {
+	config := LoadConfig(func(key string) string { return "" })
+	if config.CAKeyAlgorithm != CAKeyAlgorithmRSA2048 {
+		t.Errorf("expected CAKeyAlgorithm %q by default, got %q", CAKeyAlgorithmRSA2048, config.CAKeyAlgorithm)
+	}
+
+	config = LoadConfig(func(key string) string {
+		if key == "CA_KEY_ALGORITHM" {
+			return "ECDSAP384"
+		}
+		return ""
+	})
+	if config.CAKeyAlgorithm != CAKeyAlgorithmECDSAP384 {
+		t.Errorf("expected CAKeyAlgorithm %q, got %q", CAKeyAlgorithmECDSAP384, config.CAKeyAlgorithm)
+	}
+}
+
+func TestLoadConfig_ClusterTrustBundle(t *testing.T) {
+	config := LoadConfig(func(key string) string { return "" })
+	if config.ClusterTrustBundle {
+		t.Errorf("expected ClusterTrustBundle false by default")
+	}
+
+	config = LoadConfig(func(key string) string {
+		if key == "CLUSTER_TRUST_BUNDLE" {
+			return "true"
+		}
+		return ""
+	})
+	if !config.ClusterTrustBundle {
+		t.Errorf("expected ClusterTrustBundle true")
+	}
+}
+
+func TestLoadConfig_TrustDistribution(t *testing.T) {
+	config := LoadConfig(func(key string) string { return "" })
+	if config.TrustConfigMapName != "" {
+		t.Errorf("expected TrustConfigMapName empty by default, got %q", config.TrustConfigMapName)
+	}
+	if config.TrustConfigMapKey != "ca.crt" {
+		t.Errorf("expected TrustConfigMapKey 'ca.crt', got %q", config.TrustConfigMapKey)
+	}
+
+	env := map[string]string{
+		"TRUST_CONFIGMAP_NAME":     "signer-ca",
+		"TRUST_CONFIGMAP_KEY":      "root.pem",
+		"TRUST_NAMESPACE_SELECTOR": "mesh=enabled",
+	}
+	config = LoadConfig(func(key string) string { return env[key] })
+	if config.TrustConfigMapName != "signer-ca" {
+		t.Errorf("expected TrustConfigMapName 'signer-ca', got %q", config.TrustConfigMapName)
+	}
+	if config.TrustConfigMapKey != "root.pem" {
+		t.Errorf("expected TrustConfigMapKey 'root.pem', got %q", config.TrustConfigMapKey)
+	}
+	if config.TrustNamespaceSelector != "mesh=enabled" {
+		t.Errorf("expected TrustNamespaceSelector 'mesh=enabled', got %q", config.TrustNamespaceSelector)
+	}
+}
+
+func TestLoadConfig_CARotation(t *testing.T) {
+	config := LoadConfig(func(key string) string { return "" })
+	if config.CARotationOverlap != 24*time.Hour {
+		t.Errorf("expected CARotationOverlap 24h, got %v", config.CARotationOverlap)
+	}
+	if config.CARotationGrace != 24*time.Hour {
+		t.Errorf("expected CARotationGrace 24h, got %v", config.CARotationGrace)
+	}
+
+	env := map[string]string{
+		"CA_ROTATION_OVERLAP": "1h",
+		"CA_ROTATION_GRACE":   "2h",
+	}
+	config = LoadConfig(func(key string) string { return env[key] })
+	if config.CARotationOverlap != time.Hour {
+		t.Errorf("expected CARotationOverlap 1h, got %v", config.CARotationOverlap)
+	}
+	if config.CARotationGrace != 2*time.Hour {
+		t.Errorf("expected CARotationGrace 2h, got %v", config.CARotationGrace)
+	}
+}
+
+func TestLoadConfig_CASecretCreate(t *testing.T) {
+	config := LoadConfig(func(key string) string { return "" })
+	if config.CASecretCreate {
+		t.Errorf("expected CASecretCreate false by default")
+	}
+
+	config = LoadConfig(func(key string) string {
+		if key == "CA_SECRET_CREATE" {
+			return "true"
+		}
+		return ""
+	})
+	if !config.CASecretCreate {
+		t.Errorf("expected CASecretCreate true")
+	}
+}
+
+func TestLoadConfig_CAExpiry(t *testing.T) {
+	config := LoadConfig(func(key string) string { return "" })
+	if len(config.CAExpiryWarnings) != 3 || config.CAExpiryWarnings[0] != 720*time.Hour || config.CAExpiryWarnings[2] != 24*time.Hour {
+		t.Errorf("expected CAExpiryWarnings [720h 168h 24h], got %v", config.CAExpiryWarnings)
+	}
+	if config.CAAutoRenew != "" {
+		t.Errorf("expected CAAutoRenew empty by default, got %q", config.CAAutoRenew)
+	}
+	if config.CARenewBefore != 720*time.Hour {
+		t.Errorf("expected CARenewBefore 720h, got %v", config.CARenewBefore)
+	}
+
+	env := map[string]string{
+		"CA_EXPIRY_WARNING_THRESHOLDS": "48h, bogus,1h",
+		"CA_AUTO_RENEW":                "new-key",
+		"CA_RENEW_BEFORE":              "96h",
+	}
+	config = LoadConfig(func(key string) string { return env[key] })
+	if len(config.CAExpiryWarnings) != 2 || config.CAExpiryWarnings[0] != 48*time.Hour || config.CAExpiryWarnings[1] != time.Hour {
+		t.Errorf("expected CAExpiryWarnings [48h 1h], got %v", config.CAExpiryWarnings)
+	}
+	if config.CAAutoRenew != CARenewNewKey {
+		t.Errorf("expected CAAutoRenew %q, got %q", CARenewNewKey, config.CAAutoRenew)
+	}
+	if config.CARenewBefore != 96*time.Hour {
+		t.Errorf("expected CARenewBefore 96h, got %v", config.CARenewBefore)
+	}
+}
+
+func TestLoadConfig_CALoadFailurePolicy(t *testing.T) {
+	config := LoadConfig(func(key string) string { return "" })
+	if config.CALoadFailurePolicy != CALoadFailureUnready {
+		t.Errorf("expected CALoadFailurePolicy %q by default, got %q", CALoadFailureUnready, config.CALoadFailurePolicy)
+	}
+
+	config = LoadConfig(func(key string) string {
+		if key == "CA_LOAD_FAILURE_POLICY" {
+			return "pause"
+		}
+		return ""
+	})
+	if config.CALoadFailurePolicy != CALoadFailurePause {
+		t.Errorf("expected CALoadFailurePolicy %q, got %q", CALoadFailurePause, config.CALoadFailurePolicy)
+	}
+}
+
+func TestLoadConfig_SigningBackend(t *testing.T) {
+	config := LoadConfig(func(key string) string { return "" })
+	if config.SigningBackend != SigningBackendLocal {
+		t.Errorf("expected SigningBackend %q by default, got %q", SigningBackendLocal, config.SigningBackend)
+	}
+
+	config = LoadConfig(func(key string) string {
+		if key == "SIGNING_BACKEND" {
+			return "vault"
+		}
+		return ""
+	})
+	if config.SigningBackend != "vault" {
+		t.Errorf("expected SigningBackend %q, got %q", "vault", config.SigningBackend)
+	}
+}
+
+func TestLoadConfig_PKCS11(t *testing.T) {
+	config := LoadConfig(func(key string) string { return "" })
+	if config.PKCS11Slot != -1 {
+		t.Errorf("expected PKCS11Slot -1 by default, got %d", config.PKCS11Slot)
+	}
+	if config.PKCS11KeyLabel != "signer-ca" {
+		t.Errorf("expected PKCS11KeyLabel %q by default, got %q", "signer-ca", config.PKCS11KeyLabel)
+	}
+
+	env := map[string]string{
+		"SIGNING_BACKEND":    "pkcs11",
+		"PKCS11_MODULE":      "/usr/lib/softhsm/libsofthsm2.so",
+		"PKCS11_SLOT":        "3",
+		"PKCS11_TOKEN_LABEL": "signer",
+		"PKCS11_KEY_LABEL":   "ca",
+		"PKCS11_PIN":         "1234",
+		"PKCS11_PIN_FILE":    "/var/run/pkcs11/pin",
+	}
+	config = LoadConfig(func(key string) string { return env[key] })
+	if config.SigningBackend != SigningBackendPKCS11 {
+		t.Errorf("expected SigningBackend %q, got %q", SigningBackendPKCS11, config.SigningBackend)
+	}
+	if config.PKCS11Module != "/usr/lib/softhsm/libsofthsm2.so" {
+		t.Errorf("expected PKCS11Module to be set, got %q", config.PKCS11Module)
+	}
+	if config.PKCS11Slot != 3 {
+		t.Errorf("expected PKCS11Slot 3, got %d", config.PKCS11Slot)
+	}
+	if config.PKCS11TokenLabel != "signer" || config.PKCS11KeyLabel != "ca" {
+		t.Errorf("expected labels signer/ca, got %q/%q", config.PKCS11TokenLabel, config.PKCS11KeyLabel)
+	}
+	if config.PKCS11PIN != "1234" || config.PKCS11PINFile != "/var/run/pkcs11/pin" {
+		t.Errorf("expected PIN and PIN file to be set, got %q/%q", config.PKCS11PIN, config.PKCS11PINFile)
+	}
+}
+
+func TestLoadConfig_Vault(t *testing.T) {
+	config := LoadConfig(func(key string) string { return "" })
+	if config.VaultPKIMount != "pki" || config.VaultKubernetesMount != "kubernetes" {
+		t.Errorf("expected mounts pki/kubernetes by default, got %q/%q", config.VaultPKIMount, config.VaultKubernetesMount)
+	}
+	if config.VaultAuthMethod != VaultAuthKubernetes {
+		t.Errorf("expected VaultAuthMethod %q by default, got %q", VaultAuthKubernetes, config.VaultAuthMethod)
+	}
+
+	env := map[string]string{
+		"VAULT_ADDR":             "https://vault:8200",
+		"VAULT_PKI_MOUNT":        "pki_int",
+		"VAULT_PKI_ROLE":         "pods",
+		"VAULT_AUTH_METHOD":      "token",
+		"VAULT_TOKEN":            "s.token",
+		"VAULT_KUBERNETES_ROLE":  "signer",
+		"VAULT_KUBERNETES_MOUNT": "k8s",
+		"VAULT_CACERT":           "/etc/vault/ca.crt",
+	}
+	config = LoadConfig(func(key string) string { return env[key] })
+	if config.VaultAddress != "https://vault:8200" || config.VaultPKIMount != "pki_int" || config.VaultPKIRole != "pods" {
+		t.Errorf("unexpected Vault PKI settings %q %q %q", config.VaultAddress, config.VaultPKIMount, config.VaultPKIRole)
+	}
+	if config.VaultAuthMethod != VaultAuthToken || config.VaultToken != "s.token" {
+		t.Errorf("unexpected Vault token auth settings %q %q", config.VaultAuthMethod, config.VaultToken)
+	}
+	if config.VaultKubernetesRole != "signer" || config.VaultKubernetesMount != "k8s" {
+		t.Errorf("unexpected Vault Kubernetes auth settings %q %q", config.VaultKubernetesRole, config.VaultKubernetesMount)
+	}
+	if config.VaultCACert != "/etc/vault/ca.crt" {
+		t.Errorf("expected VaultCACert to be set, got %q", config.VaultCACert)
+	}
+}
+
+func TestLoadConfig_RemoteSigner(t *testing.T) {
+	config := LoadConfig(func(key string) string { return "" })
+	if config.SigningServerAddress != ":9443" {
+		t.Errorf("expected SigningServerAddress :9443 by default, got %q", config.SigningServerAddress)
+	}
+	if config.SigningMaxValidity != 24*time.Hour {
+		t.Errorf("expected SigningMaxValidity 24h by default, got %v", config.SigningMaxValidity)
+	}
+
+	env := map[string]string{
+		"SIGNING_ENDPOINT":        "signer-signing.signer-system:9443",
+		"SIGNING_SERVER_ADDRESS":  ":8443",
+		"SIGNING_TLS_CERT":        "/etc/signing-tls/tls.crt",
+		"SIGNING_TLS_KEY":         "/etc/signing-tls/tls.key",
+		"SIGNING_TLS_CA":          "/etc/signing-tls/ca.crt",
+		"SIGNING_TLS_SERVER_NAME": "signer-signing",
+		"SIGNING_MAX_VALIDITY":    "48h",
+	}
+	config = LoadConfig(func(key string) string { return env[key] })
+	if config.SigningEndpoint != "signer-signing.signer-system:9443" || config.SigningServerAddress != ":8443" {
+		t.Errorf("unexpected remote signer addresses %q %q", config.SigningEndpoint, config.SigningServerAddress)
+	}
+	if config.SigningTLSCert != "/etc/signing-tls/tls.crt" || config.SigningTLSKey != "/etc/signing-tls/tls.key" || config.SigningTLSCA != "/etc/signing-tls/ca.crt" {
+		t.Errorf("unexpected remote signer TLS files %q %q %q", config.SigningTLSCert, config.SigningTLSKey, config.SigningTLSCA)
+	}
+	if config.SigningTLSServerName != "signer-signing" {
+		t.Errorf("expected SigningTLSServerName to be set, got %q", config.SigningTLSServerName)
+	}
+	if config.SigningMaxValidity != 48*time.Hour {
+		t.Errorf("expected SigningMaxValidity 48h, got %v", config.SigningMaxValidity)
+	}
+}
+
+func TestLoadConfig_KMS(t *testing.T) {
+	env := map[string]string{
+		"KMS_PLUGIN":        "/usr/local/bin/aws-kms-plugin --region eu-west-1",
+		"KMS_PLUGIN_SOCKET": "/run/kms/plugin.sock",
+		"KMS_KEY_ID":        "alias/signer-ca",
+		"KMS_CA_CERT":       "/etc/signer-kms/ca.crt",
+	}
+	config := LoadConfig(func(key string) string { return env[key] })
+	if config.KMSPlugin != "/usr/local/bin/aws-kms-plugin --region eu-west-1" || config.KMSPluginSocket != "/run/kms/plugin.sock" {
+		t.Errorf("unexpected KMS plugin settings %q %q", config.KMSPlugin, config.KMSPluginSocket)
+	}
+	if config.KMSKeyID != "alias/signer-ca" || config.KMSCACert != "/etc/signer-kms/ca.crt" {
+		t.Errorf("unexpected KMS key settings %q %q", config.KMSKeyID, config.KMSCACert)
+	}
+}
+
+func TestLoadConfig_CAFiles(t *testing.T) {
+	env := map[string]string{
+		"CA_CERT_FILE": "/etc/signer-ca/tls.crt",
+		"CA_KEY_FILE":  "/etc/signer-ca/tls.key",
+	}
+	config := LoadConfig(func(key string) string { return env[key] })
+	if config.CACertFile != "/etc/signer-ca/tls.crt" || config.CAKeyFile != "/etc/signer-ca/tls.key" {
+		t.Errorf("unexpected CA files %q %q", config.CACertFile, config.CAKeyFile)
+	}
+}
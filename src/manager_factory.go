@@ -3,18 +3,27 @@ package main
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"slices"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/events"
 	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
-	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -26,38 +35,71 @@ import (
 var (
 	scheme               = runtime.NewScheme()
 	newManagerFunc       = ctrl.NewManager
-	newCAFunc            = NewCA
+	newCAFunc            = NewCAWithKeyAlgorithm
+	newPKCS11CAFunc      = newPKCS11CA
+	newKMSCAFunc         = newKMSCA
 	setupWithManagerFunc = func(r *SignerReconciler, mgr ctrl.Manager, options controller.Options) error {
 		return r.SetupWithManager(mgr, options)
 	}
+	setupInformersFunc = func(mgr ctrl.Manager, objs []client.Object) error {
+		// Register informers up front so they sync with the manager cache
+		// instead of lazily on the first PodCertificateRequest.
+		for _, obj := range objs {
+			if _, err := mgr.GetCache().GetInformer(context.Background(), obj); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	setupTrustBundleFunc = func(mgr ctrl.Manager, ca *CAHelper, config *Config) error {
+		return (&TrustBundleReconciler{
+			Client:     mgr.GetClient(),
+			CA:         ca,
+			SignerName: config.SignerName,
+		}).SetupWithManager(mgr)
+	}
+	setupTrustDistributionFunc = func(mgr ctrl.Manager, r *TrustDistributionReconciler) error {
+		return r.SetupWithManager(mgr)
+	}
+	setupSignerPolicyFunc = func(mgr ctrl.Manager) error {
+		return (&SignerPolicyReconciler{Client: mgr.GetClient()}).SetupWithManager(mgr)
+	}
 	setupSecretWatcherFunc = func(mgr ctrl.Manager, ca *CAHelper, config *Config) error {
-		return ctrl.NewControllerManagedBy(mgr).
+		r := &SecretReconciler{
+			Client:    mgr.GetClient(),
+			APIReader: mgr.GetAPIReader(),
+			CA:        ca,
+			Config:    config,
+			Recorder:  mgr.GetEventRecorder(eventRecorderName),
+			Elected:   mgr.Elected(),
+		}
+		// Deletes mark the CA as failed; the last loaded CA stays in memory
+		isCASecret := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+			return obj.GetName() == config.CASecretName && obj.GetNamespace() == config.CASecretNamespace
+		})
+
+		// Every replica signs with the CA, so every replica reloads it
+		if err := ctrl.NewControllerManagedBy(mgr).
 			Named("ca-secret-watcher").
 			For(&corev1.Secret{}).
-			WithEventFilter(predicate.Funcs{
-				UpdateFunc: func(e event.UpdateEvent) bool {
-					return e.ObjectNew.GetName() == config.CASecretName &&
-						e.ObjectNew.GetNamespace() == config.CASecretNamespace
-				},
-				CreateFunc: func(e event.CreateEvent) bool {
-					return e.Object.GetName() == config.CASecretName &&
-						e.Object.GetNamespace() == config.CASecretNamespace
-				},
-				DeleteFunc: func(e event.DeleteEvent) bool {
-					return false // Ignore Secret deletes; reloads only on Create/Update events
-				},
-			}).
-			Complete(&SecretReconciler{
-				Client: mgr.GetClient(),
-				CA:     ca,
-				Config: config,
-			})
+			WithEventFilter(isCASecret).
+			WithOptions(controller.Options{NeedLeaderElection: ptr.To(false)}).
+			Complete(r); err != nil {
+			return err
+		}
+		// Rotation writes the Secret, so only the leader drives it
+		return ctrl.NewControllerManagedBy(mgr).
+			Named("ca-rotation").
+			For(&corev1.Secret{}).
+			WithEventFilter(isCASecret).
+			Complete(reconcile.Func(r.ReconcileRotation))
 	}
 )
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(certificatesv1beta1.AddToScheme(scheme))
+	utilruntime.Must(AddSignerPolicyToScheme(scheme))
 }
 
 func CreateManager(kubeConfig *rest.Config, config *Config) (ctrl.Manager, error) {
@@ -65,6 +107,75 @@ func CreateManager(kubeConfig *rest.Config, config *Config) (ctrl.Manager, error
 		return nil, fmt.Errorf("kubeConfig and config must not be nil")
 	}
 
+	switch config.IssuanceMode {
+	case "", IssuanceModePodDNS, IssuanceModeSPIFFE:
+	default:
+		return nil, fmt.Errorf("unknown issuance mode %q", config.IssuanceMode)
+	}
+	if config.IssuanceMode == IssuanceModeSPIFFE {
+		// A bad trust domain would fail every request, so refuse to start instead
+		if err := validateTrustDomain(config.SPIFFETrustDomain); err != nil {
+			return nil, fmt.Errorf("invalid SPIFFE trust domain: %w", err)
+		}
+	}
+
+	switch config.CALoadFailurePolicy {
+	case "", CALoadFailureUnready, CALoadFailurePause:
+	default:
+		return nil, fmt.Errorf("unknown CA load failure policy %q", config.CALoadFailurePolicy)
+	}
+
+	switch config.CAAutoRenew {
+	case "", CARenewSameKey, CARenewNewKey:
+	default:
+		return nil, fmt.Errorf("unknown CA auto-renew mode %q", config.CAAutoRenew)
+	}
+	if externalKeySigningBackend(config.SigningBackend) && config.CAAutoRenew == CARenewNewKey {
+		return nil, fmt.Errorf("CA auto-renew mode %q would move the CA key out of the HSM or KMS of the %s signing backend", CARenewNewKey, config.SigningBackend)
+	}
+	if (config.CACertFile == "") != (config.CAKeyFile == "") {
+		return nil, fmt.Errorf("CA_CERT_FILE and CA_KEY_FILE must be set together")
+	}
+	if config.CACertFile != "" && config.CAAutoRenew != "" {
+		return nil, fmt.Errorf("CA auto-renew is not supported with CA files, renew the CA where the files come from")
+	}
+	if keylessSigningBackend(config.SigningBackend) && config.CAAutoRenew != "" {
+		return nil, fmt.Errorf("CA auto-renew is not supported with the %s signing backend, it manages its own CA", config.SigningBackend)
+	}
+	if err := validateCASecretNamespace(config); err != nil {
+		return nil, err
+	}
+	if err := validateCARotationGrace(config); err != nil {
+		return nil, err
+	}
+
+	// Compile admission rules up front so a broken rule fails startup
+	rules, err := ParseAdmissionRules(config.AdmissionRules)
+	if err != nil {
+		return nil, err
+	}
+	admissionRules, err := NewAdmissionRules(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate trust distribution settings up front
+	var trustSelector labels.Selector
+	var trustExtraRoots []byte
+	if config.TrustConfigMapName != "" {
+		trustSelector, err = labels.Parse(config.TrustNamespaceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trust namespace selector: %w", err)
+		}
+		if config.TrustExtraRoots != "" {
+			roots, err := parseCertificatesPEM([]byte(config.TrustExtraRoots))
+			if err != nil {
+				return nil, fmt.Errorf("invalid trust extra roots: %w", err)
+			}
+			trustExtraRoots = encodeCertificatesPEM(roots)
+		}
+	}
+
 	mgrOptions := ctrl.Options{
 		Scheme:                  scheme,
 		Metrics:                 metricsserver.Options{BindAddress: config.MetricsBindAddress},
@@ -74,6 +185,17 @@ func CreateManager(kubeConfig *rest.Config, config *Config) (ctrl.Manager, error
 		LeaderElectionNamespace: config.LeaderElectionNamespace,
 	}
 
+	mgrOptions.Cache.ByObject = map[client.Object]cache.ByObject{}
+	if config.TrustConfigMapName != "" {
+		// Only cache the trust ConfigMaps, not every ConfigMap in the cluster
+		mgrOptions.Cache.ByObject[&corev1.ConfigMap{}] = cache.ByObject{
+			Field: fields.OneTermEqualSelector("metadata.name", config.TrustConfigMapName),
+		}
+	}
+	if usesCASecret(config) {
+		mgrOptions.Cache.ByObject[&corev1.Secret{}] = caSecretCache(config)
+	}
+
 	mgr, err := newManagerFunc(kubeConfig, mgrOptions)
 	if err != nil {
 		return nil, err
@@ -89,13 +211,54 @@ func CreateManager(kubeConfig *rest.Config, config *Config) (ctrl.Manager, error
 	// Initialize the CA
 	var ca *CAHelper
 
-	if config.CASecretName != "" {
+	if config.SigningBackend == SigningBackendPKCS11 {
+		// The key never leaves the token, so the CA Secret is not used
+		ca, err = newPKCS11CAFunc(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load CA from PKCS#11 token: %w", err)
+		}
+	} else if config.SigningBackend == SigningBackendKMS {
+		// The key never leaves the KMS, the certificate comes from KMS_CA_CERT
+		ca, err = newKMSCAFunc(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load CA for KMS key: %w", err)
+		}
+	} else if keylessSigningBackend(config.SigningBackend) {
+		// Vault or the remote signer holds the key; the backend fills the CA with its issuing chain
+		ca = &CAHelper{}
+	} else if config.CACertFile != "" {
+		// Mounted files need no Secret access; the watcher reloads them when they are swapped
+		ca, err = NewCAFromFiles(config.CACertFile, config.CAKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load CA from files: %w", err)
+		}
+		if err := mgr.Add(&CAFileWatcher{
+			CA:                ca,
+			CertFile:          config.CACertFile,
+			KeyFile:           config.CAKeyFile,
+			LoadFailurePolicy: config.CALoadFailurePolicy,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to add CA file watcher: %w", err)
+		}
+	} else if config.CASecretName != "" {
 		ctx := context.Background()
 		// Use APIReader to bypass manager cache during initialization.
 		// The manager's cache is not yet synced at this point (only syncs on mgr.Start()),
 		// but APIReader provides live cluster lookups via the API server.
 		ca, err = NewCAFromSecret(ctx, mgr.GetAPIReader(), config.CASecretName, config.CASecretNamespace, config.CACertKey, config.CAKeyKey)
-		if err != nil {
+		switch {
+		case apierrors.IsNotFound(err) && config.CASecretCreate:
+			// Start without a CA; the leader creates the Secret and the watcher loads it
+			log.Log.Info("CA secret not found, the leader will generate it", "secret", config.CASecretName, "namespace", config.CASecretNamespace)
+			ca = &CAHelper{}
+			if err := mgr.Add(&CASecretGenerator{
+				Client:   mgr.GetClient(),
+				Config:   config,
+				Recorder: mgr.GetEventRecorder(eventRecorderName),
+			}); err != nil {
+				return nil, fmt.Errorf("failed to add CA secret generator: %w", err)
+			}
+		case err != nil:
 			return nil, fmt.Errorf("failed to load CA from secret: %w", err)
 		}
 
@@ -105,12 +268,85 @@ func CreateManager(kubeConfig *rest.Config, config *Config) (ctrl.Manager, error
 		}
 
 	} else {
-		ca, err = newCAFunc()
+		ca, err = newCAFunc(config.CAKeyAlgorithm)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	backend, err := newSigningBackend(config, ca)
+	if err != nil {
+		return nil, err
+	}
+	if runnable, ok := backend.(manager.Runnable); ok {
+		if err := mgr.Add(runnable); err != nil {
+			return nil, fmt.Errorf("failed to add signing backend: %w", err)
+		}
+	}
+
+	// Not ready until the CA is usable and requests are cached
+	caCheck := caReadyzCheck(ca)
+	if keylessSigningBackend(config.SigningBackend) {
+		caCheck = issuerReadyzCheck(backend)
+	}
+	if err := mgr.AddReadyzCheck("ca", caCheck); err != nil {
+		return nil, err
+	}
+	if remote, ok := backend.(*RemoteSigningBackend); ok {
+		if err := mgr.AddReadyzCheck("remote-signer", remoteSignerReadyzCheck(remote)); err != nil {
+			return nil, err
+		}
+	}
+	if err := mgr.AddReadyzCheck("informers", informerSyncedCheck(mgr.GetCache(), &certificatesv1beta1.PodCertificateRequest{})); err != nil {
+		return nil, err
+	}
+	if config.LeaderElection {
+		if err := mgr.AddMetricsServerExtraHandler(leaderStatusPath, leaderStatusHandler(mgr.Elected())); err != nil {
+			return nil, fmt.Errorf("failed to add leader status endpoint: %w", err)
+		}
+	}
+
+	if config.ClusterTrustBundle {
+		if err := setupTrustBundleFunc(mgr, ca, config); err != nil {
+			return nil, fmt.Errorf("failed to setup ClusterTrustBundle publisher: %w", err)
+		}
+	}
+
+	if config.TrustConfigMapName != "" {
+		if err := setupTrustDistributionFunc(mgr, &TrustDistributionReconciler{
+			Client:            mgr.GetClient(),
+			CA:                ca,
+			ConfigMapName:     config.TrustConfigMapName,
+			ConfigMapKey:      config.TrustConfigMapKey,
+			NamespaceSelector: trustSelector,
+			ExtraRoots:        trustExtraRoots,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to setup trust distribution: %w", err)
+		}
+	}
+
+	if config.SignerPolicies {
+		if err := setupSignerPolicyFunc(mgr); err != nil {
+			return nil, fmt.Errorf("failed to setup SignerPolicy validation: %w", err)
+		}
+	}
+
+	var informers []client.Object
+	if config.ServiceSANs || config.PodIPSANs {
+		informers = appendInformers(informers, &corev1.Pod{}, &corev1.Service{})
+	}
+	if config.SignerPolicies {
+		informers = appendInformers(informers, &SignerPolicy{}, &corev1.Namespace{})
+	}
+	if admissionRules.Len() > 0 {
+		informers = appendInformers(informers, &corev1.Pod{}, &corev1.Namespace{})
+	}
+	if len(informers) > 0 {
+		if err := setupInformersFunc(mgr, informers); err != nil {
+			return nil, fmt.Errorf("failed to setup informers: %w", err)
+		}
+	}
+
 	ctrlOptions := controller.Options{
 		RateLimiter: workqueue.DefaultTypedControllerRateLimiter[reconcile.Request](),
 	}
@@ -118,11 +354,33 @@ func CreateManager(kubeConfig *rest.Config, config *Config) (ctrl.Manager, error
 		ctrlOptions.MaxConcurrentReconciles = config.MaxConcurrentReconciles
 	}
 
-	if err = setupWithManagerFunc(&SignerReconciler{
-		Client:     mgr.GetClient(),
-		CA:         ca,
+	requestMetrics := &RequestMetricsRunnable{
+		Reader:     mgr.GetClient(),
 		SignerName: config.SignerName,
-		Config:     config,
+		Interval:   requestMetricsInterval,
+	}
+	if err := mgr.Add(requestMetrics); err != nil {
+		return nil, fmt.Errorf("failed to add request metrics: %w", err)
+	}
+
+	if err := mgr.Add(&CAExpiryMonitor{
+		Client:   mgr.GetClient(),
+		CA:       ca,
+		Config:   config,
+		Recorder: mgr.GetEventRecorder(eventRecorderName),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to add CA expiry monitor: %w", err)
+	}
+
+	if err = setupWithManagerFunc(&SignerReconciler{
+		Client:         mgr.GetClient(),
+		CA:             ca,
+		SignerName:     config.SignerName,
+		Backend:        backend,
+		Config:         config,
+		AdmissionRules: admissionRules,
+		Recorder:       mgr.GetEventRecorder(eventRecorderName),
+		RequestMetrics: requestMetrics,
 	}, mgr, ctrlOptions); err != nil {
 		return nil, err
 	}
@@ -130,11 +388,79 @@ func CreateManager(kubeConfig *rest.Config, config *Config) (ctrl.Manager, error
 	return mgr, nil
 }
 
-// SecretReconciler reloads CA when secret changes
+// usesCASecret reports whether the CA is loaded from the CA Secret rather than
+// from an HSM, a KMS, a keyless backend, CA files or memory
+func usesCASecret(config *Config) bool {
+	return config.CASecretName != "" && config.CACertFile == "" &&
+		!externalKeySigningBackend(config.SigningBackend) && !keylessSigningBackend(config.SigningBackend)
+}
+
+// validateCASecretNamespace refuses a CA Secret without a namespace, which
+// would cache and watch Secrets in every namespace
+func validateCASecretNamespace(config *Config) error {
+	if usesCASecret(config) && config.CASecretNamespace == "" {
+		return fmt.Errorf("CA_SECRET_NAMESPACE (or POD_NAMESPACE) must be set with CA_SECRET_NAME")
+	}
+	return nil
+}
+
+// validateCARotationGrace refuses a rotation grace shorter than the certificate
+// validity: leaves signed by the old CA just before the switch would outlive
+// its trust and fail to verify until their holders refresh them.
+func validateCARotationGrace(config *Config) error {
+	if !usesCASecret(config) {
+		return nil
+	}
+	validity := minCertValidity
+	if config.CertValidity > validity {
+		validity = config.CertValidity
+	}
+	if config.CARotationGrace < validity {
+		return fmt.Errorf("CA rotation grace %s is shorter than the certificate validity %s", config.CARotationGrace, validity)
+	}
+	return nil
+}
+
+// caSecretCache caches only the CA Secret, so no cluster-wide Secret access is needed
+func caSecretCache(config *Config) cache.ByObject {
+	return cache.ByObject{
+		Namespaces: map[string]cache.Config{config.CASecretNamespace: {}},
+		Field:      fields.OneTermEqualSelector("metadata.name", config.CASecretName),
+	}
+}
+
+// appendInformers adds objects whose type is not already in the list
+func appendInformers(objs []client.Object, add ...client.Object) []client.Object {
+	for _, obj := range add {
+		if !slices.ContainsFunc(objs, func(o client.Object) bool {
+			return reflect.TypeOf(o) == reflect.TypeOf(obj)
+		}) {
+			objs = append(objs, obj)
+		}
+	}
+	return objs
+}
+
+// CA load failure policies: what to do while the CA Secret is deleted or invalid
+const (
+	// CALoadFailureUnready keeps signing with the last loaded CA and fails readiness
+	CALoadFailureUnready = "unready"
+	// CALoadFailurePause additionally stops issuing until a valid CA is loaded
+	CALoadFailurePause = "pause"
+)
+
+// SecretReconciler reloads the CA when the CA Secret changes, on every replica,
+// and drives CA rotations from the leader through ReconcileRotation
 type SecretReconciler struct {
 	client.Client
-	CA     *CAHelper
-	Config *Config
+	// APIReader reads the rotation status ConfigMap, which is not cached
+	APIReader client.Reader
+	CA        *CAHelper
+	Config    *Config
+	Recorder  events.EventRecorder
+	// Elected is closed once this replica leads. Every replica reloads, so
+	// only the leader reports reloads; nil reports on every replica.
+	Elected <-chan struct{}
 }
 
 func (r *SecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -143,11 +469,48 @@ func (r *SecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 
 	err := r.CA.LoadFromSecret(ctx, r.Client, r.Config.CASecretName, r.Config.CASecretNamespace, r.Config.CACertKey, r.Config.CAKeyKey)
 	if err != nil {
-		log.Error(err, "Failed to reload CA from secret")
-		// Backoff might be good, return error
-		return ctrl.Result{}, err
+		// Keep the last-known-good CA. Retrying cannot help until the Secret
+		// changes again, and that change triggers the next reload.
+		log.Error(err, "Failed to reload CA from secret, keeping the last loaded CA", "policy", r.Config.CALoadFailurePolicy)
+		r.CA.setLoadError(err)
+		CALoadErrors.Inc()
+		reason := "CAReloadFailed"
+		if apierrors.IsNotFound(err) {
+			reason = "CASecretMissing"
+		}
+		r.recordReloadEvent(ctx, req, corev1.EventTypeWarning, reason, fmt.Sprintf("Failed to reload CA, keeping the last loaded CA: %v", err))
+		return ctrl.Result{}, nil
 	}
 
 	log.Info("CA successfully reloaded from secret")
+	r.recordReloadEvent(ctx, req, corev1.EventTypeNormal, "CAReloaded", "CA reloaded from secret")
 	return ctrl.Result{}, nil
 }
+
+// recordReloadEvent emits a reload event regarding the CA Secret, on the leader only
+func (r *SecretReconciler) recordReloadEvent(ctx context.Context, req ctrl.Request, eventType, reason, note string) {
+	if r.Recorder == nil {
+		return
+	}
+	if r.Elected != nil {
+		select {
+		case <-r.Elected:
+		default:
+			return
+		}
+	}
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, req.NamespacedName, secret); err != nil {
+		// A deleted Secret can only be referenced by name
+		secret = &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace}}
+	}
+	r.recordEvent(secret, eventType, reason, eventActionReload, note)
+}
+
+// recordEvent emits an event regarding the CA Secret
+func (r *SecretReconciler) recordEvent(secret *corev1.Secret, eventType, reason, action, note string) {
+	if r.Recorder == nil {
+		return
+	}
+	r.Recorder.Eventf(secret, nil, eventType, reason, action, "%s", note)
+}
@@ -7,22 +7,40 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"fmt"
 	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
 	"time"
 
+	"github.com/go-logr/logr"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	ctrlconfig "sigs.k8s.io/controller-runtime/pkg/config"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllertest"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
 var _ = Describe("ManagerFactory Unit", func() {
@@ -43,7 +61,7 @@ var _ = Describe("ManagerFactory Unit", func() {
 
 		origNewCAFunc := newCAFunc
 		defer func() { newCAFunc = origNewCAFunc }()
-		newCAFunc = func() (*CAHelper, error) {
+		newCAFunc = func(keyAlgorithm string) (*CAHelper, error) {
 			return &CAHelper{}, nil
 		}
 
@@ -83,7 +101,7 @@ var _ = Describe("ManagerFactory Unit", func() {
 
 		origNewCAFunc := newCAFunc
 		defer func() { newCAFunc = origNewCAFunc }()
-		newCAFunc = func() (*CAHelper, error) {
+		newCAFunc = func(keyAlgorithm string) (*CAHelper, error) {
 			return &CAHelper{}, nil
 		}
 
@@ -116,7 +134,7 @@ var _ = Describe("ManagerFactory Unit", func() {
 
 		origNewCAFunc := newCAFunc
 		defer func() { newCAFunc = origNewCAFunc }()
-		newCAFunc = func() (*CAHelper, error) {
+		newCAFunc = func(keyAlgorithm string) (*CAHelper, error) {
 			return &CAHelper{}, nil
 		}
 
@@ -153,7 +171,9 @@ var _ = Describe("ManagerFactory Unit", func() {
 		fakeManager := &mockManager{
 			apiReader: fakeClient,
 		}
+		var gotOptions ctrl.Options
 		newManagerFunc = func(restConfig *rest.Config, options ctrl.Options) (ctrl.Manager, error) {
+			gotOptions = options
 			return fakeManager, nil
 		}
 
@@ -177,11 +197,21 @@ var _ = Describe("ManagerFactory Unit", func() {
 			SignerName:        "test-signer",
 			CACertKey:         "ca.crt",
 			CAKeyKey:          "ca.key",
+			CARotationGrace:   24 * time.Hour,
 		}
 
 		_, err := CreateManager(&rest.Config{}, testConfig)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(secretWatcherCalled).To(BeTrue())
+
+		// Only the CA Secret is cached, in its own namespace
+		Expect(gotOptions.Cache.ByObject).To(HaveLen(1))
+		for obj, byObject := range gotOptions.Cache.ByObject {
+			Expect(obj).To(BeAssignableToTypeOf(&corev1.Secret{}))
+			Expect(byObject.Namespaces).To(HaveKey("test-ns"))
+			Expect(byObject.Namespaces).To(HaveLen(1))
+			Expect(byObject.Field.String()).To(Equal("metadata.name=test-secret"))
+		}
 	})
 
 	It("TestCreateManager_NoSecretWatcher", func() {
@@ -207,7 +237,7 @@ var _ = Describe("ManagerFactory Unit", func() {
 
 		origNewCAFunc := newCAFunc
 		defer func() { newCAFunc = origNewCAFunc }()
-		newCAFunc = func() (*CAHelper, error) {
+		newCAFunc = func(keyAlgorithm string) (*CAHelper, error) {
 			return &CAHelper{}, nil
 		}
 
@@ -233,7 +263,7 @@ var _ = Describe("ManagerFactory Unit", func() {
 
 		origNewCAFunc := newCAFunc
 		defer func() { newCAFunc = origNewCAFunc }()
-		newCAFunc = func() (*CAHelper, error) {
+		newCAFunc = func(keyAlgorithm string) (*CAHelper, error) {
 			return &CAHelper{}, nil
 		}
 
@@ -262,7 +292,7 @@ var _ = Describe("ManagerFactory Unit", func() {
 
 		origNewCAFunc := newCAFunc
 		defer func() { newCAFunc = origNewCAFunc }()
-		newCAFunc = func() (*CAHelper, error) {
+		newCAFunc = func(keyAlgorithm string) (*CAHelper, error) {
 			return &CAHelper{}, nil
 		}
 
@@ -294,7 +324,7 @@ var _ = Describe("ManagerFactory Unit", func() {
 
 		origNewCAFunc := newCAFunc
 		defer func() { newCAFunc = origNewCAFunc }()
-		newCAFunc = func() (*CAHelper, error) {
+		newCAFunc = func(keyAlgorithm string) (*CAHelper, error) {
 			return &CAHelper{}, nil
 		}
 
@@ -316,13 +346,947 @@ var _ = Describe("ManagerFactory Unit", func() {
 		Expect(err).NotTo(HaveOccurred())
 		Expect(capturedOptions.RateLimiter).NotTo(BeNil())
 	})
+
+	It("TestCreateManager_SetupInformers", func() {
+		origNewManagerFunc := newManagerFunc
+		defer func() { newManagerFunc = origNewManagerFunc }()
+		newManagerFunc = func(restConfig *rest.Config, options ctrl.Options) (ctrl.Manager, error) {
+			return &mockManager{}, nil
+		}
+
+		origNewCAFunc := newCAFunc
+		defer func() { newCAFunc = origNewCAFunc }()
+		newCAFunc = func(keyAlgorithm string) (*CAHelper, error) {
+			return &CAHelper{}, nil
+		}
+
+		origSetupFunc := setupWithManagerFunc
+		defer func() { setupWithManagerFunc = origSetupFunc }()
+		setupWithManagerFunc = func(r *SignerReconciler, mgr ctrl.Manager, opts controller.Options) error {
+			return nil
+		}
+
+		origSetupInformersFunc := setupInformersFunc
+		defer func() { setupInformersFunc = origSetupInformersFunc }()
+		var informers []client.Object
+		informersCalled := 0
+		setupInformersFunc = func(mgr ctrl.Manager, objs []client.Object) error {
+			informersCalled++
+			informers = objs
+			return nil
+		}
+
+		origSetupSignerPolicyFunc := setupSignerPolicyFunc
+		defer func() { setupSignerPolicyFunc = origSetupSignerPolicyFunc }()
+		policyValidationCalled := false
+		setupSignerPolicyFunc = func(mgr ctrl.Manager) error {
+			policyValidationCalled = true
+			return nil
+		}
+
+		_, err := CreateManager(&rest.Config{}, &Config{SignerName: "test-signer"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(informersCalled).To(Equal(0))
+		Expect(policyValidationCalled).To(BeFalse())
+
+		_, err = CreateManager(&rest.Config{}, &Config{SignerName: "test-signer", ServiceSANs: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(informersCalled).To(Equal(1))
+		Expect(informers).To(ConsistOf(BeAssignableToTypeOf(&corev1.Pod{}), BeAssignableToTypeOf(&corev1.Service{})))
+
+		_, err = CreateManager(&rest.Config{}, &Config{SignerName: "test-signer", SignerPolicies: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(informersCalled).To(Equal(2))
+		Expect(informers).To(ConsistOf(BeAssignableToTypeOf(&SignerPolicy{}), BeAssignableToTypeOf(&corev1.Namespace{})))
+		Expect(policyValidationCalled).To(BeTrue())
+	})
+
+	It("TestCreateManager_AddsRequestMetricsRunnable", func() {
+		mgr := &mockManager{}
+		origNewManagerFunc := newManagerFunc
+		defer func() { newManagerFunc = origNewManagerFunc }()
+		newManagerFunc = func(restConfig *rest.Config, options ctrl.Options) (ctrl.Manager, error) {
+			return mgr, nil
+		}
+
+		origNewCAFunc := newCAFunc
+		defer func() { newCAFunc = origNewCAFunc }()
+		newCAFunc = func(keyAlgorithm string) (*CAHelper, error) {
+			return &CAHelper{}, nil
+		}
+
+		origSetupFunc := setupWithManagerFunc
+		defer func() { setupWithManagerFunc = origSetupFunc }()
+		var reconciler *SignerReconciler
+		setupWithManagerFunc = func(r *SignerReconciler, mgr ctrl.Manager, opts controller.Options) error {
+			reconciler = r
+			return nil
+		}
+
+		_, err := CreateManager(&rest.Config{}, &Config{SignerName: "test-signer"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mgr.runnables).To(HaveLen(2))
+		runnable, ok := mgr.runnables[0].(*RequestMetricsRunnable)
+		Expect(ok).To(BeTrue())
+		Expect(runnable.SignerName).To(Equal("test-signer"))
+		Expect(runnable.NeedLeaderElection()).To(BeTrue())
+		// Every reconcile refreshes the gauges
+		Expect(reconciler.RequestMetrics).To(BeIdenticalTo(runnable))
+	})
+
+	It("TestCreateManager_GeneratesMissingCASecret", func() {
+		mgr := &mockManager{}
+		origNewManagerFunc := newManagerFunc
+		defer func() { newManagerFunc = origNewManagerFunc }()
+		newManagerFunc = func(restConfig *rest.Config, options ctrl.Options) (ctrl.Manager, error) {
+			return mgr, nil
+		}
+
+		origSetupFunc := setupWithManagerFunc
+		defer func() { setupWithManagerFunc = origSetupFunc }()
+		var signerCA *CAHelper
+		setupWithManagerFunc = func(r *SignerReconciler, mgr ctrl.Manager, opts controller.Options) error {
+			signerCA = r.CA
+			return nil
+		}
+
+		origSetupSecretFunc := setupSecretWatcherFunc
+		defer func() { setupSecretWatcherFunc = origSetupSecretFunc }()
+		var watchedCA *CAHelper
+		setupSecretWatcherFunc = func(mgr ctrl.Manager, ca *CAHelper, config *Config) error {
+			watchedCA = ca
+			return nil
+		}
+
+		testConfig := &Config{
+			CASecretName:      "missing",
+			CASecretNamespace: "test-ns",
+			SignerName:        "test-signer",
+			CACertKey:         "ca.crt",
+			CAKeyKey:          "ca.key",
+			CARotationGrace:   24 * time.Hour,
+		}
+
+		_, err := CreateManager(&rest.Config{}, testConfig)
+		Expect(err).To(MatchError(ContainSubstring("failed to load CA from secret")))
+
+		testConfig.CASecretCreate = true
+		_, err = CreateManager(&rest.Config{}, testConfig)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signerCA).NotTo(BeNil())
+		Expect(watchedCA).To(BeIdenticalTo(signerCA))
+		Expect(mgr.runnables).To(ContainElement(BeAssignableToTypeOf(&CASecretGenerator{})))
+	})
+
+	It("TestCreateManager_PassesCAKeyAlgorithm", func() {
+		origNewManagerFunc := newManagerFunc
+		defer func() { newManagerFunc = origNewManagerFunc }()
+		newManagerFunc = func(restConfig *rest.Config, options ctrl.Options) (ctrl.Manager, error) {
+			return &mockManager{}, nil
+		}
+
+		origNewCAFunc := newCAFunc
+		defer func() { newCAFunc = origNewCAFunc }()
+		var capturedAlgorithm string
+		newCAFunc = func(keyAlgorithm string) (*CAHelper, error) {
+			capturedAlgorithm = keyAlgorithm
+			return &CAHelper{}, nil
+		}
+
+		origSetupFunc := setupWithManagerFunc
+		defer func() { setupWithManagerFunc = origSetupFunc }()
+		setupWithManagerFunc = func(r *SignerReconciler, mgr ctrl.Manager, opts controller.Options) error {
+			return nil
+		}
+
+		_, err := CreateManager(&rest.Config{}, &Config{SignerName: "test-signer", CAKeyAlgorithm: CAKeyAlgorithmEd25519})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(capturedAlgorithm).To(Equal(CAKeyAlgorithmEd25519))
+	})
+
+	It("TestCreateManager_LoadsPKCS11CA", func() {
+		origNewManagerFunc := newManagerFunc
+		defer func() { newManagerFunc = origNewManagerFunc }()
+		newManagerFunc = func(restConfig *rest.Config, options ctrl.Options) (ctrl.Manager, error) {
+			return &mockManager{}, nil
+		}
+
+		origNewPKCS11CAFunc := newPKCS11CAFunc
+		defer func() { newPKCS11CAFunc = origNewPKCS11CAFunc }()
+		var capturedLabel string
+		newPKCS11CAFunc = func(config *Config) (*CAHelper, error) {
+			capturedLabel = config.PKCS11KeyLabel
+			return &CAHelper{}, nil
+		}
+
+		origWatcherFunc := setupSecretWatcherFunc
+		defer func() { setupSecretWatcherFunc = origWatcherFunc }()
+		watcherCalled := false
+		setupSecretWatcherFunc = func(mgr ctrl.Manager, ca *CAHelper, config *Config) error {
+			watcherCalled = true
+			return nil
+		}
+
+		origSetupFunc := setupWithManagerFunc
+		defer func() { setupWithManagerFunc = origSetupFunc }()
+		setupWithManagerFunc = func(r *SignerReconciler, mgr ctrl.Manager, opts controller.Options) error {
+			return nil
+		}
+
+		_, err := CreateManager(&rest.Config{}, &Config{
+			SignerName:     "test-signer",
+			SigningBackend: SigningBackendPKCS11,
+			PKCS11KeyLabel: "hsm-ca",
+			CASecretName:   "ignored-ca",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(capturedLabel).To(Equal("hsm-ca"))
+		Expect(watcherCalled).To(BeFalse())
+	})
+
+	It("TestCreateManager_LoadsCAFromFiles", func() {
+		mgr := &mockManager{}
+		origNewManagerFunc := newManagerFunc
+		defer func() { newManagerFunc = origNewManagerFunc }()
+		newManagerFunc = func(restConfig *rest.Config, options ctrl.Options) (ctrl.Manager, error) {
+			return mgr, nil
+		}
+
+		origWatcherFunc := setupSecretWatcherFunc
+		defer func() { setupSecretWatcherFunc = origWatcherFunc }()
+		watcherCalled := false
+		setupSecretWatcherFunc = func(mgr ctrl.Manager, ca *CAHelper, config *Config) error {
+			watcherCalled = true
+			return nil
+		}
+
+		origSetupFunc := setupWithManagerFunc
+		defer func() { setupWithManagerFunc = origSetupFunc }()
+		setupWithManagerFunc = func(r *SignerReconciler, mgr ctrl.Manager, opts controller.Options) error {
+			return nil
+		}
+
+		dir := GinkgoT().TempDir()
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		certPEM, keyPEM, err := (&CAHelper{Cert: newTestCACert("File CA", key, nil, nil), Key: key}).EncodePEM()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(filepath.Join(dir, "ca.crt"), certPEM, 0o600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "ca.key"), keyPEM, 0o600)).To(Succeed())
+
+		config := &Config{
+			SignerName:   "test-signer",
+			CACertFile:   filepath.Join(dir, "ca.crt"),
+			CAKeyFile:    filepath.Join(dir, "ca.key"),
+			CASecretName: "ignored-ca",
+		}
+		_, err = CreateManager(&rest.Config{}, config)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(watcherCalled).To(BeFalse())
+		Expect(mgr.runnables).To(ContainElement(BeAssignableToTypeOf(&CAFileWatcher{})))
+		Expect(mgr.readyzChecks["ca"](nil)).To(Succeed())
+
+		config.CAAutoRenew = CARenewSameKey
+		_, err = CreateManager(&rest.Config{}, config)
+		Expect(err).To(MatchError(ContainSubstring("not supported with CA files")))
+		_, err = CreateManager(&rest.Config{}, &Config{SignerName: "test-signer", CACertFile: config.CACertFile})
+		Expect(err).To(MatchError(ContainSubstring("must be set together")))
+	})
+
+	It("TestCreateManager_LoadsKMSCA", func() {
+		origNewManagerFunc := newManagerFunc
+		defer func() { newManagerFunc = origNewManagerFunc }()
+		newManagerFunc = func(restConfig *rest.Config, options ctrl.Options) (ctrl.Manager, error) {
+			return &mockManager{}, nil
+		}
+
+		origNewKMSCAFunc := newKMSCAFunc
+		defer func() { newKMSCAFunc = origNewKMSCAFunc }()
+		kmsCA := &CAHelper{}
+		var capturedKeyID string
+		newKMSCAFunc = func(config *Config) (*CAHelper, error) {
+			capturedKeyID = config.KMSKeyID
+			return kmsCA, nil
+		}
+
+		origWatcherFunc := setupSecretWatcherFunc
+		defer func() { setupSecretWatcherFunc = origWatcherFunc }()
+		watcherCalled := false
+		setupSecretWatcherFunc = func(mgr ctrl.Manager, ca *CAHelper, config *Config) error {
+			watcherCalled = true
+			return nil
+		}
+
+		origSetupFunc := setupWithManagerFunc
+		defer func() { setupWithManagerFunc = origSetupFunc }()
+		var reconciler *SignerReconciler
+		setupWithManagerFunc = func(r *SignerReconciler, mgr ctrl.Manager, opts controller.Options) error {
+			reconciler = r
+			return nil
+		}
+
+		config := &Config{
+			SignerName:     "test-signer",
+			SigningBackend: SigningBackendKMS,
+			KMSKeyID:       "arn:aws:kms:eu-west-1:111122223333:key/ca",
+			CASecretName:   "ignored-ca",
+		}
+		_, err := CreateManager(&rest.Config{}, config)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(capturedKeyID).To(Equal(config.KMSKeyID))
+		Expect(watcherCalled).To(BeFalse())
+		Expect(reconciler.Backend).To(Equal(&LocalSigningBackend{CA: kmsCA}))
+
+		config.CAAutoRenew = CARenewNewKey
+		_, err = CreateManager(&rest.Config{}, config)
+		Expect(err).To(MatchError(ContainSubstring("kms signing backend")))
+	})
+
+	It("TestCreateManager_VaultBackend", func() {
+		mgr := &mockManager{}
+		origNewManagerFunc := newManagerFunc
+		defer func() { newManagerFunc = origNewManagerFunc }()
+		newManagerFunc = func(restConfig *rest.Config, options ctrl.Options) (ctrl.Manager, error) {
+			return mgr, nil
+		}
+
+		origSetupFunc := setupWithManagerFunc
+		defer func() { setupWithManagerFunc = origSetupFunc }()
+		var reconciler *SignerReconciler
+		setupWithManagerFunc = func(r *SignerReconciler, mgr ctrl.Manager, opts controller.Options) error {
+			reconciler = r
+			return nil
+		}
+
+		_, err := CreateManager(&rest.Config{}, &Config{
+			SignerName:      "test-signer",
+			SigningBackend:  SigningBackendVault,
+			VaultAddress:    "https://vault.example:8200",
+			VaultAuthMethod: VaultAuthToken,
+			VaultToken:      "token",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reconciler.Backend).To(BeAssignableToTypeOf(&VaultSigningBackend{}))
+		Expect(mgr.runnables).To(ContainElement(reconciler.Backend))
+
+		// Not ready until the issuing CA was fetched from Vault
+		Expect(mgr.readyzChecks["ca"](nil)).To(MatchError(ContainSubstring("issuing CA not loaded")))
+	})
+
+	It("TestCreateManager_RejectsVaultWithoutAddress", func() {
+		_, err := CreateManager(&rest.Config{}, &Config{
+			SignerName:      "test-signer",
+			SigningBackend:  SigningBackendVault,
+			VaultAuthMethod: VaultAuthToken,
+		})
+		Expect(err).To(MatchError(ContainSubstring("VAULT_ADDR")))
+	})
+
+	It("TestCreateManager_RemoteSignerBackend", func() {
+		mgr := &mockManager{}
+		origNewManagerFunc := newManagerFunc
+		defer func() { newManagerFunc = origNewManagerFunc }()
+		newManagerFunc = func(restConfig *rest.Config, options ctrl.Options) (ctrl.Manager, error) {
+			return mgr, nil
+		}
+
+		origSetupFunc := setupWithManagerFunc
+		defer func() { setupWithManagerFunc = origSetupFunc }()
+		var reconciler *SignerReconciler
+		setupWithManagerFunc = func(r *SignerReconciler, mgr ctrl.Manager, opts controller.Options) error {
+			reconciler = r
+			return nil
+		}
+
+		config := newTestSigningPKI(GinkgoT().TempDir()).clientConfig("127.0.0.1:1")
+		config.SignerName = "test-signer"
+		_, err := CreateManager(&rest.Config{}, config)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reconciler.Backend).To(BeAssignableToTypeOf(&RemoteSigningBackend{}))
+		Expect(mgr.runnables).To(ContainElement(reconciler.Backend))
+
+		// Not ready until the CA was fetched and the remote signer answers
+		Expect(mgr.readyzChecks["ca"](nil)).To(MatchError(ContainSubstring("issuing CA not loaded")))
+		Expect(mgr.readyzChecks).To(HaveKey("remote-signer"))
+
+		config.CAAutoRenew = CARenewSameKey
+		_, err = CreateManager(&rest.Config{}, config)
+		Expect(err).To(MatchError(ContainSubstring("not supported with the grpc signing backend")))
+	})
+
+	It("TestCreateManager_RejectsPKCS11NewKeyRenewal", func() {
+		_, err := CreateManager(&rest.Config{}, &Config{
+			SignerName:     "test-signer",
+			SigningBackend: SigningBackendPKCS11,
+			CAAutoRenew:    CARenewNewKey,
+		})
+		Expect(err).To(MatchError(ContainSubstring("pkcs11 signing backend")))
+	})
+
+	It("TestCreateManager_SetupTrustBundle", func() {
+		origNewManagerFunc := newManagerFunc
+		defer func() { newManagerFunc = origNewManagerFunc }()
+		newManagerFunc = func(restConfig *rest.Config, options ctrl.Options) (ctrl.Manager, error) {
+			return &mockManager{}, nil
+		}
+
+		origNewCAFunc := newCAFunc
+		defer func() { newCAFunc = origNewCAFunc }()
+		newCAFunc = func(keyAlgorithm string) (*CAHelper, error) {
+			return &CAHelper{}, nil
+		}
+
+		origSetupFunc := setupWithManagerFunc
+		defer func() { setupWithManagerFunc = origSetupFunc }()
+		setupWithManagerFunc = func(r *SignerReconciler, mgr ctrl.Manager, opts controller.Options) error {
+			return nil
+		}
+
+		origSetupTrustBundleFunc := setupTrustBundleFunc
+		defer func() { setupTrustBundleFunc = origSetupTrustBundleFunc }()
+		trustBundleCalled := 0
+		setupTrustBundleFunc = func(mgr ctrl.Manager, ca *CAHelper, config *Config) error {
+			trustBundleCalled++
+			return nil
+		}
+
+		_, err := CreateManager(&rest.Config{}, &Config{SignerName: "test-signer"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(trustBundleCalled).To(Equal(0))
+
+		_, err = CreateManager(&rest.Config{}, &Config{SignerName: "test-signer", ClusterTrustBundle: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(trustBundleCalled).To(Equal(1))
+	})
+
+	It("TestCreateManager_SetupTrustDistribution", func() {
+		var gotOptions ctrl.Options
+		origNewManagerFunc := newManagerFunc
+		defer func() { newManagerFunc = origNewManagerFunc }()
+		newManagerFunc = func(restConfig *rest.Config, options ctrl.Options) (ctrl.Manager, error) {
+			gotOptions = options
+			return &mockManager{}, nil
+		}
+
+		origNewCAFunc := newCAFunc
+		defer func() { newCAFunc = origNewCAFunc }()
+		newCAFunc = func(keyAlgorithm string) (*CAHelper, error) {
+			return &CAHelper{}, nil
+		}
+
+		origSetupFunc := setupWithManagerFunc
+		defer func() { setupWithManagerFunc = origSetupFunc }()
+		setupWithManagerFunc = func(r *SignerReconciler, mgr ctrl.Manager, opts controller.Options) error {
+			return nil
+		}
+
+		origSetupTrustDistributionFunc := setupTrustDistributionFunc
+		defer func() { setupTrustDistributionFunc = origSetupTrustDistributionFunc }()
+		var got *TrustDistributionReconciler
+		setupTrustDistributionFunc = func(mgr ctrl.Manager, r *TrustDistributionReconciler) error {
+			got = r
+			return nil
+		}
+
+		_, err := CreateManager(&rest.Config{}, &Config{SignerName: "test-signer"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got).To(BeNil())
+		Expect(gotOptions.Cache.ByObject).To(BeEmpty())
+
+		_, err = CreateManager(&rest.Config{}, &Config{
+			SignerName:             "test-signer",
+			TrustConfigMapName:     "signer-ca",
+			TrustConfigMapKey:      "ca.crt",
+			TrustNamespaceSelector: "mesh=enabled",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got).NotTo(BeNil())
+		Expect(got.ConfigMapName).To(Equal("signer-ca"))
+		Expect(got.NamespaceSelector.String()).To(Equal("mesh=enabled"))
+		Expect(gotOptions.Cache.ByObject).To(HaveLen(1))
+
+		_, err = CreateManager(&rest.Config{}, &Config{
+			SignerName:             "test-signer",
+			TrustConfigMapName:     "signer-ca",
+			TrustNamespaceSelector: "mesh in (",
+		})
+		Expect(err).To(MatchError(ContainSubstring("invalid trust namespace selector")))
+
+		_, err = CreateManager(&rest.Config{}, &Config{
+			SignerName:         "test-signer",
+			TrustConfigMapName: "signer-ca",
+			TrustExtraRoots:    "not a certificate",
+		})
+		Expect(err).To(MatchError(ContainSubstring("invalid trust extra roots")))
+	})
+
+	It("TestCreateManager_RejectsInvalidAdmissionRules", func() {
+		_, err := CreateManager(&rest.Config{}, &Config{
+			SignerName:     "test-signer",
+			AdmissionRules: `[{"expression": "request.podName =="}]`,
+		})
+		Expect(err).To(MatchError(ContainSubstring("failed to compile admission rule")))
+	})
+
+	It("TestCreateManager_CAReadyzCheck", func() {
+		mgr := &mockManager{}
+		origNewManagerFunc := newManagerFunc
+		defer func() { newManagerFunc = origNewManagerFunc }()
+		newManagerFunc = func(restConfig *rest.Config, options ctrl.Options) (ctrl.Manager, error) {
+			return mgr, nil
+		}
+
+		ca, err := NewCA()
+		Expect(err).NotTo(HaveOccurred())
+		origNewCAFunc := newCAFunc
+		defer func() { newCAFunc = origNewCAFunc }()
+		newCAFunc = func(keyAlgorithm string) (*CAHelper, error) {
+			return ca, nil
+		}
+
+		origSetupFunc := setupWithManagerFunc
+		defer func() { setupWithManagerFunc = origSetupFunc }()
+		setupWithManagerFunc = func(r *SignerReconciler, mgr ctrl.Manager, opts controller.Options) error {
+			return nil
+		}
+
+		_, err = CreateManager(&rest.Config{}, &Config{SignerName: "test-signer"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mgr.readyzChecks).To(HaveKey("ca"))
+		Expect(mgr.readyzChecks).To(HaveKey("informers"))
+		Expect(mgr.readyzChecks["informers"](httptest.NewRequest(http.MethodGet, "/readyz", nil))).To(Succeed())
+		Expect(mgr.extraHandlers).NotTo(HaveKey(leaderStatusPath))
+		Expect(mgr.readyzChecks["ca"](nil)).To(Succeed())
+
+		ca.setLoadError(fmt.Errorf("secret deleted"))
+		Expect(mgr.readyzChecks["ca"](nil)).To(MatchError(ContainSubstring("secret deleted")))
+	})
+
+	It("TestCreateManager_CAReadyzCheckOnFollower", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		informers := newSecretInformers()
+		informer := informers.secrets
+		mgr := &mockManager{client: c, apiReader: c, cache: informers}
+		origNewManagerFunc := newManagerFunc
+		defer func() { newManagerFunc = origNewManagerFunc }()
+		newManagerFunc = func(restConfig *rest.Config, options ctrl.Options) (ctrl.Manager, error) {
+			return mgr, nil
+		}
+
+		origSetupFunc := setupWithManagerFunc
+		defer func() { setupWithManagerFunc = origSetupFunc }()
+		setupWithManagerFunc = func(r *SignerReconciler, mgr ctrl.Manager, opts controller.Options) error {
+			return nil
+		}
+
+		_, err := CreateManager(&rest.Config{}, &Config{
+			SignerName:        "test-signer",
+			LeaderElection:    true,
+			CASecretName:      "ca",
+			CASecretNamespace: "signer",
+			CASecretCreate:    true,
+			CACertKey:         "ca.crt",
+			CAKeyKey:          "ca.key",
+			CARotationGrace:   24 * time.Hour,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mgr.readyzChecks["ca"](nil)).To(MatchError(ContainSubstring("CA not loaded")))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		startFollower(ctx, mgr)
+
+		// Another replica is the leader and generates the Secret
+		secret := createFakeSecret("ca", "signer")
+		Expect(c.Create(ctx, secret)).To(Succeed())
+		Eventually(func() error {
+			informer.Add(secret)
+			return mgr.readyzChecks["ca"](nil)
+		}).Should(Succeed())
+	})
+
+	It("TestCreateManager_LeaderStatusEndpoint", func() {
+		mgr := &mockManager{}
+		origNewManagerFunc := newManagerFunc
+		defer func() { newManagerFunc = origNewManagerFunc }()
+		newManagerFunc = func(restConfig *rest.Config, options ctrl.Options) (ctrl.Manager, error) {
+			return mgr, nil
+		}
+
+		origSetupFunc := setupWithManagerFunc
+		defer func() { setupWithManagerFunc = origSetupFunc }()
+		setupWithManagerFunc = func(r *SignerReconciler, mgr ctrl.Manager, opts controller.Options) error {
+			return nil
+		}
+
+		_, err := CreateManager(&rest.Config{}, &Config{SignerName: "test-signer", LeaderElection: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mgr.extraHandlers).To(HaveKey(leaderStatusPath))
+
+		rec := httptest.NewRecorder()
+		mgr.extraHandlers[leaderStatusPath].ServeHTTP(rec, httptest.NewRequest(http.MethodGet, leaderStatusPath, nil))
+		Expect(rec.Body.String()).To(ContainSubstring(`"leader":false`))
+
+		close(mgr.elected)
+		rec = httptest.NewRecorder()
+		mgr.extraHandlers[leaderStatusPath].ServeHTTP(rec, httptest.NewRequest(http.MethodGet, leaderStatusPath, nil))
+		Expect(rec.Body.String()).To(ContainSubstring(`"leader":true`))
+	})
+
+	It("TestCreateManager_RejectsCASecretWithoutNamespace", func() {
+		_, err := CreateManager(&rest.Config{}, &Config{
+			SignerName:   "test-signer",
+			CASecretName: "ca",
+		})
+		Expect(err).To(MatchError(ContainSubstring("CA_SECRET_NAMESPACE (or POD_NAMESPACE) must be set")))
+	})
+
+	It("TestCreateManager_RejectsShortCARotationGrace", func() {
+		// Leaves from the old CA must not outlive its trust after a switch
+		_, err := CreateManager(&rest.Config{}, &Config{
+			SignerName:        "test-signer",
+			CASecretName:      "ca",
+			CASecretNamespace: "signer",
+			CertValidity:      48 * time.Hour,
+			CARotationGrace:   24 * time.Hour,
+		})
+		Expect(err).To(MatchError(ContainSubstring("CA rotation grace 24h0m0s is shorter than the certificate validity 48h0m0s")))
+
+		// The grace covers the default validity too
+		_, err = CreateManager(&rest.Config{}, &Config{
+			SignerName:        "test-signer",
+			CASecretName:      "ca",
+			CASecretNamespace: "signer",
+			CARotationGrace:   30 * time.Minute,
+		})
+		Expect(err).To(MatchError(ContainSubstring("shorter than the certificate validity 1h0m0s")))
+	})
+
+	It("TestCreateManager_RejectsUnknownCALoadFailurePolicy", func() {
+		_, err := CreateManager(&rest.Config{}, &Config{
+			SignerName:          "test-signer",
+			CALoadFailurePolicy: "ignore",
+		})
+		Expect(err).To(MatchError(ContainSubstring("unknown CA load failure policy")))
+	})
+
+	It("TestCreateManager_RejectsUnknownCAAutoRenew", func() {
+		_, err := CreateManager(&rest.Config{}, &Config{
+			SignerName:  "test-signer",
+			CAAutoRenew: "sometimes",
+		})
+		Expect(err).To(MatchError(ContainSubstring("unknown CA auto-renew mode")))
+	})
+
+	It("TestCreateManager_RejectsUnknownIssuanceMode", func() {
+		_, err := CreateManager(&rest.Config{}, &Config{
+			SignerName:   "test-signer",
+			IssuanceMode: "bogus",
+		})
+		Expect(err).To(MatchError(ContainSubstring("unknown issuance mode")))
+	})
+
+	It("TestCreateManager_RejectsInvalidSPIFFETrustDomain", func() {
+		_, err := CreateManager(&rest.Config{}, &Config{
+			SignerName:        "test-signer",
+			IssuanceMode:      IssuanceModeSPIFFE,
+			SPIFFETrustDomain: "Example.org",
+		})
+		Expect(err).To(MatchError(ContainSubstring("invalid SPIFFE trust domain")))
+	})
+})
+
+var _ = Describe("SecretReconciler", func() {
+	It("SecretReconciler_RecordsReloadEvents", func() {
+		testScheme := runtime.NewScheme()
+		utilruntime.Must(clientgoscheme.AddToScheme(testScheme))
+		recorder := &captureRecorder{}
+		config := &Config{CASecretName: "ca", CASecretNamespace: "signer", CACertKey: "ca.crt", CAKeyKey: "ca.key"}
+
+		caSecret := createFakeSecret("ca", "signer")
+		caSecret.UID = "ca-uid"
+		c := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(caSecret).Build()
+		elected := make(chan struct{})
+		close(elected)
+		r := &SecretReconciler{
+			Client:    c,
+			APIReader: c,
+			CA:        &CAHelper{},
+			Config:    config,
+			Recorder:  recorder,
+			Elected:   elected,
+		}
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "ca", Namespace: "signer"}}
+
+		_, err := r.Reconcile(context.Background(), req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.events).To(HaveLen(1))
+		Expect(recorder.events[0].eventType).To(Equal(corev1.EventTypeNormal))
+		Expect(recorder.events[0].reason).To(Equal("CAReloaded"))
+		// The event regards the Secret itself, not just its name
+		secret, ok := recorder.events[0].regarding.(*corev1.Secret)
+		Expect(ok).To(BeTrue())
+		Expect(secret.Name).To(Equal("ca"))
+		Expect(secret.UID).To(Equal(types.UID("ca-uid")))
+
+		r.Client = fake.NewClientBuilder().WithScheme(testScheme).Build()
+		_, err = r.Reconcile(context.Background(), req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.events).To(HaveLen(2))
+		Expect(recorder.events[1].eventType).To(Equal(corev1.EventTypeWarning))
+		Expect(recorder.events[1].reason).To(Equal("CASecretMissing"))
+	})
+
+	It("SecretReconciler_FollowerReloadsWithoutEvents", func() {
+		testScheme := runtime.NewScheme()
+		utilruntime.Must(clientgoscheme.AddToScheme(testScheme))
+		recorder := &captureRecorder{}
+		config := &Config{CASecretName: "ca", CASecretNamespace: "signer", CACertKey: "ca.crt", CAKeyKey: "ca.key"}
+		c := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(createFakeSecret("ca", "signer")).Build()
+		r := &SecretReconciler{Client: c, APIReader: c, CA: &CAHelper{}, Config: config, Recorder: recorder, Elected: make(chan struct{})}
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "ca", Namespace: "signer"}}
+
+		// Every replica reloads, but only the leader reports it
+		_, err := r.Reconcile(context.Background(), req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.CA.GetCert()).NotTo(BeNil())
+		r.Client = fake.NewClientBuilder().WithScheme(testScheme).Build()
+		_, err = r.Reconcile(context.Background(), req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.CA.LoadError()).To(HaveOccurred())
+		Expect(recorder.events).To(BeEmpty())
+	})
+
+	It("SecretReconciler_KeepsLastKnownGoodCA", func() {
+		testScheme := runtime.NewScheme()
+		utilruntime.Must(clientgoscheme.AddToScheme(testScheme))
+		recorder := &captureRecorder{}
+		config := &Config{CASecretName: "ca", CASecretNamespace: "signer", CACertKey: "ca.crt", CAKeyKey: "ca.key"}
+		secret := createFakeSecret("ca", "signer")
+		c := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(secret).Build()
+		r := &SecretReconciler{Client: c, APIReader: c, CA: &CAHelper{}, Config: config, Recorder: recorder}
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "ca", Namespace: "signer"}}
+
+		_, err := r.Reconcile(context.Background(), req)
+		Expect(err).NotTo(HaveOccurred())
+		good := r.CA.GetCert()
+		Expect(good).NotTo(BeNil())
+		errorsBefore := getCounterValue(CALoadErrors)
+
+		// An unparsable update keeps the loaded CA but reports the failure
+		Expect(c.Get(context.Background(), req.NamespacedName, secret)).To(Succeed())
+		validCert := secret.Data["ca.crt"]
+		secret.Data["ca.crt"] = []byte("garbage")
+		Expect(c.Update(context.Background(), secret)).To(Succeed())
+		_, err = r.Reconcile(context.Background(), req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.CA.GetCert()).To(BeIdenticalTo(good))
+		Expect(r.CA.LoadError()).To(HaveOccurred())
+		Expect(getCounterValue(CALoadErrors)).To(Equal(errorsBefore + 1))
+		Expect(recorder.events[len(recorder.events)-1].reason).To(Equal("CAReloadFailed"))
+
+		// A valid Secret resumes normal operation
+		secret.Data["ca.crt"] = validCert
+		Expect(c.Update(context.Background(), secret)).To(Succeed())
+		_, err = r.Reconcile(context.Background(), req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.CA.LoadError()).NotTo(HaveOccurred())
+	})
+
+	It("SecretReconciler_SecretDeleted", func() {
+		testScheme := runtime.NewScheme()
+		utilruntime.Must(clientgoscheme.AddToScheme(testScheme))
+		c := fake.NewClientBuilder().WithScheme(testScheme).Build()
+		ca, err := NewCA()
+		Expect(err).NotTo(HaveOccurred())
+		r := &SecretReconciler{Client: c, APIReader: c, CA: ca, Config: &Config{CASecretName: "ca", CASecretNamespace: "signer", CACertKey: "ca.crt", CAKeyKey: "ca.key"}}
+
+		_, err = r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "ca", Namespace: "signer"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(apierrors.IsNotFound(r.CA.LoadError())).To(BeTrue())
+		Expect(r.CA.GetCert()).NotTo(BeNil())
+	})
+
+	It("SecretWatcher_ReloadsWithoutLeadership", func() {
+		secret := createFakeSecret("ca", "signer")
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		informers := newSecretInformers()
+		informer := informers.secrets
+		mgr := &mockManager{client: c, apiReader: c, cache: informers}
+		ca := &CAHelper{}
+		config := &Config{CASecretName: "ca", CASecretNamespace: "signer", CACertKey: "ca.crt", CAKeyKey: "ca.key"}
+
+		Expect(setupSecretWatcherFunc(mgr, ca, config)).To(Succeed())
+		// The reload runs on every replica, rotation only on the leader
+		var needLeader []bool
+		for _, r := range mgr.runnables {
+			needLeader = append(needLeader, r.(manager.LeaderElectionRunnable).NeedLeaderElection())
+		}
+		Expect(needLeader).To(ConsistOf(false, true))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		startFollower(ctx, mgr)
+
+		// The leader creates the Secret; the follower picks it up
+		Expect(c.Create(ctx, secret)).To(Succeed())
+		Eventually(func() bool {
+			informer.Add(secret)
+			return ca.GetCert() != nil
+		}).Should(BeTrue())
+		Expect(ca.LoadError()).NotTo(HaveOccurred())
+	})
+
+	It("SecretWatcher_FollowerReportsBrokenSecret", func() {
+		secret := createFakeSecret("ca", "signer")
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		ca, err := NewCAFromSecret(context.Background(), c, "ca", "signer", "ca.crt", "ca.key")
+		Expect(err).NotTo(HaveOccurred())
+		informers := newSecretInformers()
+		informer := informers.secrets
+		mgr := &mockManager{client: c, apiReader: c, cache: informers}
+		config := &Config{CASecretName: "ca", CASecretNamespace: "signer", CACertKey: "ca.crt", CAKeyKey: "ca.key", CALoadFailurePolicy: CALoadFailurePause}
+		Expect(setupSecretWatcherFunc(mgr, ca, config)).To(Succeed())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		startFollower(ctx, mgr)
+
+		// An invalid update is reported without leadership
+		broken := secret.DeepCopy()
+		broken.Data["ca.key"] = []byte("garbage")
+		Expect(c.Update(ctx, broken)).To(Succeed())
+		Eventually(func() error {
+			informer.Update(secret, broken)
+			return ca.LoadError()
+		}).Should(HaveOccurred())
+		Expect(ca.GetCert()).NotTo(BeNil())
+
+		// So is a deletion
+		Expect(c.Delete(ctx, broken)).To(Succeed())
+		Eventually(func() bool {
+			informer.Delete(broken)
+			return apierrors.IsNotFound(ca.LoadError())
+		}).Should(BeTrue())
+		Expect(ca.GetCert()).NotTo(BeNil())
+	})
 })
 
+// startFollower runs the manager's runnables the way a replica that is not the
+// leader does: only those that do not need leader election
+func startFollower(ctx context.Context, mgr *mockManager) {
+	for _, r := range mgr.runnables {
+		if le, ok := r.(manager.LeaderElectionRunnable); ok && le.NeedLeaderElection() {
+			continue
+		}
+		go func() {
+			defer GinkgoRecover()
+			Expect(r.Start(ctx)).To(Succeed())
+		}()
+	}
+}
+
+// secretInformers serves one Secret informer that running controllers can
+// register with while the test injects events, which FakeInformer does not lock
+type secretInformers struct {
+	*informertest.FakeInformers
+	secrets *lockedInformer
+}
+
+func newSecretInformers() *secretInformers {
+	return &secretInformers{
+		FakeInformers: &informertest.FakeInformers{Scheme: scheme},
+		secrets:       &lockedInformer{FakeInformer: &controllertest.FakeInformer{Synced: true}},
+	}
+}
+
+func (c *secretInformers) GetInformer(ctx context.Context, obj client.Object, opts ...cache.InformerGetOption) (cache.Informer, error) {
+	if _, ok := obj.(*corev1.Secret); !ok {
+		return nil, fmt.Errorf("unexpected informer for %T", obj)
+	}
+	return c.secrets, nil
+}
+
+type lockedInformer struct {
+	*controllertest.FakeInformer
+	mu sync.Mutex
+}
+
+func (i *lockedInformer) AddEventHandlerWithOptions(handler toolscache.ResourceEventHandler, opts toolscache.HandlerOptions) (toolscache.ResourceEventHandlerRegistration, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.FakeInformer.AddEventHandlerWithOptions(handler, opts)
+}
+
+func (i *lockedInformer) Add(obj metav1.Object) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.FakeInformer.Add(obj)
+}
+
+func (i *lockedInformer) Update(oldObj, newObj metav1.Object) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.FakeInformer.Update(oldObj, newObj)
+}
+
+func (i *lockedInformer) Delete(obj metav1.Object) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.FakeInformer.Delete(obj)
+}
+
 type mockManager struct {
 	ctrl.Manager
 	addHealthzCheckErr error
 	addReadyzCheckErr  error
 	apiReader          client.Reader
+	client             client.Client
+	cache              cache.Cache
+	runnables          []manager.Runnable
+	readyzChecks       map[string]healthz.Checker
+	extraHandlers      map[string]http.Handler
+	elected            chan struct{}
+}
+
+func (m *mockManager) GetCache() cache.Cache {
+	if m.cache != nil {
+		return m.cache
+	}
+	return &informertest.FakeInformers{Scheme: scheme}
+}
+
+func (m *mockManager) GetScheme() *runtime.Scheme {
+	return scheme
+}
+
+func (m *mockManager) GetLogger() logr.Logger {
+	return ctrl.Log.WithName("test")
+}
+
+func (m *mockManager) GetControllerOptions() ctrlconfig.Controller {
+	// Tests register the same controllers more than once
+	return ctrlconfig.Controller{SkipNameValidation: ptr.To(true)}
+}
+
+func (m *mockManager) Elected() <-chan struct{} {
+	if m.elected == nil {
+		m.elected = make(chan struct{})
+	}
+	return m.elected
+}
+
+func (m *mockManager) AddMetricsServerExtraHandler(path string, handler http.Handler) error {
+	if m.extraHandlers == nil {
+		m.extraHandlers = map[string]http.Handler{}
+	}
+	m.extraHandlers[path] = handler
+	return nil
+}
+
+func (m *mockManager) Add(r manager.Runnable) error {
+	m.runnables = append(m.runnables, r)
+	return nil
 }
 
 func (m *mockManager) AddHealthzCheck(name string, check healthz.Checker) error {
@@ -330,11 +1294,19 @@ func (m *mockManager) AddHealthzCheck(name string, check healthz.Checker) error
 }
 
 func (m *mockManager) AddReadyzCheck(name string, check healthz.Checker) error {
+	if m.readyzChecks == nil {
+		m.readyzChecks = map[string]healthz.Checker{}
+	}
+	m.readyzChecks[name] = check
 	return m.addReadyzCheckErr
 }
 
+func (m *mockManager) GetEventRecorder(name string) events.EventRecorder {
+	return &events.FakeRecorder{}
+}
+
 func (m *mockManager) GetClient() client.Client {
-	return nil
+	return m.client
 }
 
 func (m *mockManager) GetAPIReader() client.Reader {
@@ -355,12 +1327,13 @@ func createFakeSecret(name, namespace string) *corev1.Secret {
 	// Generate valid cert/key
 	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
 	template := x509.Certificate{
-		SerialNumber: big.NewInt(1),
-		Subject:      pkix.Name{CommonName: "Test CA"},
-		NotBefore:    time.Now(),
-		NotAfter:     time.Now().Add(time.Hour),
-		IsCA:         true,
-		KeyUsage:     x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
 	}
 	derBytes, _ := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
 	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derBytes})
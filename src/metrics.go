@@ -24,7 +24,7 @@ var (
 		[]string{"reason"},
 	)
 
-	// ActiveCertificatesGauge tracks unsigned PodCertificateRequests
+	// ActiveCertificatesGauge tracks pending (unsigned, not denied or failed) PodCertificateRequests
 	ActiveCertificatesGauge = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "signer_certificates_active",
@@ -32,6 +32,47 @@ var (
 		},
 	)
 
+	// IssuedUnexpiredGauge tracks issued certificates that have not yet expired
+	IssuedUnexpiredGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "signer_certificates_issued_unexpired",
+			Help: "The number of issued certificates that have not yet expired",
+		},
+	)
+
+	// OldestPendingRequestAge tracks how long the oldest pending request has been waiting
+	OldestPendingRequestAge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "signer_oldest_pending_request_age_seconds",
+			Help: "Age in seconds of the oldest unsigned PodCertificateRequest, 0 when none are pending",
+		},
+	)
+
+	// CALoadErrors counts failed CA reloads (Secret deleted or invalid)
+	CALoadErrors = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "signer_ca_load_errors_total",
+			Help: "The total number of failed CA reloads from the CA Secret",
+		},
+	)
+
+	// CAExpiryTimestamp tracks when the signing CA certificate expires
+	CAExpiryTimestamp = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "signer_ca_expiry_timestamp_seconds",
+			Help: "Unix time at which the signing CA certificate expires",
+		},
+	)
+
+	// CARotationPhaseGauge is 1 for the current CA rotation phase and 0 for the others
+	CARotationPhaseGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "signer_ca_rotation_phase",
+			Help: "Current CA rotation phase (Idle, Staged or Switched), 1 for the active phase",
+		},
+		[]string{"phase"},
+	)
+
 	// ReconciliationDuration tracks reconciliation timing
 	ReconciliationDuration = prometheus.NewHistogram(
 		prometheus.HistogramOpts{
@@ -48,6 +89,11 @@ func init() {
 		IssuedCounter,
 		FailedCounter,
 		ActiveCertificatesGauge,
+		IssuedUnexpiredGauge,
+		OldestPendingRequestAge,
+		CALoadErrors,
+		CAExpiryTimestamp,
+		CARotationPhaseGauge,
 		ReconciliationDuration,
 	)
 }
@@ -0,0 +1,376 @@
+//go:build pkcs11
+
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/pkcs11"
+)
+
+// pkcs11Session is a logged-in session on the token holding the CA key
+type pkcs11Session struct {
+	ctx     *pkcs11.Ctx
+	session pkcs11.SessionHandle
+	// config selects the slot and PIN when the session has to be opened again
+	config *Config
+	// mu serializes operations, PKCS#11 sessions must not be used concurrently
+	mu sync.Mutex
+}
+
+// newPKCS11CA loads the CA from a PKCS#11 token: the certificate object and the
+// private key both labeled config.PKCS11KeyLabel. The private key never leaves
+// the token; CAHelper.Key signs through the session.
+func newPKCS11CA(config *Config) (*CAHelper, error) {
+	s, err := openPKCS11Session(config)
+	if err != nil {
+		return nil, err
+	}
+	return s.loadCA(config.PKCS11KeyLabel)
+}
+
+// openPKCS11Session loads the module, selects the slot by number or token label and logs in
+func openPKCS11Session(config *Config) (*pkcs11Session, error) {
+	ctx := pkcs11.New(config.PKCS11Module)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to load PKCS#11 module %s", config.PKCS11Module)
+	}
+	if err := ctx.Initialize(); err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED)) {
+		return nil, fmt.Errorf("failed to initialize PKCS#11 module: %w", err)
+	}
+
+	s := &pkcs11Session{ctx: ctx, config: config}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// open selects the slot, opens a session on it and logs in. The slot is looked
+// up again every time, a token label may move to another slot after a reset.
+func (s *pkcs11Session) open() error {
+	pin, err := resolvePKCS11PIN(s.config)
+	if err != nil {
+		return err
+	}
+	slot, err := findPKCS11Slot(s.ctx, s.config.PKCS11Slot, s.config.PKCS11TokenLabel)
+	if err != nil {
+		return err
+	}
+	session, err := s.ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	if err != nil {
+		return fmt.Errorf("failed to open PKCS#11 session on slot %d: %w", slot, err)
+	}
+	if err := s.ctx.Login(session, pkcs11.CKU_USER, pin); err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)) {
+		_ = s.ctx.CloseSession(session)
+		return fmt.Errorf("failed to log in to PKCS#11 token: %w", err)
+	}
+	s.session = session
+	return nil
+}
+
+// reopen replaces a session the token dropped, e.g. after a reset
+func (s *pkcs11Session) reopen() error {
+	_ = s.ctx.CloseSession(s.session)
+	return s.open()
+}
+
+// pkcs11SessionLost reports whether err means the session or its login is
+// gone, so that opening a new session and logging in again can succeed
+func pkcs11SessionLost(err error) bool {
+	var rv pkcs11.Error
+	if !errors.As(err, &rv) {
+		return false
+	}
+	switch rv {
+	case pkcs11.CKR_SESSION_HANDLE_INVALID, pkcs11.CKR_SESSION_CLOSED, pkcs11.CKR_USER_NOT_LOGGED_IN,
+		pkcs11.CKR_TOKEN_NOT_PRESENT, pkcs11.CKR_DEVICE_REMOVED, pkcs11.CKR_KEY_HANDLE_INVALID:
+		return true
+	}
+	return false
+}
+
+// resolvePKCS11PIN returns the user PIN, read from PKCS11PINFile when set
+func resolvePKCS11PIN(config *Config) (string, error) {
+	if config.PKCS11PINFile != "" {
+		pin, err := os.ReadFile(config.PKCS11PINFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read PKCS#11 PIN file: %w", err)
+		}
+		return strings.TrimSpace(string(pin)), nil
+	}
+	if config.PKCS11PIN == "" {
+		return "", fmt.Errorf("PKCS#11 PIN not configured, set PKCS11_PIN or PKCS11_PIN_FILE")
+	}
+	return config.PKCS11PIN, nil
+}
+
+// findPKCS11Slot returns slot if it is set (>= 0), otherwise the slot holding the token labeled tokenLabel
+func findPKCS11Slot(ctx *pkcs11.Ctx, slot int, tokenLabel string) (uint, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list PKCS#11 slots: %w", err)
+	}
+	for _, id := range slots {
+		if slot >= 0 {
+			if id == uint(slot) {
+				return id, nil
+			}
+			continue
+		}
+		info, err := ctx.GetTokenInfo(id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read PKCS#11 token info of slot %d: %w", id, err)
+		}
+		if strings.TrimSpace(info.Label) == tokenLabel {
+			return id, nil
+		}
+	}
+	if slot >= 0 {
+		return 0, fmt.Errorf("PKCS#11 slot %d not found or has no token", slot)
+	}
+	return 0, fmt.Errorf("PKCS#11 token %q not found", tokenLabel)
+}
+
+// loadCA reads the certificate and public key labeled label and binds a signer to the private key
+func (s *pkcs11Session) loadCA(label string) (*CAHelper, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	certHandle, err := s.findObject(pkcs11.CKO_CERTIFICATE, label)
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := s.ctx.GetAttributeValue(s.session, certHandle, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_VALUE, nil)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PKCS#11 certificate %q: %w", label, err)
+	}
+	cert, err := x509.ParseCertificate(attrs[0].Value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PKCS#11 certificate %q: %w", label, err)
+	}
+
+	signer, err := s.signer(label)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateCA(cert, signer, time.Now()); err != nil {
+		return nil, fmt.Errorf("invalid CA in PKCS#11 token: %w", err)
+	}
+	return &CAHelper{Cert: cert, Key: signer}, nil
+}
+
+// signer binds a crypto.Signer to the private key labeled label, reading the
+// public half from the matching public key object
+func (s *pkcs11Session) signer(label string) (*pkcs11Signer, error) {
+	privHandle, err := s.findObject(pkcs11.CKO_PRIVATE_KEY, label)
+	if err != nil {
+		return nil, err
+	}
+	pubHandle, err := s.findObject(pkcs11.CKO_PUBLIC_KEY, label)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := s.publicKey(pubHandle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PKCS#11 public key %q: %w", label, err)
+	}
+	return &pkcs11Signer{session: s, label: label, key: privHandle, pub: pub}, nil
+}
+
+// findObject returns the single object of class labeled label
+func (s *pkcs11Session) findObject(class uint, label string) (pkcs11.ObjectHandle, error) {
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	}
+	if err := s.ctx.FindObjectsInit(s.session, template); err != nil {
+		return 0, fmt.Errorf("failed to search PKCS#11 objects: %w", err)
+	}
+	handles, _, err := s.ctx.FindObjects(s.session, 2)
+	_ = s.ctx.FindObjectsFinal(s.session)
+	if err != nil {
+		return 0, fmt.Errorf("failed to search PKCS#11 objects: %w", err)
+	}
+	switch len(handles) {
+	case 0:
+		return 0, fmt.Errorf("no PKCS#11 %s labeled %q", pkcs11ClassName(class), label)
+	case 1:
+		return handles[0], nil
+	default:
+		return 0, fmt.Errorf("more than one PKCS#11 %s labeled %q", pkcs11ClassName(class), label)
+	}
+}
+
+// publicKey converts an RSA or EC public key object
+func (s *pkcs11Session) publicKey(handle pkcs11.ObjectHandle) (crypto.PublicKey, error) {
+	attrs, err := s.ctx.GetAttributeValue(s.session, handle, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, nil)})
+	if err != nil {
+		return nil, err
+	}
+	switch keyType := bytesToUint(attrs[0].Value); keyType {
+	case pkcs11.CKK_RSA:
+		attrs, err := s.ctx.GetAttributeValue(s.session, handle, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, nil),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(attrs[0].Value),
+			E: int(new(big.Int).SetBytes(attrs[1].Value).Int64()),
+		}, nil
+	case pkcs11.CKK_EC:
+		attrs, err := s.ctx.GetAttributeValue(s.session, handle, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+		})
+		if err != nil {
+			return nil, err
+		}
+		var oid asn1.ObjectIdentifier
+		if _, err := asn1.Unmarshal(attrs[0].Value, &oid); err != nil {
+			return nil, fmt.Errorf("failed to parse EC parameters: %w", err)
+		}
+		curve, ok := pkcs11Curves[oid.String()]
+		if !ok {
+			return nil, fmt.Errorf("unsupported EC curve %s", oid)
+		}
+		// CKA_EC_POINT is a DER OCTET STRING, but some tokens return the raw point
+		point := attrs[1].Value
+		var wrapped []byte
+		if rest, err := asn1.Unmarshal(point, &wrapped); err == nil && len(rest) == 0 {
+			point = wrapped
+		}
+		return ecdsa.ParseUncompressedPublicKey(curve, point)
+	default:
+		return nil, fmt.Errorf("unsupported PKCS#11 key type 0x%x", keyType)
+	}
+}
+
+// pkcs11Curves maps the named curve OIDs of CKA_EC_PARAMS
+var pkcs11Curves = map[string]elliptic.Curve{
+	"1.2.840.10045.3.1.7": elliptic.P256(),
+	"1.3.132.0.34":        elliptic.P384(),
+	"1.3.132.0.35":        elliptic.P521(),
+}
+
+// pkcs11Signer is a crypto.Signer whose private key stays in the token
+type pkcs11Signer struct {
+	session *pkcs11Session
+	// label finds the private key again in a new session
+	label string
+	key   pkcs11.ObjectHandle
+	pub   crypto.PublicKey
+}
+
+// Public returns the public half of the token key
+func (k *pkcs11Signer) Public() crypto.PublicKey {
+	return k.pub
+}
+
+// Sign signs digest on the token with RSA PKCS#1 v1.5 or ECDSA
+func (k *pkcs11Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	var mechanism uint
+	switch k.pub.(type) {
+	case *rsa.PublicKey:
+		if _, ok := opts.(*rsa.PSSOptions); ok {
+			return nil, fmt.Errorf("RSA-PSS signatures are not supported by the PKCS#11 signer")
+		}
+		prefix, ok := pkcs1DigestInfoPrefixes[opts.HashFunc()]
+		if !ok {
+			return nil, fmt.Errorf("unsupported hash %s for the PKCS#11 signer", opts.HashFunc())
+		}
+		// CKM_RSA_PKCS pads, but expects the DigestInfo encoding of the hash
+		digest = append(append([]byte{}, prefix...), digest...)
+		mechanism = pkcs11.CKM_RSA_PKCS
+	case *ecdsa.PublicKey:
+		mechanism = pkcs11.CKM_ECDSA
+	default:
+		return nil, fmt.Errorf("unsupported PKCS#11 public key type %T", k.pub)
+	}
+
+	k.session.mu.Lock()
+	defer k.session.mu.Unlock()
+	sig, err := k.sign(mechanism, digest)
+	if pkcs11SessionLost(err) {
+		// The token dropped the session: log in on a new one and retry once
+		if err = k.session.reopen(); err == nil {
+			if k.key, err = k.session.findObject(pkcs11.CKO_PRIVATE_KEY, k.label); err == nil {
+				sig, err = k.sign(mechanism, digest)
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if mechanism == pkcs11.CKM_ECDSA {
+		// CKM_ECDSA returns r || s, X.509 wants an ASN.1 sequence
+		if len(sig)%2 != 0 {
+			return nil, fmt.Errorf("malformed PKCS#11 ECDSA signature of %d bytes", len(sig))
+		}
+		half := len(sig) / 2
+		return asn1.Marshal(struct{ R, S *big.Int }{
+			R: new(big.Int).SetBytes(sig[:half]),
+			S: new(big.Int).SetBytes(sig[half:]),
+		})
+	}
+	return sig, nil
+}
+
+// sign does one sign operation on the current session; the caller holds the session lock
+func (k *pkcs11Signer) sign(mechanism uint, digest []byte) ([]byte, error) {
+	ctx, session := k.session.ctx, k.session.session
+	if err := ctx.SignInit(session, []*pkcs11.Mechanism{pkcs11.NewMechanism(mechanism, nil)}, k.key); err != nil {
+		return nil, fmt.Errorf("PKCS#11 sign init failed: %w", err)
+	}
+	sig, err := ctx.Sign(session, digest)
+	if err != nil {
+		return nil, fmt.Errorf("PKCS#11 sign failed: %w", err)
+	}
+	return sig, nil
+}
+
+// pkcs1DigestInfoPrefixes are the DER DigestInfo headers prepended to a hash for PKCS#1 v1.5
+var pkcs1DigestInfoPrefixes = map[crypto.Hash][]byte{
+	crypto.SHA256: {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+	crypto.SHA384: {0x30, 0x41, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02, 0x05, 0x00, 0x04, 0x30},
+	crypto.SHA512: {0x30, 0x51, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03, 0x05, 0x00, 0x04, 0x40},
+}
+
+func pkcs11ClassName(class uint) string {
+	switch class {
+	case pkcs11.CKO_CERTIFICATE:
+		return "certificate"
+	case pkcs11.CKO_PUBLIC_KEY:
+		return "public key"
+	case pkcs11.CKO_PRIVATE_KEY:
+		return "private key"
+	default:
+		return fmt.Sprintf("object class 0x%x", class)
+	}
+}
+
+// bytesToUint decodes a CK_ULONG attribute in host (little-endian) byte order
+func bytesToUint(b []byte) uint {
+	var v uint
+	for i := len(b) - 1; i >= 0; i-- {
+		v = v<<8 | uint(b[i])
+	}
+	return v
+}
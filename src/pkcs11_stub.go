@@ -0,0 +1,10 @@
+//go:build !pkcs11
+
+package main
+
+import "fmt"
+
+// newPKCS11CA is unavailable without the pkcs11 build tag, which needs cgo
+func newPKCS11CA(config *Config) (*CAHelper, error) {
+	return nil, fmt.Errorf("PKCS#11 support is not compiled in, rebuild with CGO_ENABLED=1 and -tags pkcs11")
+}
@@ -0,0 +1,183 @@
+//go:build pkcs11
+
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/miekg/pkcs11"
+	. "github.com/onsi/gomega"
+)
+
+// softHSMConfig returns the signer config for a SoftHSM2 token prepared with
+//
+//	softhsm2-util --init-token --free --label signer-test --pin 1234 --so-pin 1234
+//
+// and SOFTHSM2_MODULE pointing at libsofthsm2.so. The test is skipped otherwise.
+func softHSMConfig(t *testing.T, keyLabel string) *Config {
+	module := os.Getenv("SOFTHSM2_MODULE")
+	if module == "" {
+		t.Skip("SOFTHSM2_MODULE not set")
+	}
+	tokenLabel, pin := os.Getenv("SOFTHSM2_TOKEN_LABEL"), os.Getenv("SOFTHSM2_PIN")
+	if tokenLabel == "" {
+		tokenLabel = "signer-test"
+	}
+	if pin == "" {
+		pin = "1234"
+	}
+	return &Config{
+		SigningBackend:   SigningBackendPKCS11,
+		PKCS11Module:     module,
+		PKCS11Slot:       -1,
+		PKCS11TokenLabel: tokenLabel,
+		PKCS11KeyLabel:   keyLabel,
+		PKCS11PIN:        pin,
+	}
+}
+
+// importTestCA generates a key pair on the token, self-signs a CA certificate
+// with it and stores the certificate next to the key, all labeled label
+func importTestCA(t *testing.T, s *pkcs11Session, label string, mechanism uint, publicAttrs []*pkcs11.Attribute) {
+	public := append([]*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	}, publicAttrs...)
+	private := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	}
+	pubHandle, privHandle, err := s.ctx.GenerateKeyPair(s.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(mechanism, nil)}, public, private)
+	Expect(err).NotTo(HaveOccurred())
+
+	signer, err := s.signer(label)
+	Expect(err).NotTo(HaveOccurred())
+	now := time.Now()
+	cert, err := createSelfSignedCA(&x509.Certificate{
+		Subject:               pkix.Name{CommonName: label},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, signer)
+	Expect(err).NotTo(HaveOccurred())
+
+	certHandle, err := s.ctx.CreateObject(s.session, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_CERTIFICATE),
+		pkcs11.NewAttribute(pkcs11.CKA_CERTIFICATE_TYPE, pkcs11.CKC_X_509),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		pkcs11.NewAttribute(pkcs11.CKA_SUBJECT, cert.RawSubject),
+		pkcs11.NewAttribute(pkcs11.CKA_VALUE, cert.Raw),
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	t.Cleanup(func() {
+		for _, handle := range []pkcs11.ObjectHandle{certHandle, pubHandle, privHandle} {
+			_ = s.ctx.DestroyObject(s.session, handle)
+		}
+	})
+}
+
+func TestPKCS11CA_SignsWithTokenKey(t *testing.T) {
+	RegisterTestingT(t)
+
+	p256, err := asn1.Marshal(asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7})
+	Expect(err).NotTo(HaveOccurred())
+	keyTypes := map[string]struct {
+		mechanism uint
+		attrs     []*pkcs11.Attribute
+	}{
+		"ecdsa": {pkcs11.CKM_EC_KEY_PAIR_GEN, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, p256)}},
+		"rsa": {pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS_BITS, 2048),
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, []byte{1, 0, 1}),
+		}},
+	}
+
+	for name, kt := range keyTypes {
+		label := fmt.Sprintf("signer-test-%s-%d", name, time.Now().UnixNano())
+		config := softHSMConfig(t, label)
+		s, err := openPKCS11Session(config)
+		Expect(err).NotTo(HaveOccurred(), name)
+		importTestCA(t, s, label, kt.mechanism, kt.attrs)
+
+		ca, err := newPKCS11CA(config)
+		Expect(err).NotTo(HaveOccurred(), name)
+		Expect(ca.GetKey()).To(BeAssignableToTypeOf(&pkcs11Signer{}), name)
+
+		leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		chainPEM, err := (&LocalSigningBackend{CA: ca}).Issue(context.Background(), &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "leaf"},
+			NotBefore:    time.Now(),
+			NotAfter:     time.Now().Add(time.Hour),
+		}, leafKey.Public())
+		Expect(err).NotTo(HaveOccurred(), name)
+		leaves, err := parseCertificatesPEM(chainPEM)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(leaves[0].CheckSignatureFrom(ca.GetCert())).To(Succeed(), name)
+	}
+}
+
+func TestPKCS11CA_MissingKey(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := newPKCS11CA(softHSMConfig(t, "does-not-exist"))
+	Expect(err).To(MatchError(ContainSubstring(`labeled "does-not-exist"`)))
+}
+
+func TestPKCS11CA_ReopensLostSession(t *testing.T) {
+	RegisterTestingT(t)
+
+	p256, err := asn1.Marshal(asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7})
+	Expect(err).NotTo(HaveOccurred())
+	label := fmt.Sprintf("signer-test-reopen-%d", time.Now().UnixNano())
+	config := softHSMConfig(t, label)
+	s, err := openPKCS11Session(config)
+	Expect(err).NotTo(HaveOccurred())
+	importTestCA(t, s, label, pkcs11.CKM_EC_KEY_PAIR_GEN, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, p256)})
+
+	ca, err := newPKCS11CA(config)
+	Expect(err).NotTo(HaveOccurred())
+	// Drop the session behind the signer's back, like a token reset does
+	signer := ca.GetKey().(*pkcs11Signer)
+	Expect(signer.session.ctx.CloseSession(signer.session.session)).To(Succeed())
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	_, err = (&LocalSigningBackend{CA: ca}).Issue(context.Background(), &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "leaf"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}, leafKey.Public())
+	Expect(err).NotTo(HaveOccurred())
+}
+
+func TestPKCS11SessionLost(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(pkcs11SessionLost(fmt.Errorf("PKCS#11 sign failed: %w", pkcs11.Error(pkcs11.CKR_SESSION_HANDLE_INVALID)))).To(BeTrue())
+	Expect(pkcs11SessionLost(pkcs11.Error(pkcs11.CKR_DEVICE_REMOVED))).To(BeTrue())
+	Expect(pkcs11SessionLost(pkcs11.Error(pkcs11.CKR_MECHANISM_INVALID))).To(BeFalse())
+	Expect(pkcs11SessionLost(fmt.Errorf("unrelated"))).To(BeFalse())
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// The signing protocol is the gRPC service defined in proto/signer/v1/signing.proto.
+// Its messages are the structs below, encoded with the proto3 JSON mapping, so
+// neither side needs generated code:
+//
+//	service signer.v1.Signing {
+//	  rpc SignLeaf(SignLeafRequest) returns (SignLeafResponse);
+//	  rpc GetCABundle(GetCABundleRequest) returns (GetCABundleResponse);
+//	  rpc Health(HealthRequest) returns (HealthResponse);
+//	}
+const (
+	signingServiceName = "signer.v1.Signing"
+	// signingCodecName is the gRPC content subtype of the protocol ("application/grpc+signer.v1.json")
+	signingCodecName = "signer.v1.json"
+	// remoteSignerRefreshInterval is how often the CA bundle is re-read from the remote signer
+	remoteSignerRefreshInterval = time.Minute
+	// remoteSignerTimeout bounds every call to the remote signer
+	remoteSignerTimeout = 30 * time.Second
+)
+
+// SignLeafRequest carries the leaf template built by the controller and the Pod's public key
+type SignLeafRequest struct {
+	// PublicKey is the PKIX, ASN.1 DER encoded public key to certify
+	PublicKey    []byte    `json:"publicKey"`
+	SerialNumber string    `json:"serialNumber"`
+	CommonName   string    `json:"commonName,omitempty"`
+	DNSNames     []string  `json:"dnsNames,omitempty"`
+	IPAddresses  []string  `json:"ipAddresses,omitempty"`
+	URIs         []string  `json:"uris,omitempty"`
+	NotBefore    time.Time `json:"notBefore"`
+	NotAfter     time.Time `json:"notAfter"`
+	// KeyUsage and ExtKeyUsage are Go's x509.KeyUsage bits and x509.ExtKeyUsage values
+	KeyUsage    int   `json:"keyUsage"`
+	ExtKeyUsage []int `json:"extKeyUsage,omitempty"`
+	// BasicConstraintsValid adds a CA:FALSE basic constraints extension. There is
+	// no IsCA: the server only issues leaves and always sets IsCA to false.
+	BasicConstraintsValid bool `json:"basicConstraintsValid,omitempty"`
+}
+
+// SignLeafResponse is the issued leaf followed by its intermediates, as PEM
+type SignLeafResponse struct {
+	ChainPEM string `json:"chainPEM"`
+}
+
+// GetCABundleRequest asks for the signing CA
+type GetCABundleRequest struct{}
+
+// GetCABundleResponse is the signing CA followed by its chain, and the certificates clients should trust
+type GetCABundleResponse struct {
+	CAPEM           string `json:"caPEM"`
+	TrustAnchorsPEM string `json:"trustAnchorsPEM"`
+}
+
+// HealthRequest asks whether the remote signer can sign
+type HealthRequest struct{}
+
+// HealthResponse is returned by a healthy remote signer; an unhealthy one returns an error
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+// signingService is implemented by the serve-signing server
+type signingService interface {
+	SignLeaf(context.Context, *SignLeafRequest) (*SignLeafResponse, error)
+	GetCABundle(context.Context, *GetCABundleRequest) (*GetCABundleResponse, error)
+	Health(context.Context, *HealthRequest) (*HealthResponse, error)
+}
+
+// signingServiceDesc registers a signingService with a gRPC server
+var signingServiceDesc = grpc.ServiceDesc{
+	ServiceName: signingServiceName,
+	HandlerType: (*signingService)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "SignLeaf", Handler: unaryHandler("SignLeaf", signingService.SignLeaf)},
+		{MethodName: "GetCABundle", Handler: unaryHandler("GetCABundle", signingService.GetCABundle)},
+		{MethodName: "Health", Handler: unaryHandler("Health", signingService.Health)},
+	},
+}
+
+// unaryHandler adapts a signingService method to a gRPC method handler
+func unaryHandler[Req, Resp any](method string, call func(signingService, context.Context, *Req) (*Resp, error)) grpc.MethodHandler {
+	return func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+		in := new(Req)
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		handler := func(ctx context.Context, req any) (any, error) {
+			return call(srv.(signingService), ctx, req.(*Req))
+		}
+		if interceptor == nil {
+			return handler(ctx, in)
+		}
+		return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + signingServiceName + "/" + method}, handler)
+	}
+}
+
+// signingCodec encodes the protocol messages as JSON. It is passed to the
+// signing server and client only, never registered with gRPC globally.
+type signingCodec struct{}
+
+func (signingCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (signingCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+func (signingCodec) Name() string                       { return signingCodecName }
+
+// newSignLeafRequest converts a leaf template built by the SignerReconciler
+func newSignLeafRequest(template *x509.Certificate, pub crypto.PublicKey) (*SignLeafRequest, error) {
+	if template.IsCA {
+		return nil, fmt.Errorf("the signing protocol only issues leaf certificates")
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public key: %w", err)
+	}
+	req := &SignLeafRequest{
+		PublicKey:             der,
+		SerialNumber:          template.SerialNumber.String(),
+		CommonName:            template.Subject.CommonName,
+		DNSNames:              template.DNSNames,
+		NotBefore:             template.NotBefore,
+		NotAfter:              template.NotAfter,
+		KeyUsage:              int(template.KeyUsage),
+		BasicConstraintsValid: template.BasicConstraintsValid,
+	}
+	for _, ip := range template.IPAddresses {
+		req.IPAddresses = append(req.IPAddresses, ip.String())
+	}
+	for _, uri := range template.URIs {
+		req.URIs = append(req.URIs, uri.String())
+	}
+	for _, usage := range template.ExtKeyUsage {
+		req.ExtKeyUsage = append(req.ExtKeyUsage, int(usage))
+	}
+	return req, nil
+}
+
+// template converts the request back into a leaf template and the public key to certify
+func (req *SignLeafRequest) template() (*x509.Certificate, crypto.PublicKey, error) {
+	pub, err := x509.ParsePKIXPublicKey(req.PublicKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	serial, ok := new(big.Int).SetString(req.SerialNumber, 10)
+	if !ok || serial.Sign() <= 0 {
+		return nil, nil, fmt.Errorf("invalid serial number %q", req.SerialNumber)
+	}
+	if !req.NotAfter.After(req.NotBefore) {
+		return nil, nil, fmt.Errorf("notAfter %s is not after notBefore %s", req.NotAfter, req.NotBefore)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: req.CommonName},
+		DNSNames:              req.DNSNames,
+		NotBefore:             req.NotBefore,
+		NotAfter:              req.NotAfter,
+		KeyUsage:              x509.KeyUsage(req.KeyUsage),
+		BasicConstraintsValid: req.BasicConstraintsValid,
+		// Leaves only, whatever the client sends
+		IsCA: false,
+	}
+	for _, s := range req.IPAddresses {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, nil, fmt.Errorf("invalid IP address %q", s)
+		}
+		template.IPAddresses = append(template.IPAddresses, ip)
+	}
+	for _, s := range req.URIs {
+		uri, err := url.Parse(s)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid URI %q: %w", s, err)
+		}
+		template.URIs = append(template.URIs, uri)
+	}
+	for _, usage := range req.ExtKeyUsage {
+		template.ExtKeyUsage = append(template.ExtKeyUsage, x509.ExtKeyUsage(usage))
+	}
+	// Leaves only: certSign would let the holder issue certificates itself
+	if template.KeyUsage&(x509.KeyUsageCertSign|x509.KeyUsageCRLSign) != 0 {
+		return nil, nil, fmt.Errorf("leaf certificates must not have the certSign or cRLSign key usage")
+	}
+	return template, pub, nil
+}
+
+// RemoteSigningBackend has certificates signed by a `signer serve-signing`
+// process over mutual TLS, so the controller holds no key material. Like the
+// Vault backend, it keeps CA filled with the remote signer's CA bundle.
+type RemoteSigningBackend struct {
+	conn *grpc.ClientConn
+	// CA receives the remote signer's CA, chain and trust anchors; it never holds a key
+	CA              *CAHelper
+	RefreshInterval time.Duration
+}
+
+// newRemoteSigningBackend connects to config.SigningEndpoint; the connection is established lazily
+func newRemoteSigningBackend(config *Config, ca *CAHelper) (*RemoteSigningBackend, error) {
+	if config.SigningEndpoint == "" {
+		return nil, fmt.Errorf("SIGNING_ENDPOINT is required for the grpc signing backend")
+	}
+	tlsConfig, err := loadSigningTLSConfig(config, false)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.NewClient(config.SigningEndpoint,
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(signingCodec{})),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create remote signer client: %w", err)
+	}
+	return &RemoteSigningBackend{conn: conn, CA: ca, RefreshInterval: remoteSignerRefreshInterval}, nil
+}
+
+// Issuer returns the remote signer's CA, once it has been fetched
+func (b *RemoteSigningBackend) Issuer() *x509.Certificate {
+	return b.CA.GetCert()
+}
+
+// Issue has the remote signer sign template for pub
+func (b *RemoteSigningBackend) Issue(ctx context.Context, template *x509.Certificate, pub crypto.PublicKey) ([]byte, error) {
+	req, err := newSignLeafRequest(template, pub)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, remoteSignerTimeout)
+	defer cancel()
+	var resp SignLeafResponse
+	if err := b.conn.Invoke(ctx, "/"+signingServiceName+"/SignLeaf", req, &resp); err != nil {
+		return nil, fmt.Errorf("remote signer: %w", err)
+	}
+	return []byte(resp.ChainPEM), nil
+}
+
+// Health calls the remote signer's Health method
+func (b *RemoteSigningBackend) Health(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, remoteSignerTimeout)
+	defer cancel()
+	var resp HealthResponse
+	if err := b.conn.Invoke(ctx, "/"+signingServiceName+"/Health", &HealthRequest{}, &resp); err != nil {
+		return fmt.Errorf("remote signer unhealthy: %w", err)
+	}
+	return nil
+}
+
+// Start fetches the CA bundle every RefreshInterval until the context is cancelled
+func (b *RemoteSigningBackend) Start(ctx context.Context) error {
+	defer func() { _ = b.conn.Close() }()
+	ticker := time.NewTicker(b.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := b.refreshCABundle(ctx); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to fetch the remote signer CA bundle")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection is false: every replica needs the CA to be ready
+func (b *RemoteSigningBackend) NeedLeaderElection() bool {
+	return false
+}
+
+// refreshCABundle loads the remote signer's CA and trust anchors into CA when they changed
+func (b *RemoteSigningBackend) refreshCABundle(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, remoteSignerTimeout)
+	defer cancel()
+	var resp GetCABundleResponse
+	if err := b.conn.Invoke(ctx, "/"+signingServiceName+"/GetCABundle", &GetCABundleRequest{}, &resp); err != nil {
+		return fmt.Errorf("remote signer: %w", err)
+	}
+
+	certs, err := parseCertificatesPEM([]byte(resp.CAPEM))
+	if err != nil {
+		return fmt.Errorf("invalid remote signer CA: %w", err)
+	}
+	cert, chain := certs[0], certs[1:]
+	if err := verifyChain(cert, chain); err != nil {
+		return fmt.Errorf("invalid remote signer CA: %w", err)
+	}
+	trust, err := parseCertificatesPEM([]byte(resp.TrustAnchorsPEM))
+	if err != nil {
+		return fmt.Errorf("invalid remote signer trust anchors: %w", err)
+	}
+	if bytes.Equal(b.CA.TrustAnchorsPEM(), encodeCertificatesPEM(trust)) && b.CA.GetCert() != nil && b.CA.GetCert().Equal(cert) {
+		return nil
+	}
+
+	b.CA.set(&caKeyPair{cert: cert, chain: chain}, trust)
+	log.FromContext(ctx).Info("Loaded remote signer CA", "subject", cert.Subject.String(), "notAfter", cert.NotAfter)
+	return nil
+}
+
+// loadSigningTLSConfig builds the mutual TLS configuration of either end of the signing protocol
+func loadSigningTLSConfig(config *Config, server bool) (*tls.Config, error) {
+	if config.SigningTLSCert == "" || config.SigningTLSKey == "" || config.SigningTLSCA == "" {
+		return nil, fmt.Errorf("SIGNING_TLS_CERT, SIGNING_TLS_KEY and SIGNING_TLS_CA are required for the remote signer")
+	}
+	cert, err := tls.LoadX509KeyPair(config.SigningTLSCert, config.SigningTLSKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load remote signer TLS certificate: %w", err)
+	}
+	caPEM, err := os.ReadFile(config.SigningTLSCA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote signer TLS CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in remote signer TLS CA %s", config.SigningTLSCA)
+	}
+
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS13}
+	if server {
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	} else {
+		tlsConfig.RootCAs = pool
+		tlsConfig.ServerName = config.SigningTLSServerName
+	}
+	return tlsConfig, nil
+}
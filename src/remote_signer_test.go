@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// testSigningPKI is the mutual TLS material of the signing protocol, written to a directory
+type testSigningPKI struct {
+	caFile                string
+	serverCert, serverKey string
+	clientCert, clientKey string
+	caCert                *x509.Certificate
+	caKey                 crypto.Signer
+	dir                   string
+}
+
+func newTestSigningPKI(dir string) *testSigningPKI {
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p := &testSigningPKI{caCert: newTestCACert("Signing TLS CA", caKey, nil, nil), caKey: caKey, dir: dir}
+	p.caFile = filepath.Join(dir, "tls-ca.crt")
+	Expect(os.WriteFile(p.caFile, encodeTestCerts(p.caCert), 0o600)).To(Succeed())
+	p.serverCert, p.serverKey = p.issue("server", x509.ExtKeyUsageServerAuth)
+	p.clientCert, p.clientKey = p.issue("client", x509.ExtKeyUsageClientAuth)
+	return p
+}
+
+// issue writes a TLS certificate for localhost signed by the TLS CA
+func (p *testSigningPKI) issue(name string, usage x509.ExtKeyUsage) (certFile, keyFile string) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}, p.caCert, key.Public(), p.caKey)
+	Expect(err).NotTo(HaveOccurred())
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+
+	certFile, keyFile = filepath.Join(p.dir, name+".crt"), filepath.Join(p.dir, name+".key")
+	Expect(os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)).To(Succeed())
+	Expect(os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600)).To(Succeed())
+	return certFile, keyFile
+}
+
+func (p *testSigningPKI) clientConfig(endpoint string) *Config {
+	return &Config{
+		SigningBackend:  SigningBackendGRPC,
+		SigningEndpoint: endpoint,
+		SigningTLSCert:  p.clientCert,
+		SigningTLSKey:   p.clientKey,
+		SigningTLSCA:    p.caFile,
+	}
+}
+
+// startTestSigningServer serves srv on a loopback port and returns its address
+func startTestSigningServer(t *testing.T, p *testSigningPKI, srv signingService) string {
+	server, err := newSigningGRPCServer(&Config{SigningTLSCert: p.serverCert, SigningTLSKey: p.serverKey, SigningTLSCA: p.caFile}, srv)
+	Expect(err).NotTo(HaveOccurred())
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
+
+func TestRemoteSigningBackend_IssuesOverMutualTLS(t *testing.T) {
+	RegisterTestingT(t)
+
+	rootKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	root := newTestCACert("Signer Root", rootKey, nil, nil)
+	intKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	intermediate := newTestCACert("Signer Intermediate", intKey, root, rootKey)
+	server := &SigningServer{CA: &CAHelper{}}
+	server.CA.set(&caKeyPair{cert: intermediate, chain: []*x509.Certificate{root}, key: intKey}, nil)
+
+	pki := newTestSigningPKI(t.TempDir())
+	address := startTestSigningServer(t, pki, server)
+	backend, err := newRemoteSigningBackend(pki.clientConfig(address), &CAHelper{})
+	Expect(err).NotTo(HaveOccurred())
+
+	// The controller learns the CA from the remote signer
+	Expect(backend.Issuer()).To(BeNil())
+	Expect(backend.refreshCABundle(context.Background())).To(Succeed())
+	Expect(backend.Issuer().Equal(intermediate)).To(BeTrue())
+	Expect(string(backend.CA.TrustAnchorsPEM())).To(Equal(string(encodeTestCerts(root))))
+	Expect(backend.Health(context.Background())).To(Succeed())
+
+	leafKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := testLeafTemplate()
+	template.IPAddresses = []net.IP{net.ParseIP("10.0.0.1")}
+	template.URIs = []*url.URL{{Scheme: "spiffe", Host: "cluster.local", Path: "/ns/default/sa/app"}}
+	template.BasicConstraintsValid = true
+	chainPEM, err := backend.Issue(context.Background(), template, leafKey.Public())
+	Expect(err).NotTo(HaveOccurred())
+
+	// The template survives the round trip; the chain stops below the root
+	certs, err := parseCertificatesPEM(chainPEM)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(certs).To(HaveLen(2))
+	leaf := certs[0]
+	Expect(leaf.PublicKey).To(Equal(leafKey.Public()))
+	Expect(leaf.SerialNumber).To(Equal(template.SerialNumber))
+	Expect(leaf.Subject.CommonName).To(Equal(template.Subject.CommonName))
+	Expect(leaf.DNSNames).To(Equal(template.DNSNames))
+	Expect(leaf.IPAddresses[0].Equal(template.IPAddresses[0])).To(BeTrue())
+	Expect(leaf.URIs[0].String()).To(Equal("spiffe://cluster.local/ns/default/sa/app"))
+	Expect(leaf.ExtKeyUsage).To(Equal(template.ExtKeyUsage))
+	Expect(leaf.KeyUsage).To(Equal(template.KeyUsage))
+	Expect(leaf.NotAfter.Equal(template.NotAfter)).To(BeTrue())
+	Expect(leaf.BasicConstraintsValid).To(BeTrue())
+	Expect(leaf.IsCA).To(BeFalse())
+	Expect(leaf.CheckSignatureFrom(intermediate)).To(Succeed())
+	Expect(certs[1].Equal(intermediate)).To(BeTrue())
+
+	// The codec is private to the protocol, not registered for every gRPC user of the process
+	Expect(encoding.GetCodecV2(signingCodecName)).To(BeNil())
+	Expect(encoding.GetCodecV2("json")).To(BeNil())
+}
+
+func TestRemoteSigningBackend_RejectsUntrustedClient(t *testing.T) {
+	RegisterTestingT(t)
+
+	pki := newTestSigningPKI(t.TempDir())
+	address := startTestSigningServer(t, pki, &SigningServer{CA: &CAHelper{}})
+
+	// A client certificate from another CA fails the handshake
+	other := newTestSigningPKI(t.TempDir())
+	config := other.clientConfig(address)
+	config.SigningTLSCA = pki.caFile
+	backend, err := newRemoteSigningBackend(config, &CAHelper{})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(backend.Health(context.Background())).To(HaveOccurred())
+
+	_, err = newRemoteSigningBackend(&Config{SigningEndpoint: address}, &CAHelper{})
+	Expect(err).To(MatchError(ContainSubstring("SIGNING_TLS_CERT")))
+	_, err = newRemoteSigningBackend(&Config{}, &CAHelper{})
+	Expect(err).To(MatchError(ContainSubstring("SIGNING_ENDPOINT")))
+}
+
+func TestSigningServer_Errors(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctx := context.Background()
+	server := &SigningServer{CA: &CAHelper{}, LoadFailurePolicy: CALoadFailurePause}
+	leafKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	req, err := newSignLeafRequest(testLeafTemplate(), leafKey.Public())
+	Expect(err).NotTo(HaveOccurred())
+
+	// Unavailable until a CA is loaded
+	_, err = server.Health(ctx, &HealthRequest{})
+	Expect(status.Code(err)).To(Equal(codes.Unavailable))
+	_, err = server.GetCABundle(ctx, &GetCABundleRequest{})
+	Expect(status.Code(err)).To(Equal(codes.Unavailable))
+	_, err = server.SignLeaf(ctx, req)
+	Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	server.CA.set(&caKeyPair{cert: newTestCACert("Signer CA", caKey, nil, nil), key: caKey}, nil)
+	_, err = server.SignLeaf(ctx, req)
+	Expect(err).NotTo(HaveOccurred())
+
+	// Never a certificate that could sign others
+	caTemplate := testLeafTemplate()
+	caTemplate.IsCA = true
+	_, err = newSignLeafRequest(caTemplate, leafKey.Public())
+	Expect(err).To(MatchError(ContainSubstring("only issues leaf certificates")))
+	caReq := *req
+	caReq.KeyUsage = int(x509.KeyUsageCertSign)
+	_, err = server.SignLeaf(ctx, &caReq)
+	Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+	badReq := *req
+	badReq.SerialNumber = "0"
+	_, err = server.SignLeaf(ctx, &badReq)
+	Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+
+	// The pause policy stops signing while the CA fails to reload
+	server.CA.setLoadError(fmt.Errorf("CA secret deleted"))
+	_, err = server.SignLeaf(ctx, req)
+	Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+	Expect(err).To(MatchError(ContainSubstring("issuance paused")))
+}
+
+func TestWatchCASecret(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctx := context.Background()
+	secret := createFakeSecret("ca", "signer")
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+	config := &Config{CASecretName: "ca", CASecretNamespace: "signer", CACertKey: "ca.crt", CAKeyKey: "ca.key"}
+	ca, err := loadSigningServerCA(ctx, config, c)
+	Expect(err).NotTo(HaveOccurred())
+	informers := &informertest.FakeInformers{Scheme: scheme}
+	Expect(watchCASecret(ctx, ca, informers, c, config)).To(Succeed())
+	informer, err := informers.FakeInformerFor(ctx, &corev1.Secret{})
+	Expect(err).NotTo(HaveOccurred())
+
+	// A new CA is picked up as soon as the Secret changes
+	rotated := createFakeSecret("ca", "signer")
+	rotated.ResourceVersion = ""
+	Expect(c.Update(ctx, rotated)).To(Succeed())
+	informer.Update(secret, rotated)
+	next, err := parseCertificatesPEM(rotated.Data["ca.crt"])
+	Expect(err).NotTo(HaveOccurred())
+	Expect(ca.GetCert().Equal(next[0])).To(BeTrue())
+
+	// A deleted Secret keeps the last CA but reports the failure
+	Expect(c.Delete(ctx, rotated)).To(Succeed())
+	informer.Delete(rotated)
+	Expect(apierrors.IsNotFound(ca.LoadError())).To(BeTrue())
+	Expect(ca.GetCert().Equal(next[0])).To(BeTrue())
+}
+
+func TestSigningServer_EnforcesConstraints(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctx := context.Background()
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	server := &SigningServer{CA: &CAHelper{}, MaxValidity: 2 * time.Hour}
+	server.CA.set(&caKeyPair{cert: newTestCACert("Signer CA", caKey, nil, nil), key: caKey}, nil)
+	leafKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	sign := func(modify func(*x509.Certificate)) error {
+		template := testLeafTemplate()
+		modify(template)
+		req, err := newSignLeafRequest(template, leafKey.Public())
+		Expect(err).NotTo(HaveOccurred())
+		_, err = server.SignLeaf(ctx, req)
+		return err
+	}
+	refused := func(message string) OmegaMatcher {
+		return SatisfyAll(
+			WithTransform(status.Code, Equal(codes.InvalidArgument)),
+			MatchError(ContainSubstring(message)),
+		)
+	}
+
+	// What the controller sends is signed
+	Expect(sign(func(*x509.Certificate) {})).To(Succeed())
+
+	// Validity
+	Expect(sign(func(c *x509.Certificate) { c.NotAfter = c.NotBefore.Add(3 * time.Hour) })).To(refused("exceeds the maximum of 2h0m0s"))
+	Expect(sign(func(c *x509.Certificate) {
+		c.NotBefore = c.NotBefore.Add(-time.Hour)
+		c.NotAfter = c.NotBefore.Add(time.Hour)
+	})).To(refused("notBefore"))
+
+	// Usages
+	Expect(sign(func(c *x509.Certificate) { c.KeyUsage |= x509.KeyUsageContentCommitment })).To(refused("not allowed on leaves"))
+	Expect(sign(func(c *x509.Certificate) { c.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageAny} })).To(refused("extended key usage"))
+
+	// SANs
+	Expect(sign(func(c *x509.Certificate) { c.DNSNames = []string{"*.cluster.local"} })).To(refused("invalid DNS SAN"))
+	Expect(sign(func(c *x509.Certificate) { c.Subject.CommonName = "bank.example.com" })).To(refused("not one of the DNS SANs"))
+	Expect(sign(func(c *x509.Certificate) {
+		c.Subject.CommonName = ""
+		c.DNSNames = nil
+	})).To(refused("no SANs"))
+
+	// SPIFFE mode issues X.509-SVIDs in its trust domain only
+	server.IssuanceMode, server.TrustDomain = IssuanceModeSPIFFE, "cluster.local"
+	svid := func(uri string) func(*x509.Certificate) {
+		return func(c *x509.Certificate) {
+			c.Subject.CommonName = ""
+			c.DNSNames = nil
+			u, _ := url.Parse(uri)
+			c.URIs = []*url.URL{u}
+		}
+	}
+	Expect(sign(svid("spiffe://cluster.local/ns/default/sa/app"))).To(Succeed())
+	Expect(sign(svid("spiffe://other.domain/ns/default/sa/app"))).To(refused("spiffe://cluster.local"))
+	Expect(sign(func(c *x509.Certificate) {
+		svid("spiffe://cluster.local/ns/default/sa/app")(c)
+		c.Subject.CommonName = "app"
+	})).To(refused("empty subject"))
+}
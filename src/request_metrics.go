@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// requestMetricsInterval is how often the request gauges are recomputed
+	// between reconciles, so that ages and expiries keep moving while idle
+	requestMetricsInterval = 30 * time.Second
+	// requestMetricsDebounce delays the recompute a reconcile asks for, so the
+	// cache sees its status write and a burst of reconciles lists only once
+	requestMetricsDebounce = time.Second
+)
+
+// updateRequestMetrics recomputes the request gauges from the signer's PodCertificateRequests
+func updateRequestMetrics(ctx context.Context, reader client.Reader, signerName string, now time.Time) error {
+	var pcrs certificatesv1beta1.PodCertificateRequestList
+	if err := reader.List(ctx, &pcrs); err != nil {
+		return fmt.Errorf("failed to list PodCertificateRequests: %w", err)
+	}
+
+	var pending, issued int
+	var oldest time.Duration
+	for i := range pcrs.Items {
+		pcr := &pcrs.Items[i]
+		if pcr.Spec.SignerName != signerName {
+			continue
+		}
+
+		switch {
+		case len(pcr.Status.CertificateChain) > 0:
+			if pcr.Status.NotAfter != nil && pcr.Status.NotAfter.After(now) {
+				issued++
+			}
+		case terminalCondition(pcr) != nil:
+			// Denied and Failed requests are neither pending nor issued
+		default:
+			pending++
+			if age := now.Sub(pcr.CreationTimestamp.Time); age > oldest {
+				oldest = age
+			}
+		}
+	}
+
+	ActiveCertificatesGauge.Set(float64(pending))
+	IssuedUnexpiredGauge.Set(float64(issued))
+	OldestPendingRequestAge.Set(oldest.Seconds())
+	return nil
+}
+
+// RequestMetricsRunnable refreshes the request gauges from the manager cache
+// periodically and after reconciles, see Refresh
+type RequestMetricsRunnable struct {
+	Reader     client.Reader
+	SignerName string
+	Interval   time.Duration
+
+	refreshOnce sync.Once
+	refresh     chan struct{}
+}
+
+// Refresh asks for the gauges to be recomputed shortly. It never blocks, and
+// calls made while a recompute is pending are coalesced into it.
+func (r *RequestMetricsRunnable) Refresh() {
+	select {
+	case r.refreshRequests() <- struct{}{}:
+	default:
+	}
+}
+
+func (r *RequestMetricsRunnable) refreshRequests() chan struct{} {
+	r.refreshOnce.Do(func() { r.refresh = make(chan struct{}, 1) })
+	return r.refresh
+}
+
+// Start refreshes the gauges every Interval until the context is cancelled
+func (r *RequestMetricsRunnable) Start(ctx context.Context) error {
+	log := log.FromContext(ctx).WithName("request-metrics")
+
+	interval := r.Interval
+	if interval <= 0 {
+		interval = requestMetricsInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := updateRequestMetrics(ctx, r.Reader, r.SignerName, time.Now()); err != nil {
+			log.Error(err, "Failed to update request metrics")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-r.refreshRequests():
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(requestMetricsDebounce):
+			}
+		}
+	}
+}
+
+// NeedLeaderElection reports the gauges only from the leader, which is the
+// replica actually signing requests.
+func (r *RequestMetricsRunnable) NeedLeaderElection() bool {
+	return true
+}
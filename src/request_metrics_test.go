@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func getGaugeValue(gauge prometheus.Gauge) float64 {
+	var m dto.Metric
+	gauge.Write(&m)
+	return m.GetGauge().GetValue()
+}
+
+func getCounterValue(counter prometheus.Counter) float64 {
+	var m dto.Metric
+	counter.Write(&m)
+	return m.GetCounter().GetValue()
+}
+
+func newMetricsTestPCR(name, signer string, created time.Time) *certificatesv1beta1.PodCertificateRequest {
+	return &certificatesv1beta1.PodCertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: certificatesv1beta1.PodCertificateRequestSpec{SignerName: signer},
+	}
+}
+
+func TestUpdateRequestMetrics(t *testing.T) {
+	RegisterTestingT(t)
+
+	scheme := runtime.NewScheme()
+	Expect(certificatesv1beta1.AddToScheme(scheme)).To(Succeed())
+
+	now := time.Now()
+	const signer = "novog93.ghcr/signer"
+
+	pendingOld := newMetricsTestPCR("pending-old", signer, now.Add(-10*time.Minute))
+	pendingNew := newMetricsTestPCR("pending-new", signer, now.Add(-time.Minute))
+
+	issued := newMetricsTestPCR("issued", signer, now.Add(-time.Hour))
+	issued.Status.CertificateChain = "chain"
+	issued.Status.NotAfter = &metav1.Time{Time: now.Add(time.Hour)}
+
+	expired := newMetricsTestPCR("expired", signer, now.Add(-3*time.Hour))
+	expired.Status.CertificateChain = "chain"
+	expired.Status.NotAfter = &metav1.Time{Time: now.Add(-time.Hour)}
+
+	denied := newMetricsTestPCR("denied", signer, now.Add(-time.Hour))
+	denied.Status.Conditions = []metav1.Condition{{
+		Type:   certificatesv1beta1.PodCertificateRequestConditionTypeDenied,
+		Status: metav1.ConditionTrue,
+		Reason: "KeyNotAllowed",
+	}}
+
+	other := newMetricsTestPCR("other-signer", "example.com/other", now.Add(-time.Hour))
+
+	reader := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(pendingOld, pendingNew, issued, expired, denied, other).
+		Build()
+
+	Expect(updateRequestMetrics(context.Background(), reader, signer, now)).To(Succeed())
+	Expect(getGaugeValue(ActiveCertificatesGauge)).To(Equal(2.0))
+	Expect(getGaugeValue(IssuedUnexpiredGauge)).To(Equal(1.0))
+	Expect(getGaugeValue(OldestPendingRequestAge)).To(BeNumerically("~", (10 * time.Minute).Seconds(), 1))
+}
+
+func TestUpdateRequestMetrics_NoPending(t *testing.T) {
+	RegisterTestingT(t)
+
+	scheme := runtime.NewScheme()
+	Expect(certificatesv1beta1.AddToScheme(scheme)).To(Succeed())
+
+	reader := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	Expect(updateRequestMetrics(context.Background(), reader, "novog93.ghcr/signer", time.Now())).To(Succeed())
+	Expect(getGaugeValue(ActiveCertificatesGauge)).To(BeZero())
+	Expect(getGaugeValue(IssuedUnexpiredGauge)).To(BeZero())
+	Expect(getGaugeValue(OldestPendingRequestAge)).To(BeZero())
+}
+
+func TestRequestMetricsRunnable_RefreshesAfterReconcile(t *testing.T) {
+	RegisterTestingT(t)
+
+	scheme := runtime.NewScheme()
+	Expect(certificatesv1beta1.AddToScheme(scheme)).To(Succeed())
+	const signer = "novog93.ghcr/signer"
+	pcr := newMetricsTestPCR("pending", signer, time.Now())
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pcr).Build()
+
+	// The interval alone would not refresh again during the test
+	runnable := &RequestMetricsRunnable{Reader: c, SignerName: signer, Interval: time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = runnable.Start(ctx) }()
+	Eventually(func() float64 { return getGaugeValue(ActiveCertificatesGauge) }).Should(Equal(1.0))
+
+	// Issuing the request moves it from pending to issued once the reconcile asks for a refresh
+	pcr.Status.CertificateChain = "chain"
+	pcr.Status.NotAfter = &metav1.Time{Time: time.Now().Add(time.Hour)}
+	Expect(c.Update(ctx, pcr)).To(Succeed())
+	reconciler := &SignerReconciler{Client: c, SignerName: signer, RequestMetrics: runnable}
+	_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
+	Expect(err).NotTo(HaveOccurred())
+	Eventually(func() float64 { return getGaugeValue(ActiveCertificatesGauge) }, 5*time.Second).Should(BeZero())
+	Expect(getGaugeValue(IssuedUnexpiredGauge)).To(Equal(1.0))
+
+	// Refreshes never block, however many are asked for
+	for range 10 {
+		runnable.Refresh()
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"time"
+
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultClusterDomain = "cluster.local"
+
+	// defaultPodIPWaitTimeout bounds how long a request waits for its Pod to be assigned an IP
+	defaultPodIPWaitTimeout = time.Minute
+	// podIPRequeueInterval is how often a request is retried while waiting for a Pod IP
+	podIPRequeueInterval = 5 * time.Second
+	// podLookupGracePeriod is how long a missing or recreated Pod is blamed on a
+	// lagging cache before the request is failed
+	podLookupGracePeriod = 30 * time.Second
+)
+
+// SANResolver derives DNS SANs for a PodCertificateRequest from the Pod it
+// was issued for and the Services that front that Pod.
+type SANResolver struct {
+	client.Reader
+	ClusterDomain string
+}
+
+// lookupPod fetches the Pod referenced by the PCR and verifies its UID so that
+// a recreated Pod with the same name never inherits another Pod's names.
+func lookupPod(ctx context.Context, reader client.Reader, pcr *certificatesv1beta1.PodCertificateRequest) (*corev1.Pod, error) {
+	var pod corev1.Pod
+	if err := reader.Get(ctx, types.NamespacedName{Name: pcr.Spec.PodName, Namespace: pcr.Namespace}, &pod); err != nil {
+		err = fmt.Errorf("failed to get pod %s/%s: %w", pcr.Namespace, pcr.Spec.PodName, err)
+		if apierrors.IsNotFound(err) {
+			return nil, &podLookupError{Reason: "PodNotFound", Err: err}
+		}
+		return nil, err
+	}
+	if pod.UID != pcr.Spec.PodUID {
+		return nil, &podLookupError{
+			Reason: "PodUIDMismatch",
+			Err:    fmt.Errorf("pod %s/%s has UID %s, request was made for UID %s", pcr.Namespace, pcr.Spec.PodName, pod.UID, pcr.Spec.PodUID),
+		}
+	}
+	return &pod, nil
+}
+
+// podLookupError is a Pod that is gone or was recreated under the requested
+// name. Once the cache has caught up the request can never be issued.
+type podLookupError struct {
+	// Reason is PodNotFound or PodUIDMismatch
+	Reason string
+	Err    error
+}
+
+func (e *podLookupError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *podLookupError) Unwrap() error {
+	return e.Err
+}
+
+// Resolve returns the DNS names of every Service selecting the Pod
+// (<svc>.<ns>.svc and <svc>.<ns>.svc.<clusterDomain>) and, for Pods with a
+// hostname/subdomain behind a headless Service (e.g. StatefulSets), the
+// per-Pod <hostname>.<subdomain>.<ns>.svc[.<clusterDomain>] names.
+func (r *SANResolver) Resolve(ctx context.Context, pod *corev1.Pod) ([]string, error) {
+	var services corev1.ServiceList
+	if err := r.List(ctx, &services, client.InNamespace(pod.Namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list services in namespace %s: %w", pod.Namespace, err)
+	}
+
+	clusterDomain := r.clusterDomain()
+	podLabels := labels.Set(pod.Labels)
+
+	var names []string
+	for _, svc := range services.Items {
+		if svc.Spec.Type == corev1.ServiceTypeExternalName || len(svc.Spec.Selector) == 0 {
+			continue
+		}
+		if !labels.SelectorFromSet(svc.Spec.Selector).Matches(podLabels) {
+			continue
+		}
+
+		names = append(names,
+			fmt.Sprintf("%s.%s.svc", svc.Name, svc.Namespace),
+			fmt.Sprintf("%s.%s.svc.%s", svc.Name, svc.Namespace, clusterDomain),
+		)
+
+		// Headless Services publish per-Pod records for Pods whose subdomain
+		// matches the Service name (this is how StatefulSet Pods are addressed).
+		if svc.Spec.ClusterIP == corev1.ClusterIPNone && pod.Spec.Subdomain == svc.Name {
+			hostname := pod.Spec.Hostname
+			if hostname == "" {
+				hostname = pod.Name
+			}
+			names = append(names,
+				fmt.Sprintf("%s.%s.%s.svc", hostname, svc.Name, svc.Namespace),
+				fmt.Sprintf("%s.%s.%s.svc.%s", hostname, svc.Name, svc.Namespace, clusterDomain),
+			)
+		}
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// podIPs returns every IP address assigned to the Pod (both families on dual-stack clusters)
+func podIPs(pod *corev1.Pod) []net.IP {
+	var ips []net.IP
+	for _, podIP := range pod.Status.PodIPs {
+		if ip := net.ParseIP(podIP.IP); ip != nil {
+			ips = append(ips, ip)
+		}
+	}
+	if len(ips) == 0 && pod.Status.PodIP != "" {
+		if ip := net.ParseIP(pod.Status.PodIP); ip != nil {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+func (r *SANResolver) clusterDomain() string {
+	if r.ClusterDomain == "" {
+		return defaultClusterDomain
+	}
+	return r.ClusterDomain
+}
+
+// appendUnique appends names that are not already present, preserving order
+func appendUnique(dst []string, names ...string) []string {
+	seen := make(map[string]struct{}, len(dst)+len(names))
+	for _, n := range dst {
+		seen[n] = struct{}{}
+	}
+	for _, n := range names {
+		if _, ok := seen[n]; ok {
+			continue
+		}
+		seen[n] = struct{}{}
+		dst = append(dst, n)
+	}
+	return dst
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newSANTestClient(objs ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
+func newSANTestPod() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-0",
+			Namespace: "shop",
+			UID:       "pod-uid",
+			Labels:    map[string]string{"app": "web"},
+		},
+		Spec: corev1.PodSpec{
+			Hostname:  "web-0",
+			Subdomain: "web-headless",
+		},
+	}
+}
+
+func TestSANResolver_MatchingServices(t *testing.T) {
+	RegisterTestingT(t)
+
+	pod := newSANTestPod()
+	c := newSANTestClient(
+		pod,
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "web"}},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "shop"},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "other"}},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "elsewhere"},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "web"}},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "no-selector", Namespace: "shop"},
+		},
+	)
+
+	resolver := &SANResolver{Reader: c, ClusterDomain: "example.internal"}
+	names, err := resolver.Resolve(context.Background(), pod)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(names).To(ConsistOf(
+		"web.shop.svc",
+		"web.shop.svc.example.internal",
+	))
+}
+
+func TestSANResolver_HeadlessStatefulSetNames(t *testing.T) {
+	RegisterTestingT(t)
+
+	pod := newSANTestPod()
+	c := newSANTestClient(
+		pod,
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-headless", Namespace: "shop"},
+			Spec: corev1.ServiceSpec{
+				ClusterIP: corev1.ClusterIPNone,
+				Selector:  map[string]string{"app": "web"},
+			},
+		},
+	)
+
+	resolver := &SANResolver{Reader: c}
+	names, err := resolver.Resolve(context.Background(), pod)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(names).To(ConsistOf(
+		"web-headless.shop.svc",
+		"web-headless.shop.svc.cluster.local",
+		"web-0.web-headless.shop.svc",
+		"web-0.web-headless.shop.svc.cluster.local",
+	))
+}
+
+func TestLookupPod_UIDMismatch(t *testing.T) {
+	RegisterTestingT(t)
+
+	c := newSANTestClient(newSANTestPod())
+	pcr := &certificatesv1beta1.PodCertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "pcr", Namespace: "shop"},
+		Spec:       certificatesv1beta1.PodCertificateRequestSpec{PodName: "web-0", PodUID: "another-uid"},
+	}
+
+	_, err := lookupPod(context.Background(), c, pcr)
+	Expect(err).To(MatchError(ContainSubstring("has UID pod-uid")))
+	var lookupErr *podLookupError
+	Expect(errors.As(err, &lookupErr)).To(BeTrue())
+	Expect(lookupErr.Reason).To(Equal("PodUIDMismatch"))
+
+	pcr.Spec.PodName = "web-1"
+	_, err = lookupPod(context.Background(), c, pcr)
+	Expect(errors.As(err, &lookupErr)).To(BeTrue())
+	Expect(lookupErr.Reason).To(Equal("PodNotFound"))
+}
+
+func TestAppendUnique(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(appendUnique([]string{"a", "b"}, "b", "c", "a", "d")).To(Equal([]string{"a", "b", "c", "d"}))
+}
+
+func TestPodIPs_DualStack(t *testing.T) {
+	RegisterTestingT(t)
+
+	pod := newSANTestPod()
+	pod.Status.PodIP = "10.0.0.7"
+	pod.Status.PodIPs = []corev1.PodIP{{IP: "10.0.0.7"}, {IP: "fd00::7"}}
+
+	ips := podIPs(pod)
+	Expect(ips).To(HaveLen(2))
+	Expect(ips[0].String()).To(Equal("10.0.0.7"))
+	Expect(ips[1].String()).To(Equal("fd00::7"))
+}
+
+func TestPodIPs_FallsBackToPodIP(t *testing.T) {
+	RegisterTestingT(t)
+
+	pod := newSANTestPod()
+	pod.Status.PodIP = "10.0.0.8"
+	Expect(podIPs(pod)).To(HaveLen(1))
+
+	pod.Status.PodIP = ""
+	Expect(podIPs(pod)).To(BeEmpty())
+}
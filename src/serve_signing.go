@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	toolscache "k8s.io/client-go/tools/cache"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// serveSigningCommand runs the remote signer instead of the controller
+const serveSigningCommand = "serve-signing"
+
+// defaultSigningMaxValidity bounds the certificates serve-signing issues, like
+// the default maxExpirationSeconds of a PodCertificateRequest
+const defaultSigningMaxValidity = 24 * time.Hour
+
+// leafKeyUsages are the key usages the controller sets on leaves
+const leafKeyUsages = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+
+// SigningServer serves the signing protocol with the CA key held by a CAHelper,
+// so it can run apart from the controller, e.g. in a locked down namespace.
+type SigningServer struct {
+	CA *CAHelper
+	// LoadFailurePolicy is CALoadFailurePause to stop signing while the CA fails to reload
+	LoadFailurePolicy string
+	// MaxValidity bounds the requested validity; defaults to defaultSigningMaxValidity
+	MaxValidity time.Duration
+	// IssuanceMode and TrustDomain restrict the SANs like the controller's ISSUANCE_MODE
+	IssuanceMode string
+	TrustDomain  string
+}
+
+// SignLeaf signs the requested leaf, the same way the local backend does in the
+// controller, once it passes the server's own constraints
+func (s *SigningServer) SignLeaf(ctx context.Context, req *SignLeafRequest) (*SignLeafResponse, error) {
+	template, pub, err := req.template()
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	// A client holding a valid TLS certificate is not trusted with the CA key
+	if err := s.checkTemplate(template, time.Now()); err != nil {
+		log.FromContext(ctx).Info("WARN: Refused certificate", "serial", req.SerialNumber, "reason", err.Error(), "client", peerSubject(ctx))
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	chainPEM, err := (&LocalSigningBackend{CA: s.CA, LoadFailurePolicy: s.LoadFailurePolicy}).Issue(ctx, template, pub)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	log.FromContext(ctx).Info("Signed certificate", "serial", req.SerialNumber, "commonName", req.CommonName, "client", peerSubject(ctx))
+	return &SignLeafResponse{ChainPEM: string(chainPEM)}, nil
+}
+
+// checkTemplate enforces the validity, SANs and usages the controller would
+// have put in the template
+func (s *SigningServer) checkTemplate(template *x509.Certificate, now time.Time) error {
+	maxValidity := s.MaxValidity
+	if maxValidity <= 0 {
+		maxValidity = defaultSigningMaxValidity
+	}
+	if validity := template.NotAfter.Sub(template.NotBefore); validity > maxValidity {
+		return fmt.Errorf("validity %s exceeds the maximum of %s", validity, maxValidity)
+	}
+	if skew := template.NotBefore.Sub(now).Abs(); skew > caClockSkew {
+		return fmt.Errorf("notBefore %s is more than %s from now", template.NotBefore.UTC().Format(time.RFC3339), caClockSkew)
+	}
+
+	if template.KeyUsage&^leafKeyUsages != 0 {
+		return fmt.Errorf("key usage %d is not allowed on leaves, only digitalSignature and keyEncipherment", template.KeyUsage)
+	}
+	allowed := slices.Collect(maps.Values(extKeyUsageNames))
+	for _, usage := range template.ExtKeyUsage {
+		if !slices.Contains(allowed, usage) {
+			return fmt.Errorf("extended key usage %d is not allowed", usage)
+		}
+	}
+
+	if len(template.DNSNames)+len(template.IPAddresses)+len(template.URIs) == 0 {
+		return fmt.Errorf("certificate has no SANs")
+	}
+	for _, name := range template.DNSNames {
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return fmt.Errorf("invalid DNS SAN %q: %s", name, strings.Join(errs, "; "))
+		}
+	}
+	if s.IssuanceMode == IssuanceModeSPIFFE {
+		// X.509-SVID: exactly one SPIFFE ID in the trust domain and an empty subject
+		if len(template.URIs) != 1 || template.URIs[0].Scheme != "spiffe" || template.URIs[0].Host != s.TrustDomain {
+			return fmt.Errorf("an X.509-SVID must carry exactly one spiffe://%s URI SAN", s.TrustDomain)
+		}
+		if template.Subject.CommonName != "" {
+			return fmt.Errorf("an X.509-SVID must have an empty subject")
+		}
+	} else if cn := template.Subject.CommonName; cn != "" && !slices.Contains(template.DNSNames, cn) {
+		return fmt.Errorf("common name %q is not one of the DNS SANs", cn)
+	}
+	return nil
+}
+
+// GetCABundle returns the signing CA with its chain and the current trust anchors
+func (s *SigningServer) GetCABundle(_ context.Context, _ *GetCABundleRequest) (*GetCABundleResponse, error) {
+	ca := s.CA.snapshot()
+	if ca == nil {
+		return nil, status.Error(codes.Unavailable, "CA not loaded")
+	}
+	return &GetCABundleResponse{
+		CAPEM:           string(encodeCertificatesPEM(append([]*x509.Certificate{ca.cert}, ca.chain...))),
+		TrustAnchorsPEM: string(ca.trustAnchorsPEM()),
+	}, nil
+}
+
+// Health fails like the controller's "ca" readiness check
+func (s *SigningServer) Health(_ context.Context, _ *HealthRequest) (*HealthResponse, error) {
+	if err := caReadyzCheck(s.CA)(nil); err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return &HealthResponse{Status: "ok"}, nil
+}
+
+// peerSubject returns the subject of the client certificate the call was made with
+func peerSubject(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.PeerCertificates) == 0 {
+		return ""
+	}
+	return info.State.PeerCertificates[0].Subject.String()
+}
+
+// newSigningGRPCServer returns a gRPC server serving srv to clients with a certificate issued by SIGNING_TLS_CA
+func newSigningGRPCServer(config *Config, srv signingService) (*grpc.Server, error) {
+	tlsConfig, err := loadSigningTLSConfig(config, true)
+	if err != nil {
+		return nil, err
+	}
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsConfig)), grpc.ForceServerCodec(signingCodec{}))
+	server.RegisterService(&signingServiceDesc, srv)
+	return server, nil
+}
+
+// loadSigningServerCA loads the CA like the controller would: from the PKCS#11
+// token or KMS, from CA files, from the CA Secret through reader, or generated in memory
+func loadSigningServerCA(ctx context.Context, config *Config, reader client.Reader) (*CAHelper, error) {
+	switch {
+	case config.SigningBackend == SigningBackendPKCS11:
+		ca, err := newPKCS11CAFunc(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load CA from PKCS#11 token: %w", err)
+		}
+		return ca, nil
+	case config.SigningBackend == SigningBackendKMS:
+		ca, err := newKMSCAFunc(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load CA for KMS key: %w", err)
+		}
+		return ca, nil
+	case config.CACertFile != "":
+		ca, err := NewCAFromFiles(config.CACertFile, config.CAKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load CA from files: %w", err)
+		}
+		return ca, nil
+	case config.CASecretName != "":
+		ca, err := NewCAFromSecret(ctx, reader, config.CASecretName, config.CASecretNamespace, config.CACertKey, config.CAKeyKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load CA from secret: %w", err)
+		}
+		return ca, nil
+	default:
+		return newCAFunc(config.CAKeyAlgorithm)
+	}
+}
+
+// watchCASecret reloads the CA through reader whenever the informer sees the
+// CA Secret change, keeping the last loaded CA when a reload fails
+func watchCASecret(ctx context.Context, ca *CAHelper, informers cache.Informers, reader client.Reader, config *Config) error {
+	informer, err := informers.GetInformer(ctx, &corev1.Secret{})
+	if err != nil {
+		return fmt.Errorf("failed to watch CA secret: %w", err)
+	}
+	reload := func(any) {
+		if err := ca.LoadFromSecret(ctx, reader, config.CASecretName, config.CASecretNamespace, config.CACertKey, config.CAKeyKey); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to reload CA from secret, keeping the last loaded CA", "policy", config.CALoadFailurePolicy)
+			ca.setLoadError(err)
+			CALoadErrors.Inc()
+		}
+	}
+	_, err = informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    reload,
+		UpdateFunc: func(_, obj any) { reload(obj) },
+		DeleteFunc: reload,
+	})
+	return err
+}
+
+// runSigningServer runs `signer serve-signing` until the context is cancelled
+func runSigningServer(ctx context.Context, config *Config) error {
+	if config.IssuanceMode == IssuanceModeSPIFFE {
+		// Every SVID would be refused, so refuse to start instead
+		if err := validateTrustDomain(config.SPIFFETrustDomain); err != nil {
+			return fmt.Errorf("invalid SPIFFE trust domain: %w", err)
+		}
+	}
+
+	var reader client.Reader
+	var secrets cache.Cache
+	external := externalKeySigningBackend(config.SigningBackend)
+	if !external && config.CACertFile == "" && config.CASecretName != "" {
+		if err := validateCASecretNamespace(config); err != nil {
+			return err
+		}
+		restConfig := ctrl.GetConfigOrDie()
+		c, err := client.New(restConfig, client.Options{})
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		reader = c
+		secrets, err = cache.New(restConfig, cache.Options{
+			ByObject: map[client.Object]cache.ByObject{&corev1.Secret{}: caSecretCache(config)},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create CA secret cache: %w", err)
+		}
+	}
+
+	ca, err := loadSigningServerCA(ctx, config, reader)
+	if err != nil {
+		return err
+	}
+	if secrets != nil {
+		if err := watchCASecret(ctx, ca, secrets, secrets, config); err != nil {
+			return err
+		}
+		go func() {
+			if err := secrets.Start(ctx); err != nil {
+				log.FromContext(ctx).Error(err, "CA secret cache stopped")
+			}
+		}()
+	}
+	if !external && config.CACertFile != "" {
+		watcher := &CAFileWatcher{CA: ca, CertFile: config.CACertFile, KeyFile: config.CAKeyFile, LoadFailurePolicy: config.CALoadFailurePolicy}
+		go func() {
+			if err := watcher.Start(ctx); err != nil {
+				log.FromContext(ctx).Error(err, "CA file watcher stopped")
+			}
+		}()
+	}
+
+	server, err := newSigningGRPCServer(config, &SigningServer{
+		CA:                ca,
+		LoadFailurePolicy: config.CALoadFailurePolicy,
+		MaxValidity:       config.SigningMaxValidity,
+		IssuanceMode:      config.IssuanceMode,
+		TrustDomain:       config.SPIFFETrustDomain,
+	})
+	if err != nil {
+		return err
+	}
+	lis, err := net.Listen("tcp", config.SigningServerAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", config.SigningServerAddress, err)
+	}
+	go func() {
+		<-ctx.Done()
+		server.GracefulStop()
+	}()
+
+	log.FromContext(ctx).Info("Serving signing requests", "address", lis.Addr().String(), "subject", ca.GetCert().Subject.String())
+	return server.Serve(lis)
+}
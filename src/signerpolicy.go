@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// signerPolicyConditionValid is the SignerPolicy condition reporting spec errors
+const signerPolicyConditionValid = "Valid"
+
+// extKeyUsageNames maps the names accepted in SignerPolicy.Spec.ExtendedKeyUsages
+var extKeyUsageNames = map[string]x509.ExtKeyUsage{
+	"ServerAuth":      x509.ExtKeyUsageServerAuth,
+	"ClientAuth":      x509.ExtKeyUsageClientAuth,
+	"CodeSigning":     x509.ExtKeyUsageCodeSigning,
+	"EmailProtection": x509.ExtKeyUsageEmailProtection,
+	"TimeStamping":    x509.ExtKeyUsageTimeStamping,
+	"OCSPSigning":     x509.ExtKeyUsageOCSPSigning,
+}
+
+// sanTemplateData is the data available to SignerPolicy SAN templates
+type sanTemplateData struct {
+	PodName            string
+	PodUID             string
+	Namespace          string
+	ServiceAccountName string
+	NodeName           string
+	ClusterDomain      string
+}
+
+// matchSignerPolicy returns the highest-priority SignerPolicy selecting the
+// PCR's namespace and service account, or nil when none applies.
+func matchSignerPolicy(ctx context.Context, reader client.Reader, pcr *certificatesv1beta1.PodCertificateRequest) (*SignerPolicy, error) {
+	var policies SignerPolicyList
+	if err := reader.List(ctx, &policies); err != nil {
+		return nil, fmt.Errorf("failed to list SignerPolicies: %w", err)
+	}
+
+	items := policies.Items
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Spec.Priority != items[j].Spec.Priority {
+			return items[i].Spec.Priority > items[j].Spec.Priority
+		}
+		return items[i].Name < items[j].Name
+	})
+
+	var nsLabels labels.Set
+	for i := range items {
+		policy := &items[i]
+
+		if len(policy.Spec.ServiceAccountNames) > 0 && !slices.Contains(policy.Spec.ServiceAccountNames, pcr.Spec.ServiceAccountName) {
+			continue
+		}
+
+		if policy.Spec.NamespaceSelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(policy.Spec.NamespaceSelector)
+			if err != nil {
+				return nil, fmt.Errorf("SignerPolicy %s has an invalid namespaceSelector: %w", policy.Name, err)
+			}
+			if nsLabels == nil {
+				var ns corev1.Namespace
+				if err := reader.Get(ctx, types.NamespacedName{Name: pcr.Namespace}, &ns); err != nil {
+					return nil, fmt.Errorf("failed to get namespace %s: %w", pcr.Namespace, err)
+				}
+				nsLabels = labels.Set(ns.Labels)
+			}
+			if !selector.Matches(nsLabels) {
+				continue
+			}
+		}
+
+		return policy, nil
+	}
+
+	return nil, nil
+}
+
+// checkPublicKey verifies the key's algorithm and size against AllowedKeys
+func (s *SignerPolicySpec) checkPublicKey(pub crypto.PublicKey) error {
+	if len(s.AllowedKeys) == 0 {
+		return nil
+	}
+
+	algorithm, size, err := publicKeyInfo(pub)
+	if err != nil {
+		return err
+	}
+
+	for _, allowed := range s.AllowedKeys {
+		if !strings.EqualFold(allowed.Algorithm, algorithm) {
+			continue
+		}
+		if len(allowed.Sizes) == 0 || slices.Contains(allowed.Sizes, size) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s-%d keys are not allowed", algorithm, size)
+}
+
+// validate checks what the CRD schema cannot: the namespace selector, the
+// extended key usages and the SAN templates, rendered with placeholder values.
+func (s *SignerPolicySpec) validate() error {
+	if s.NamespaceSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(s.NamespaceSelector); err != nil {
+			return fmt.Errorf("invalid namespaceSelector: %w", err)
+		}
+	}
+	if _, err := s.extKeyUsages(); err != nil {
+		return err
+	}
+	_, _, err := s.renderSANs(sanTemplateData{
+		PodName:            "pod",
+		PodUID:             "uid",
+		Namespace:          "namespace",
+		ServiceAccountName: "serviceaccount",
+		NodeName:           "node",
+		ClusterDomain:      defaultClusterDomain,
+	})
+	return err
+}
+
+// extKeyUsages returns the configured extended key usages, or nil to keep the defaults
+func (s *SignerPolicySpec) extKeyUsages() ([]x509.ExtKeyUsage, error) {
+	if len(s.ExtendedKeyUsages) == 0 {
+		return nil, nil
+	}
+	usages := make([]x509.ExtKeyUsage, 0, len(s.ExtendedKeyUsages))
+	for _, name := range s.ExtendedKeyUsages {
+		usage, ok := extKeyUsageNames[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown extended key usage %q", name)
+		}
+		usages = append(usages, usage)
+	}
+	return usages, nil
+}
+
+// renderSANs renders the DNS name and URI templates for a request
+func (s *SignerPolicySpec) renderSANs(data sanTemplateData) ([]string, []*url.URL, error) {
+	if s.SANs == nil {
+		return nil, nil, nil
+	}
+
+	var dnsNames []string
+	for _, tmpl := range s.SANs.DNSNames {
+		name, err := renderSANTemplate(tmpl, data)
+		if err != nil {
+			return nil, nil, err
+		}
+		if name != "" {
+			dnsNames = append(dnsNames, name)
+		}
+	}
+
+	var uris []*url.URL
+	for _, tmpl := range s.SANs.URIs {
+		raw, err := renderSANTemplate(tmpl, data)
+		if err != nil {
+			return nil, nil, err
+		}
+		if raw == "" {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("SAN template %q rendered an invalid URI: %w", tmpl, err)
+		}
+		uris = append(uris, u)
+	}
+
+	return dnsNames, uris, nil
+}
+
+func renderSANTemplate(tmpl string, data sanTemplateData) (string, error) {
+	t, err := template.New("san").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid SAN template %q: %w", tmpl, err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render SAN template %q: %w", tmpl, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// SignerPolicyReconciler sets the Valid condition of every SignerPolicy, so a
+// broken policy shows up on the policy instead of on the requests it matches
+type SignerPolicyReconciler struct {
+	client.Client
+}
+
+func (r *SignerPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var policy SignerPolicy
+	if err := r.Get(ctx, req.NamespacedName, &policy); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	condition := metav1.Condition{
+		Type:               signerPolicyConditionValid,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Valid",
+		Message:            "Policy is valid",
+		ObservedGeneration: policy.Generation,
+	}
+	if err := policy.Spec.validate(); err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "InvalidSpec"
+		condition.Message = err.Error()
+	}
+	if !meta.SetStatusCondition(&policy.Status.Conditions, condition) {
+		return ctrl.Result{}, nil
+	}
+	if err := r.Status().Update(ctx, &policy); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update SignerPolicy %s status: %w", policy.Name, err)
+	}
+	return ctrl.Result{}, nil
+}
+
+func (r *SignerPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("signer-policy").
+		For(&SignerPolicy{}).
+		Complete(r)
+}
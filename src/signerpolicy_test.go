@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newPolicyTestClient(objs ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = AddSignerPolicyToScheme(scheme)
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
+func newPolicyTestPCR(namespace, serviceAccount string) *certificatesv1beta1.PodCertificateRequest {
+	return &certificatesv1beta1.PodCertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "pcr", Namespace: namespace},
+		Spec: certificatesv1beta1.PodCertificateRequestSpec{
+			PodName:            "app-0",
+			PodUID:             "pod-uid",
+			NodeName:           "node-1",
+			ServiceAccountName: serviceAccount,
+		},
+	}
+}
+
+func TestMatchSignerPolicy_SelectsByNamespaceAndServiceAccount(t *testing.T) {
+	RegisterTestingT(t)
+
+	c := newPolicyTestClient(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "mesh", Labels: map[string]string{"mesh": "enabled"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "plain"}},
+		&SignerPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "mesh-only"},
+			Spec: SignerPolicySpec{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"mesh": "enabled"}},
+			},
+		},
+		&SignerPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-sa"},
+			Spec:       SignerPolicySpec{ServiceAccountNames: []string{"api"}},
+		},
+	)
+
+	policy, err := matchSignerPolicy(context.Background(), c, newPolicyTestPCR("mesh", "default"))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(policy).NotTo(BeNil())
+	Expect(policy.Name).To(Equal("mesh-only"))
+
+	policy, err = matchSignerPolicy(context.Background(), c, newPolicyTestPCR("plain", "api"))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(policy).NotTo(BeNil())
+	Expect(policy.Name).To(Equal("api-sa"))
+
+	policy, err = matchSignerPolicy(context.Background(), c, newPolicyTestPCR("plain", "default"))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(policy).To(BeNil())
+}
+
+func TestMatchSignerPolicy_HighestPriorityWins(t *testing.T) {
+	RegisterTestingT(t)
+
+	c := newPolicyTestClient(
+		&SignerPolicy{ObjectMeta: metav1.ObjectMeta{Name: "b-low"}, Spec: SignerPolicySpec{Priority: 1}},
+		&SignerPolicy{ObjectMeta: metav1.ObjectMeta{Name: "c-high"}, Spec: SignerPolicySpec{Priority: 10}},
+		&SignerPolicy{ObjectMeta: metav1.ObjectMeta{Name: "a-high"}, Spec: SignerPolicySpec{Priority: 10}},
+	)
+
+	policy, err := matchSignerPolicy(context.Background(), c, newPolicyTestPCR("default", "default"))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(policy.Name).To(Equal("a-high"))
+}
+
+func TestSignerPolicy_CheckPublicKey(t *testing.T) {
+	RegisterTestingT(t)
+
+	rsa2048, err := rsa.GenerateKey(rand.Reader, 2048)
+	Expect(err).NotTo(HaveOccurred())
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	spec := SignerPolicySpec{
+		AllowedKeys: []AllowedKey{
+			{Algorithm: "RSA", Sizes: []int32{3072, 4096}},
+			{Algorithm: "ECDSA", Sizes: []int32{384}},
+		},
+	}
+
+	Expect(spec.checkPublicKey(&rsa2048.PublicKey)).To(MatchError(ContainSubstring("RSA-2048 keys are not allowed")))
+	Expect(spec.checkPublicKey(&p256.PublicKey)).To(MatchError(ContainSubstring("ECDSA-256 keys are not allowed")))
+	Expect(spec.checkPublicKey(&p384.PublicKey)).To(Succeed())
+
+	Expect((&SignerPolicySpec{}).checkPublicKey(&rsa2048.PublicKey)).To(Succeed())
+}
+
+func TestSignerPolicy_ExtKeyUsages(t *testing.T) {
+	RegisterTestingT(t)
+
+	usages, err := (&SignerPolicySpec{}).extKeyUsages()
+	Expect(err).NotTo(HaveOccurred())
+	Expect(usages).To(BeNil())
+
+	usages, err = (&SignerPolicySpec{ExtendedKeyUsages: []string{"ClientAuth"}}).extKeyUsages()
+	Expect(err).NotTo(HaveOccurred())
+	Expect(usages).To(Equal([]x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}))
+
+	_, err = (&SignerPolicySpec{ExtendedKeyUsages: []string{"Bogus"}}).extKeyUsages()
+	Expect(err).To(MatchError(ContainSubstring("unknown extended key usage")))
+}
+
+func TestSignerPolicy_RenderSANs(t *testing.T) {
+	RegisterTestingT(t)
+
+	spec := SignerPolicySpec{
+		SANs: &SANTemplates{
+			DNSNames: []string{"{{ .PodName }}.{{ .Namespace }}.pods.{{ .ClusterDomain }}"},
+			URIs:     []string{"urn:workload:{{ .Namespace }}:{{ .ServiceAccountName }}"},
+		},
+	}
+	dnsNames, uris, err := spec.renderSANs(sanTemplateData{
+		PodName:            "app-0",
+		Namespace:          "shop",
+		ServiceAccountName: "api",
+		ClusterDomain:      "cluster.local",
+	})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(dnsNames).To(Equal([]string{"app-0.shop.pods.cluster.local"}))
+	Expect(uris).To(HaveLen(1))
+	Expect(uris[0].String()).To(Equal("urn:workload:shop:api"))
+
+	_, _, err = (&SignerPolicySpec{SANs: &SANTemplates{DNSNames: []string{"{{ .Unknown }}"}}}).renderSANs(sanTemplateData{})
+	Expect(err).To(HaveOccurred())
+}
+
+func TestSignerPolicy_DeepCopy(t *testing.T) {
+	RegisterTestingT(t)
+
+	orig := &SignerPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "p"},
+		Spec: SignerPolicySpec{
+			ServiceAccountNames: []string{"api"},
+			AllowedKeys:         []AllowedKey{{Algorithm: "RSA", Sizes: []int32{3072}}},
+			SANs:                &SANTemplates{DNSNames: []string{"a"}},
+		},
+	}
+	copied := orig.DeepCopy()
+	copied.Spec.ServiceAccountNames[0] = "changed"
+	copied.Spec.AllowedKeys[0].Sizes[0] = 4096
+	copied.Spec.SANs.DNSNames[0] = "b"
+
+	Expect(orig.Spec.ServiceAccountNames[0]).To(Equal("api"))
+	Expect(orig.Spec.AllowedKeys[0].Sizes[0]).To(Equal(int32(3072)))
+	Expect(orig.Spec.SANs.DNSNames[0]).To(Equal("a"))
+}
+
+func TestSignerPolicy_Validate(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect((&SignerPolicySpec{}).validate()).To(Succeed())
+	Expect((&SignerPolicySpec{SANs: &SANTemplates{DNSNames: []string{"{{ .PodName }}.{{ .Namespace }}"}}}).validate()).To(Succeed())
+
+	Expect((&SignerPolicySpec{SANs: &SANTemplates{DNSNames: []string{"{{ .PodNam }}"}}}).validate()).To(MatchError(ContainSubstring("failed to render SAN template")))
+	Expect((&SignerPolicySpec{SANs: &SANTemplates{URIs: []string{"{{ .PodName"}}}).validate()).To(MatchError(ContainSubstring("invalid SAN template")))
+	Expect((&SignerPolicySpec{ExtendedKeyUsages: []string{"Bogus"}}).validate()).To(MatchError(ContainSubstring("unknown extended key usage")))
+	Expect((&SignerPolicySpec{NamespaceSelector: &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "mesh", Operator: "Bogus"}},
+	}}).validate()).To(MatchError(ContainSubstring("invalid namespaceSelector")))
+}
+
+func TestSignerPolicyReconciler_SetsValidCondition(t *testing.T) {
+	RegisterTestingT(t)
+
+	scheme := runtime.NewScheme()
+	_ = AddSignerPolicyToScheme(scheme)
+	policy := &SignerPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "typo", Generation: 1},
+		Spec:       SignerPolicySpec{SANs: &SANTemplates{DNSNames: []string{"{{ .PodNam }}"}}},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(policy).WithStatusSubresource(policy).Build()
+	r := &SignerPolicyReconciler{Client: c}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "typo"}}
+
+	_, err := r.Reconcile(context.Background(), req)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(c.Get(context.Background(), req.NamespacedName, policy)).To(Succeed())
+	condition := meta.FindStatusCondition(policy.Status.Conditions, signerPolicyConditionValid)
+	Expect(condition).NotTo(BeNil())
+	Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+	Expect(condition.Reason).To(Equal("InvalidSpec"))
+	Expect(condition.Message).To(ContainSubstring("PodNam"))
+
+	policy.Spec.SANs.DNSNames = []string{"{{ .PodName }}"}
+	Expect(c.Update(context.Background(), policy)).To(Succeed())
+	_, err = r.Reconcile(context.Background(), req)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(c.Get(context.Background(), req.NamespacedName, policy)).To(Succeed())
+	Expect(meta.IsStatusConditionTrue(policy.Status.Conditions, signerPolicyConditionValid)).To(BeTrue())
+}
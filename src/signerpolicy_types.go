@@ -0,0 +1,196 @@
+package main
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// SignerPolicyGroupVersion is the API group and version of the SignerPolicy custom resource
+var SignerPolicyGroupVersion = schema.GroupVersion{Group: "signer.novog93.ghcr", Version: "v1alpha1"}
+
+var (
+	signerPolicySchemeBuilder = runtime.NewSchemeBuilder(addSignerPolicyTypes)
+	// AddSignerPolicyToScheme registers the SignerPolicy types with a scheme
+	AddSignerPolicyToScheme = signerPolicySchemeBuilder.AddToScheme
+)
+
+func addSignerPolicyTypes(scheme *runtime.Scheme) error {
+	scheme.AddKnownTypes(SignerPolicyGroupVersion, &SignerPolicy{}, &SignerPolicyList{})
+	metav1.AddToGroupVersion(scheme, SignerPolicyGroupVersion)
+	return nil
+}
+
+// SignerPolicy is a cluster-scoped set of issuance rules applied to the
+// PodCertificateRequests of the namespaces and service accounts it selects.
+type SignerPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SignerPolicySpec   `json:"spec"`
+	Status SignerPolicyStatus `json:"status,omitempty"`
+}
+
+// SignerPolicySpec defines which requests a policy applies to and how they are issued
+type SignerPolicySpec struct {
+	// Priority decides between several matching policies; the highest wins,
+	// ties are broken by policy name.
+	Priority int32 `json:"priority,omitempty"`
+
+	// NamespaceSelector selects namespaces by label. Empty selects all namespaces.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// ServiceAccountNames restricts the policy to these service accounts. Empty selects all.
+	ServiceAccountNames []string `json:"serviceAccountNames,omitempty"`
+
+	// Validity overrides the certificate lifetime (minimum 1h).
+	Validity *metav1.Duration `json:"validity,omitempty"`
+
+	// RefreshBefore overrides how long before expiry kubelet starts refreshing (minimum 30m).
+	RefreshBefore *metav1.Duration `json:"refreshBefore,omitempty"`
+
+	// AllowedKeys lists the permitted public key algorithms and sizes. Empty allows all supported keys.
+	AllowedKeys []AllowedKey `json:"allowedKeys,omitempty"`
+
+	// SANs holds templates for additional subject alternative names.
+	SANs *SANTemplates `json:"sans,omitempty"`
+
+	// ExtendedKeyUsages replaces the default ClientAuth and ServerAuth usages.
+	ExtendedKeyUsages []string `json:"extendedKeyUsages,omitempty"`
+}
+
+// AllowedKey permits one public key algorithm, optionally limited to specific sizes
+type AllowedKey struct {
+	// Algorithm is RSA or ECDSA.
+	Algorithm string `json:"algorithm"`
+	// Sizes are RSA modulus sizes or ECDSA curve sizes in bits. Empty allows any size.
+	Sizes []int32 `json:"sizes,omitempty"`
+}
+
+// SANTemplates are Go text/template strings rendered per request. Available
+// fields: .PodName, .PodUID, .Namespace, .ServiceAccountName, .NodeName, .ClusterDomain.
+type SANTemplates struct {
+	DNSNames []string `json:"dnsNames,omitempty"`
+	URIs     []string `json:"uris,omitempty"`
+}
+
+// SignerPolicyStatus reports whether the policy can be applied
+type SignerPolicyStatus struct {
+	// Conditions holds the Valid condition, false with the reason when the spec
+	// has errors the schema cannot catch, such as a broken SAN template.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// SignerPolicyList is a list of SignerPolicy objects
+type SignerPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []SignerPolicy `json:"items"`
+}
+
+// DeepCopyInto copies the receiver into out
+func (in *SignerPolicy) DeepCopyInto(out *SignerPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy returns a deep copy of the SignerPolicy
+func (in *SignerPolicy) DeepCopy() *SignerPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(SignerPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject implements runtime.Object
+func (in *SignerPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto copies the receiver into out
+func (in *SignerPolicySpec) DeepCopyInto(out *SignerPolicySpec) {
+	*out = *in
+	if in.NamespaceSelector != nil {
+		out.NamespaceSelector = in.NamespaceSelector.DeepCopy()
+	}
+	if in.ServiceAccountNames != nil {
+		out.ServiceAccountNames = append([]string(nil), in.ServiceAccountNames...)
+	}
+	if in.Validity != nil {
+		v := *in.Validity
+		out.Validity = &v
+	}
+	if in.RefreshBefore != nil {
+		v := *in.RefreshBefore
+		out.RefreshBefore = &v
+	}
+	if in.AllowedKeys != nil {
+		out.AllowedKeys = make([]AllowedKey, len(in.AllowedKeys))
+		for i := range in.AllowedKeys {
+			out.AllowedKeys[i] = AllowedKey{
+				Algorithm: in.AllowedKeys[i].Algorithm,
+				Sizes:     append([]int32(nil), in.AllowedKeys[i].Sizes...),
+			}
+		}
+	}
+	if in.SANs != nil {
+		out.SANs = &SANTemplates{
+			DNSNames: append([]string(nil), in.SANs.DNSNames...),
+			URIs:     append([]string(nil), in.SANs.URIs...),
+		}
+	}
+	if in.ExtendedKeyUsages != nil {
+		out.ExtendedKeyUsages = append([]string(nil), in.ExtendedKeyUsages...)
+	}
+}
+
+// DeepCopyInto copies the receiver into out
+func (in *SignerPolicyStatus) DeepCopyInto(out *SignerPolicyStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		out.Conditions = make([]metav1.Condition, len(in.Conditions))
+		for i := range in.Conditions {
+			in.Conditions[i].DeepCopyInto(&out.Conditions[i])
+		}
+	}
+}
+
+// DeepCopyInto copies the receiver into out
+func (in *SignerPolicyList) DeepCopyInto(out *SignerPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		out.Items = make([]SignerPolicy, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of the SignerPolicyList
+func (in *SignerPolicyList) DeepCopy() *SignerPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(SignerPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject implements runtime.Object
+func (in *SignerPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
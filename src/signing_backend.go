@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+)
+
+// minCertValidity is the shortest certificate validity, per the Kubernetes PCR API
+const minCertValidity = time.Hour
+
+// Signing backends, selected by SIGNING_BACKEND
+const (
+	// SigningBackendLocal signs in process with the in-memory or Secret-loaded CA key
+	SigningBackendLocal = "local"
+	// SigningBackendPKCS11 signs in process with a CA key held by a PKCS#11 token
+	SigningBackendPKCS11 = "pkcs11"
+	// SigningBackendKMS signs in process through a plugin that has a KMS sign with the CA key
+	SigningBackendKMS = "kms"
+	// SigningBackendVault has certificates signed by a Vault PKI secrets engine
+	SigningBackendVault = "vault"
+	// SigningBackendGRPC has certificates signed by a `signer serve-signing` process over mutual TLS
+	SigningBackendGRPC = "grpc"
+)
+
+// externalKeySigningBackend reports whether the named backend signs in process
+// with a CA key that stays in an HSM or KMS, so the CA Secret is not used
+func externalKeySigningBackend(name string) bool {
+	return name == SigningBackendPKCS11 || name == SigningBackendKMS
+}
+
+// keylessSigningBackend reports whether the named backend signs outside the
+// controller, which then only learns the issuing CA from the backend
+func keylessSigningBackend(name string) bool {
+	return name == SigningBackendVault || name == SigningBackendGRPC
+}
+
+// SigningBackend issues leaf certificates for the SignerReconciler, which
+// builds the template and never touches the CA key itself. Implementations
+// may sign in process or delegate to an HSM, Vault, a KMS or a remote signer.
+type SigningBackend interface {
+	// Issuer returns the CA certificate leaves are issued under, or nil while it is unavailable
+	Issuer() *x509.Certificate
+	// Issue signs template for pub and returns the PEM chain: the leaf followed by its intermediates.
+	// The leaf's validity may differ from the template's, e.g. NotAfter is clamped to the CA's and
+	// Vault backdates NotBefore; the request's status times are always taken from the returned leaf.
+	Issue(ctx context.Context, template *x509.Certificate, pub crypto.PublicKey) ([]byte, error)
+}
+
+// newSigningBackend returns the backend named by config.SigningBackend
+func newSigningBackend(config *Config, ca *CAHelper) (SigningBackend, error) {
+	switch config.SigningBackend {
+	case "", SigningBackendLocal, SigningBackendPKCS11, SigningBackendKMS:
+		// With PKCS#11 or a KMS the CAHelper's key is a signer backed by the token or the plugin
+		return &LocalSigningBackend{CA: ca, LoadFailurePolicy: config.CALoadFailurePolicy}, nil
+	case SigningBackendVault:
+		return newVaultSigningBackend(config, ca)
+	case SigningBackendGRPC:
+		return newRemoteSigningBackend(config, ca)
+	default:
+		return nil, fmt.Errorf("unknown signing backend %q", config.SigningBackend)
+	}
+}
+
+// LocalSigningBackend signs with the CA key held by a CAHelper
+type LocalSigningBackend struct {
+	CA *CAHelper
+	// LoadFailurePolicy is CALoadFailurePause to stop signing while the CA fails to reload
+	LoadFailurePolicy string
+}
+
+// Issuer returns the CAHelper's current certificate
+func (b *LocalSigningBackend) Issuer() *x509.Certificate {
+	if b.CA == nil {
+		return nil
+	}
+	return b.CA.GetCert()
+}
+
+// Issue signs template with the CA key and appends the CA's intermediates.
+// NotAfter is clamped to the expiry of the CA it signs with.
+func (b *LocalSigningBackend) Issue(_ context.Context, template *x509.Certificate, pub crypto.PublicKey) ([]byte, error) {
+	if b.CA == nil {
+		return nil, fmt.Errorf("CA not initialized")
+	}
+	ca := b.CA.snapshot()
+	if ca == nil {
+		return nil, fmt.Errorf("CA not initialized")
+	}
+
+	// With the pause policy, never sign with a CA the Secret no longer holds
+	if loadErr := b.CA.LoadError(); loadErr != nil && b.LoadFailurePolicy == CALoadFailurePause {
+		return nil, fmt.Errorf("issuance paused, CA failed to load: %w", loadErr)
+	}
+
+	// Never issue certificates that outlive the CA. The check uses the same
+	// snapshot as the signature, so a reload in between cannot slip past it.
+	if ca.cert.NotAfter.Before(template.NotAfter) {
+		if ca.cert.NotAfter.Sub(template.NotBefore) < minCertValidity {
+			return nil, fmt.Errorf("CA expires at %s, within the minimum certificate validity of %s", ca.cert.NotAfter.UTC().Format(time.RFC3339), minCertValidity)
+		}
+		clamped := *template
+		clamped.NotAfter = ca.cert.NotAfter
+		template = &clamped
+	}
+
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, ca.cert, pub, ca.key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Encode to PEM, followed by the intermediates so clients can build a path to the root
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certBytes})
+	return append(certPEM, ca.chainPEM...), nil
+}
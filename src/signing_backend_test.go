@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// recordingBackend signs with a LocalSigningBackend and records the templates it was given
+type recordingBackend struct {
+	LocalSigningBackend
+	templates []*x509.Certificate
+}
+
+func (b *recordingBackend) Issue(ctx context.Context, template *x509.Certificate, pub crypto.PublicKey) ([]byte, error) {
+	b.templates = append(b.templates, template)
+	return b.LocalSigningBackend.Issue(ctx, template, pub)
+}
+
+// clampingBackend issues like Vault does: backdated and clamped to maxTTL, whatever the template says
+type clampingBackend struct {
+	LocalSigningBackend
+	maxTTL time.Duration
+}
+
+func (b *clampingBackend) Issue(ctx context.Context, template *x509.Certificate, pub crypto.PublicKey) ([]byte, error) {
+	issued := *template
+	issued.NotBefore = time.Now().Add(-30 * time.Second)
+	issued.NotAfter = time.Now().Add(b.maxTTL)
+	return b.LocalSigningBackend.Issue(ctx, &issued, pub)
+}
+
+func TestNewSigningBackend(t *testing.T) {
+	RegisterTestingT(t)
+
+	ca, err := NewCA()
+	Expect(err).NotTo(HaveOccurred())
+
+	backend, err := newSigningBackend(&Config{CALoadFailurePolicy: CALoadFailurePause}, ca)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(backend).To(Equal(&LocalSigningBackend{CA: ca, LoadFailurePolicy: CALoadFailurePause}))
+
+	_, err = newSigningBackend(&Config{SigningBackend: "carrier-pigeon"}, ca)
+	Expect(err).To(MatchError(ContainSubstring(`unknown signing backend "carrier-pigeon"`)))
+}
+
+func TestLocalSigningBackend_Issue(t *testing.T) {
+	RegisterTestingT(t)
+
+	ca, err := NewCA()
+	Expect(err).NotTo(HaveOccurred())
+	backend := &LocalSigningBackend{CA: ca}
+	Expect(backend.Issuer()).To(Equal(ca.GetCert()))
+
+	_, key, err := generateTestPublicKeyDER()
+	Expect(err).NotTo(HaveOccurred())
+	pub := key.Public()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"app.pod.cluster.local"},
+	}
+
+	chainPEM, err := backend.Issue(context.Background(), template, pub)
+	Expect(err).NotTo(HaveOccurred())
+	certs, err := parseCertificatesPEM(chainPEM)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(certs).To(HaveLen(1))
+	Expect(certs[0].CheckSignatureFrom(ca.GetCert())).To(Succeed())
+
+	// The pause policy refuses to sign with a CA that failed to reload
+	ca.setLoadError(fmt.Errorf("CA secret deleted"))
+	_, err = (&LocalSigningBackend{CA: ca, LoadFailurePolicy: CALoadFailurePause}).Issue(context.Background(), template, pub)
+	Expect(err).To(MatchError(ContainSubstring("issuance paused")))
+
+	Expect((&LocalSigningBackend{}).Issuer()).To(BeNil())
+	_, err = (&LocalSigningBackend{}).Issue(context.Background(), template, pub)
+	Expect(err).To(MatchError(ContainSubstring("CA not initialized")))
+}
+
+func TestLocalSigningBackend_IssueDuringReload(t *testing.T) {
+	RegisterTestingT(t)
+
+	first, err := NewCA()
+	Expect(err).NotTo(HaveOccurred())
+	second, err := NewCA()
+	Expect(err).NotTo(HaveOccurred())
+	ca := &CAHelper{}
+	ca.set(&first.snapshot().caKeyPair, nil)
+	backend := &LocalSigningBackend{CA: ca}
+
+	_, key, err := generateTestPublicKeyDER()
+	Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	// Issuing never pairs the certificate of one CA with the key of the other
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			next := first
+			if i%2 == 0 {
+				next = second
+			}
+			ca.set(&next.snapshot().caKeyPair, nil)
+		}
+	}()
+	for range 200 {
+		_, err := backend.Issue(context.Background(), template, key.Public())
+		Expect(err).NotTo(HaveOccurred())
+	}
+}
+
+func TestLocalSigningBackend_IssueClampsToSigningCA(t *testing.T) {
+	RegisterTestingT(t)
+
+	long, err := NewCA()
+	Expect(err).NotTo(HaveOccurred())
+	short := newExpiringTestCA(time.Now().Add(24 * time.Hour))
+	ca := &CAHelper{}
+	ca.set(&long.snapshot().caKeyPair, nil)
+	backend := &LocalSigningBackend{CA: ca}
+
+	_, key, err := generateTestPublicKeyDER()
+	Expect(err).NotTo(HaveOccurred())
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    now,
+		NotAfter:     now.Add(48 * time.Hour),
+	}
+
+	// Every leaf is clamped to the CA that signed it, even while the CA flips
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			next := long
+			if i%2 == 0 {
+				next = short
+			}
+			ca.set(&next.snapshot().caKeyPair, nil)
+		}
+	}()
+	for range 200 {
+		chainPEM, err := backend.Issue(context.Background(), template, key.Public())
+		Expect(err).NotTo(HaveOccurred())
+		certs, err := parseCertificatesPEM(chainPEM)
+		Expect(err).NotTo(HaveOccurred())
+		if certs[0].CheckSignatureFrom(short.GetCert()) == nil {
+			Expect(certs[0].NotAfter).To(BeTemporally("==", short.GetCert().NotAfter))
+		} else {
+			Expect(certs[0].NotAfter).To(BeTemporally("~", template.NotAfter, time.Second))
+		}
+	}
+	// The caller's template is left alone
+	Expect(template.NotAfter).To(Equal(now.Add(48 * time.Hour)))
+}
+
+func TestSignerReconciler_UsesSigningBackend(t *testing.T) {
+	RegisterTestingT(t)
+
+	ca, err := NewCA()
+	Expect(err).NotTo(HaveOccurred())
+	pubKey, _, err := generateTestPublicKeyDER()
+	Expect(err).NotTo(HaveOccurred())
+	pcr := &certificatesv1beta1.PodCertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "backend-pcr", Namespace: "default"},
+		Spec: certificatesv1beta1.PodCertificateRequestSpec{
+			SignerName:         "novog93.ghcr/signer",
+			PodName:            "app-0",
+			PodUID:             "pod-uid",
+			PKIXPublicKey:      pubKey,
+			NodeName:           "node1",
+			NodeUID:            "node-uid",
+			ServiceAccountName: "sa",
+			ServiceAccountUID:  "sa-uid",
+			ProofOfPossession:  []byte("pop"),
+		},
+	}
+	backend := &recordingBackend{LocalSigningBackend: LocalSigningBackend{CA: ca}}
+	reconciler := &SignerReconciler{
+		Client:     fake.NewClientBuilder().WithScheme(scheme).WithObjects(pcr).WithStatusSubresource(pcr).Build(),
+		SignerName: "novog93.ghcr/signer",
+		Backend:    backend,
+	}
+
+	_, err = reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(backend.templates).To(HaveLen(1))
+	Expect(backend.templates[0].DNSNames).To(ConsistOf("app-0.default.pod.cluster.local"))
+
+	retrieved := &certificatesv1beta1.PodCertificateRequest{}
+	Expect(reconciler.Get(context.Background(), types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}, retrieved)).To(Succeed())
+	Expect(retrieved.Status.CertificateChain).NotTo(BeEmpty())
+}
+
+func TestSignerReconciler_StatusTimesFromIssuedLeaf(t *testing.T) {
+	RegisterTestingT(t)
+
+	ca, err := NewCA()
+	Expect(err).NotTo(HaveOccurred())
+	pubKey, _, err := generateTestPublicKeyDER()
+	Expect(err).NotTo(HaveOccurred())
+	pcr := &certificatesv1beta1.PodCertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "clamped-pcr", Namespace: "default"},
+		Spec: certificatesv1beta1.PodCertificateRequestSpec{
+			SignerName:         "novog93.ghcr/signer",
+			PodName:            "app-0",
+			PodUID:             "pod-uid",
+			PKIXPublicKey:      pubKey,
+			NodeName:           "node1",
+			NodeUID:            "node-uid",
+			ServiceAccountName: "sa",
+			ServiceAccountUID:  "sa-uid",
+			ProofOfPossession:  []byte("pop"),
+		},
+	}
+	reconciler := &SignerReconciler{
+		Client:     fake.NewClientBuilder().WithScheme(scheme).WithObjects(pcr).WithStatusSubresource(pcr).Build(),
+		SignerName: "novog93.ghcr/signer",
+		Backend:    &clampingBackend{LocalSigningBackend: LocalSigningBackend{CA: ca}, maxTTL: 2 * time.Hour},
+	}
+
+	_, err = reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}})
+	Expect(err).NotTo(HaveOccurred())
+
+	retrieved := &certificatesv1beta1.PodCertificateRequest{}
+	Expect(reconciler.Get(context.Background(), types.NamespacedName{Name: pcr.Name, Namespace: pcr.Namespace}, retrieved)).To(Succeed())
+	chain, err := parseCertificatesPEM([]byte(retrieved.Status.CertificateChain))
+	Expect(err).NotTo(HaveOccurred())
+	leaf := chain[0]
+	Expect(leaf.NotAfter.Sub(leaf.NotBefore)).To(BeNumerically("<", 24*time.Hour))
+	Expect(retrieved.Status.NotBefore.Time).To(BeTemporally("==", leaf.NotBefore))
+	Expect(retrieved.Status.NotAfter.Time).To(BeTemporally("==", leaf.NotAfter))
+	Expect(retrieved.Status.BeginRefreshAt.Time).To(BeTemporally("==", leaf.NotAfter.Add(-30*time.Minute)))
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	// IssuanceModePodDNS issues certificates with a <podName>.<namespace>.pod.cluster.local DNS SAN and CommonName
+	IssuanceModePodDNS = "pod-dns"
+	// IssuanceModeSPIFFE issues X.509-SVIDs carrying a single SPIFFE ID URI SAN and an empty subject
+	IssuanceModeSPIFFE = "spiffe"
+)
+
+// spiffeID builds the SPIFFE ID for a workload in the form
+// spiffe://<trust-domain>/ns/<namespace>/sa/<serviceAccountName>.
+func spiffeID(trustDomain, namespace, serviceAccount string) (*url.URL, error) {
+	if err := validateTrustDomain(trustDomain); err != nil {
+		return nil, err
+	}
+	if namespace == "" {
+		return nil, fmt.Errorf("namespace must not be empty")
+	}
+	if serviceAccount == "" {
+		return nil, fmt.Errorf("service account name must not be empty")
+	}
+
+	return &url.URL{
+		Scheme: "spiffe",
+		Host:   trustDomain,
+		Path:   fmt.Sprintf("/ns/%s/sa/%s", namespace, serviceAccount),
+	}, nil
+}
+
+// validateTrustDomain checks the trust domain against the SPIFFE ID specification:
+// lowercase letters, digits, dots, dashes and underscores only.
+func validateTrustDomain(trustDomain string) error {
+	if trustDomain == "" {
+		return fmt.Errorf("trust domain must not be empty")
+	}
+	if len(trustDomain) > 255 {
+		return fmt.Errorf("trust domain %q exceeds 255 characters", trustDomain)
+	}
+	for _, ch := range trustDomain {
+		switch {
+		case ch >= 'a' && ch <= 'z':
+		case ch >= '0' && ch <= '9':
+		case strings.ContainsRune(".-_", ch):
+		default:
+			return fmt.Errorf("trust domain %q contains invalid character %q", trustDomain, ch)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSPIFFEID_Format(t *testing.T) {
+	RegisterTestingT(t)
+
+	id, err := spiffeID("example.org", "payments", "api")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(id.String()).To(Equal("spiffe://example.org/ns/payments/sa/api"))
+}
+
+func TestSPIFFEID_RejectsInvalidTrustDomain(t *testing.T) {
+	RegisterTestingT(t)
+
+	for _, td := range []string{"", "Example.org", "example.org:8443", "exa mple"} {
+		_, err := spiffeID(td, "default", "default")
+		Expect(err).To(HaveOccurred(), "trust domain %q should be rejected", td)
+	}
+}
+
+func TestSPIFFEID_RequiresNamespaceAndServiceAccount(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := spiffeID("cluster.local", "", "default")
+	Expect(err).To(MatchError(ContainSubstring("namespace")))
+
+	_, err = spiffeID("cluster.local", "default", "")
+	Expect(err).To(MatchError(ContainSubstring("service account")))
+}
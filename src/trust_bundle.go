@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// trustBundleName returns the name of the ClusterTrustBundle published for a
+// signer. The API requires the signer name as prefix, with "/" replaced by ":".
+func trustBundleName(signerName string) string {
+	return strings.ReplaceAll(signerName, "/", ":") + ":ca"
+}
+
+// TrustBundleReconciler keeps a ClusterTrustBundle linked to SignerName in sync
+// with the CA's trust anchors, so Pods can mount it via the clusterTrustBundle
+// projected volume source.
+type TrustBundleReconciler struct {
+	client.Client
+	CA         *CAHelper
+	SignerName string
+}
+
+func (r *TrustBundleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	anchors := r.CA.TrustAnchorsPEM()
+	if len(anchors) == 0 {
+		return ctrl.Result{}, fmt.Errorf("CA not initialized")
+	}
+
+	name := trustBundleName(r.SignerName)
+	var bundle certificatesv1beta1.ClusterTrustBundle
+	err := r.Get(ctx, types.NamespacedName{Name: name}, &bundle)
+	if apierrors.IsNotFound(err) {
+		bundle = certificatesv1beta1.ClusterTrustBundle{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: certificatesv1beta1.ClusterTrustBundleSpec{
+				SignerName:  r.SignerName,
+				TrustBundle: string(anchors),
+			},
+		}
+		if err := r.Create(ctx, &bundle); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to create ClusterTrustBundle %s: %w", name, err)
+		}
+		log.Info("Created ClusterTrustBundle", "name", name)
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get ClusterTrustBundle %s: %w", name, err)
+	}
+
+	if bundle.Spec.TrustBundle == string(anchors) {
+		return ctrl.Result{}, nil
+	}
+	bundle.Spec.TrustBundle = string(anchors)
+	if err := r.Update(ctx, &bundle); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update ClusterTrustBundle %s: %w", name, err)
+	}
+	log.Info("Updated ClusterTrustBundle", "name", name)
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager reconciles on changes to our ClusterTrustBundle (so edits and
+// deletes are reverted), on every CA reload, and once on startup.
+func (r *TrustBundleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	name := trustBundleName(r.SignerName)
+
+	caChanged := make(chan event.GenericEvent, 1)
+	notify := func() {
+		select {
+		case caChanged <- event.GenericEvent{Object: &certificatesv1beta1.ClusterTrustBundle{ObjectMeta: metav1.ObjectMeta{Name: name}}}:
+		default: // a reconcile is already pending
+		}
+	}
+	r.CA.OnChange(notify)
+	notify()
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("trust-bundle").
+		For(&certificatesv1beta1.ClusterTrustBundle{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+			return obj.GetName() == name
+		}))).
+		WatchesRawSource(source.Channel(caChanged, &handler.EnqueueRequestForObject{})).
+		Complete(r)
+}
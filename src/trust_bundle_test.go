@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTrustBundleTestReconciler(ca *CAHelper, objs ...*certificatesv1beta1.ClusterTrustBundle) *TrustBundleReconciler {
+	scheme := runtime.NewScheme()
+	Expect(certificatesv1beta1.AddToScheme(scheme)).To(Succeed())
+	builder := fake.NewClientBuilder().WithScheme(scheme)
+	for _, obj := range objs {
+		builder = builder.WithObjects(obj)
+	}
+	return &TrustBundleReconciler{
+		Client:     builder.Build(),
+		CA:         ca,
+		SignerName: "novog93.ghcr/signer",
+	}
+}
+
+func TestTrustBundleName(t *testing.T) {
+	RegisterTestingT(t)
+	Expect(trustBundleName("novog93.ghcr/signer")).To(Equal("novog93.ghcr:signer:ca"))
+}
+
+func TestTrustBundleReconciler_CreatesBundle(t *testing.T) {
+	RegisterTestingT(t)
+
+	ca, err := NewCA()
+	Expect(err).NotTo(HaveOccurred())
+	r := newTrustBundleTestReconciler(ca)
+
+	_, err = r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "novog93.ghcr:signer:ca"}})
+	Expect(err).NotTo(HaveOccurred())
+
+	var bundle certificatesv1beta1.ClusterTrustBundle
+	Expect(r.Get(context.Background(), types.NamespacedName{Name: "novog93.ghcr:signer:ca"}, &bundle)).To(Succeed())
+	Expect(bundle.Spec.SignerName).To(Equal("novog93.ghcr/signer"))
+	Expect(bundle.Spec.TrustBundle).To(Equal(string(ca.TrustAnchorsPEM())))
+}
+
+func TestTrustBundleReconciler_RestoresEditedBundle(t *testing.T) {
+	RegisterTestingT(t)
+
+	ca, err := NewCA()
+	Expect(err).NotTo(HaveOccurred())
+	other, err := NewCA()
+	Expect(err).NotTo(HaveOccurred())
+
+	r := newTrustBundleTestReconciler(ca, &certificatesv1beta1.ClusterTrustBundle{
+		ObjectMeta: metav1.ObjectMeta{Name: "novog93.ghcr:signer:ca"},
+		Spec: certificatesv1beta1.ClusterTrustBundleSpec{
+			SignerName:  "novog93.ghcr/signer",
+			TrustBundle: string(other.TrustAnchorsPEM()),
+		},
+	})
+
+	_, err = r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "novog93.ghcr:signer:ca"}})
+	Expect(err).NotTo(HaveOccurred())
+
+	var bundle certificatesv1beta1.ClusterTrustBundle
+	Expect(r.Get(context.Background(), types.NamespacedName{Name: "novog93.ghcr:signer:ca"}, &bundle)).To(Succeed())
+	Expect(bundle.Spec.TrustBundle).To(Equal(string(ca.TrustAnchorsPEM())))
+}
+
+func TestTrustBundleReconciler_RequiresCA(t *testing.T) {
+	RegisterTestingT(t)
+
+	r := newTrustBundleTestReconciler(&CAHelper{})
+	_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "novog93.ghcr:signer:ca"}})
+	Expect(err).To(MatchError(ContainSubstring("CA not initialized")))
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// defaultTrustConfigMapKey is the ConfigMap data key holding the trust bundle
+	defaultTrustConfigMapKey = "ca.crt"
+
+	managedByLabel = "app.kubernetes.io/managed-by"
+	managedByValue = "novog93-signer"
+)
+
+// TrustDistributionReconciler writes the CA trust bundle into a ConfigMap in
+// every namespace matching NamespaceSelector, for clusters without the
+// ClusterTrustBundle API. Requests are keyed by namespace name.
+type TrustDistributionReconciler struct {
+	client.Client
+	CA                *CAHelper
+	ConfigMapName     string
+	ConfigMapKey      string
+	NamespaceSelector labels.Selector
+	// ExtraRoots are PEM certificates distributed alongside the CA
+	ExtraRoots []byte
+}
+
+func (r *TrustDistributionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	var ns corev1.Namespace
+	if err := r.Get(ctx, types.NamespacedName{Name: req.Name}, &ns); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !ns.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	var existing corev1.ConfigMap
+	err := r.Get(ctx, types.NamespacedName{Name: r.ConfigMapName, Namespace: ns.Name}, &existing)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, fmt.Errorf("failed to get ConfigMap %s/%s: %w", ns.Name, r.ConfigMapName, err)
+	}
+	found := err == nil
+
+	// Remove our ConfigMap from namespaces that no longer match
+	if !r.selector().Matches(labels.Set(ns.Labels)) {
+		if found && existing.Labels[managedByLabel] == managedByValue {
+			log.Info("Removing trust bundle ConfigMap from unselected namespace", "namespace", ns.Name)
+			return ctrl.Result{}, client.IgnoreNotFound(r.Delete(ctx, &existing))
+		}
+		return ctrl.Result{}, nil
+	}
+
+	bundle, err := r.bundle()
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	data := map[string]string{r.configMapKey(): bundle}
+
+	if !found {
+		cm := corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      r.ConfigMapName,
+				Namespace: ns.Name,
+				Labels:    map[string]string{managedByLabel: managedByValue},
+			},
+			Data: data,
+		}
+		if err := r.Create(ctx, &cm); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to create ConfigMap %s/%s: %w", ns.Name, r.ConfigMapName, err)
+		}
+		log.Info("Created trust bundle ConfigMap", "namespace", ns.Name)
+		return ctrl.Result{}, nil
+	}
+
+	if maps.Equal(existing.Data, data) && len(existing.BinaryData) == 0 && existing.Labels[managedByLabel] == managedByValue {
+		return ctrl.Result{}, nil
+	}
+	existing.Data = data
+	existing.BinaryData = nil
+	if existing.Labels == nil {
+		existing.Labels = map[string]string{}
+	}
+	existing.Labels[managedByLabel] = managedByValue
+	if err := r.Update(ctx, &existing); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update ConfigMap %s/%s: %w", ns.Name, r.ConfigMapName, err)
+	}
+	log.Info("Updated trust bundle ConfigMap", "namespace", ns.Name)
+	return ctrl.Result{}, nil
+}
+
+// bundle returns the CA trust anchors followed by the extra roots
+func (r *TrustDistributionReconciler) bundle() (string, error) {
+	anchors := r.CA.TrustAnchorsPEM()
+	if len(anchors) == 0 {
+		return "", fmt.Errorf("CA not initialized")
+	}
+	return string(anchors) + string(r.ExtraRoots), nil
+}
+
+func (r *TrustDistributionReconciler) selector() labels.Selector {
+	if r.NamespaceSelector == nil {
+		return labels.Everything()
+	}
+	return r.NamespaceSelector
+}
+
+func (r *TrustDistributionReconciler) configMapKey() string {
+	if r.ConfigMapKey == "" {
+		return defaultTrustConfigMapKey
+	}
+	return r.ConfigMapKey
+}
+
+// SetupWithManager reconciles a namespace when it changes, when its trust
+// ConfigMap is edited or deleted, and every namespace on CA reload and startup.
+func (r *TrustDistributionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	caChanged := make(chan event.GenericEvent, 1)
+	notify := func() {
+		select {
+		case caChanged <- event.GenericEvent{Object: &corev1.Namespace{}}:
+		default: // a resync is already pending
+		}
+	}
+	r.CA.OnChange(notify)
+	notify()
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("trust-distribution").
+		For(&corev1.Namespace{}).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+			if obj.GetName() != r.ConfigMapName {
+				return nil
+			}
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: obj.GetNamespace()}}}
+		})).
+		WatchesRawSource(source.Channel(caChanged, handler.EnqueueRequestsFromMapFunc(r.allNamespaces))).
+		Complete(r)
+}
+
+// allNamespaces enqueues every namespace; Reconcile decides whether it is selected
+func (r *TrustDistributionReconciler) allNamespaces(ctx context.Context, _ client.Object) []reconcile.Request {
+	var namespaces corev1.NamespaceList
+	if err := r.List(ctx, &namespaces); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list namespaces for trust distribution")
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(namespaces.Items))
+	for _, ns := range namespaces.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: ns.Name}})
+	}
+	return requests
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTrustDistributionTestReconciler(ca *CAHelper, selector labels.Selector, objs ...client.Object) *TrustDistributionReconciler {
+	scheme := runtime.NewScheme()
+	Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	return &TrustDistributionReconciler{
+		Client:            fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+		CA:                ca,
+		ConfigMapName:     "signer-ca",
+		ConfigMapKey:      "ca.crt",
+		NamespaceSelector: selector,
+	}
+}
+
+func reconcileNamespace(r *TrustDistributionReconciler, name string) error {
+	_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: name}})
+	return err
+}
+
+func TestTrustDistributionReconciler_CreatesConfigMap(t *testing.T) {
+	RegisterTestingT(t)
+
+	ca, err := NewCA()
+	Expect(err).NotTo(HaveOccurred())
+	r := newTrustDistributionTestReconciler(ca, nil, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}})
+
+	Expect(reconcileNamespace(r, "app")).To(Succeed())
+
+	var cm corev1.ConfigMap
+	Expect(r.Get(context.Background(), types.NamespacedName{Name: "signer-ca", Namespace: "app"}, &cm)).To(Succeed())
+	Expect(cm.Data).To(Equal(map[string]string{"ca.crt": string(ca.TrustAnchorsPEM())}))
+	Expect(cm.Labels).To(HaveKeyWithValue(managedByLabel, managedByValue))
+}
+
+func TestTrustDistributionReconciler_RestoresEditedConfigMap(t *testing.T) {
+	RegisterTestingT(t)
+
+	ca, err := NewCA()
+	Expect(err).NotTo(HaveOccurred())
+	r := newTrustDistributionTestReconciler(ca, nil,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "signer-ca", Namespace: "app"},
+			Data:       map[string]string{"ca.crt": "tampered", "other": "x"},
+		},
+	)
+
+	Expect(reconcileNamespace(r, "app")).To(Succeed())
+
+	var cm corev1.ConfigMap
+	Expect(r.Get(context.Background(), types.NamespacedName{Name: "signer-ca", Namespace: "app"}, &cm)).To(Succeed())
+	Expect(cm.Data).To(Equal(map[string]string{"ca.crt": string(ca.TrustAnchorsPEM())}))
+	Expect(cm.Labels).To(HaveKeyWithValue(managedByLabel, managedByValue))
+}
+
+func TestTrustDistributionReconciler_NamespaceSelector(t *testing.T) {
+	RegisterTestingT(t)
+
+	ca, err := NewCA()
+	Expect(err).NotTo(HaveOccurred())
+	selector, err := labels.Parse("mesh=enabled")
+	Expect(err).NotTo(HaveOccurred())
+	r := newTrustDistributionTestReconciler(ca, selector,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "selected", Labels: map[string]string{"mesh": "enabled"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dropped"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "foreign"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Name: "signer-ca", Namespace: "dropped", Labels: map[string]string{managedByLabel: managedByValue},
+		}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "signer-ca", Namespace: "foreign"}},
+	)
+
+	for _, ns := range []string{"selected", "dropped", "foreign"} {
+		Expect(reconcileNamespace(r, ns)).To(Succeed())
+	}
+
+	var cm corev1.ConfigMap
+	Expect(r.Get(context.Background(), types.NamespacedName{Name: "signer-ca", Namespace: "selected"}, &cm)).To(Succeed())
+	err = r.Get(context.Background(), types.NamespacedName{Name: "signer-ca", Namespace: "dropped"}, &cm)
+	Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	// ConfigMaps the signer did not create are left alone
+	Expect(r.Get(context.Background(), types.NamespacedName{Name: "signer-ca", Namespace: "foreign"}, &cm)).To(Succeed())
+}
+
+func TestTrustDistributionReconciler_ExtraRoots(t *testing.T) {
+	RegisterTestingT(t)
+
+	ca, err := NewCA()
+	Expect(err).NotTo(HaveOccurred())
+	extra, err := NewCA()
+	Expect(err).NotTo(HaveOccurred())
+	r := newTrustDistributionTestReconciler(ca, nil, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}})
+	r.ExtraRoots = extra.TrustAnchorsPEM()
+
+	Expect(reconcileNamespace(r, "app")).To(Succeed())
+
+	var cm corev1.ConfigMap
+	Expect(r.Get(context.Background(), types.NamespacedName{Name: "signer-ca", Namespace: "app"}, &cm)).To(Succeed())
+	certs, err := parseCertificatesPEM([]byte(cm.Data["ca.crt"]))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(certs).To(HaveLen(2))
+	Expect(certs[0].Equal(ca.GetCert())).To(BeTrue())
+	Expect(certs[1].Equal(extra.GetCert())).To(BeTrue())
+}
+
+func TestTrustDistributionReconciler_RequiresCA(t *testing.T) {
+	RegisterTestingT(t)
+
+	r := newTrustDistributionTestReconciler(&CAHelper{}, nil, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}})
+	Expect(reconcileNamespace(r, "app")).To(MatchError(ContainSubstring("CA not initialized")))
+}
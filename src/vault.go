@@ -0,0 +1,490 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Vault auth methods
+const (
+	// VaultAuthKubernetes logs in with the Pod's service account token
+	VaultAuthKubernetes = "kubernetes"
+	// VaultAuthToken uses a static Vault token
+	VaultAuthToken = "token"
+)
+
+const (
+	// vaultServiceAccountTokenPath is the projected service account token used for Kubernetes auth
+	vaultServiceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	// vaultIssuerRefreshInterval is how often the issuing CA chain is re-read from Vault
+	vaultIssuerRefreshInterval = 10 * time.Minute
+	// vaultRequestTimeout bounds every request to Vault
+	vaultRequestTimeout = 30 * time.Second
+)
+
+// VaultSigningBackend has certificates signed by a Vault PKI secrets engine,
+// through its sign-verbatim endpoint or, when Role is set, a role's sign endpoint.
+// It also runs on every replica to keep CA filled with Vault's issuing chain,
+// which the trust bundle, expiry monitor and readiness check read.
+type VaultSigningBackend struct {
+	Address string
+	// Mount is the path of the PKI secrets engine, e.g. "pki_int"
+	Mount string
+	// Role signs through <mount>/sign/<role> instead of <mount>/sign-verbatim
+	Role string
+	// AuthMethod is VaultAuthKubernetes or VaultAuthToken
+	AuthMethod      string
+	Token           string
+	KubernetesRole  string
+	KubernetesMount string
+	// TokenPath is the service account token file for Kubernetes auth
+	TokenPath  string
+	HTTPClient *http.Client
+	// CA receives Vault's issuing CA and its chain; it never holds a key
+	CA              *CAHelper
+	RefreshInterval time.Duration
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// newVaultSigningBackend builds the Vault backend from config
+func newVaultSigningBackend(config *Config, ca *CAHelper) (*VaultSigningBackend, error) {
+	if config.VaultAddress == "" {
+		return nil, fmt.Errorf("VAULT_ADDR is required for the vault signing backend")
+	}
+	switch config.VaultAuthMethod {
+	case VaultAuthKubernetes:
+		if config.VaultKubernetesRole == "" {
+			return nil, fmt.Errorf("VAULT_KUBERNETES_ROLE is required for Vault Kubernetes auth")
+		}
+	case VaultAuthToken:
+		if config.VaultToken == "" {
+			return nil, fmt.Errorf("VAULT_TOKEN is required for Vault token auth")
+		}
+	default:
+		return nil, fmt.Errorf("unknown Vault auth method %q", config.VaultAuthMethod)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.VaultCACert != "" {
+		caPEM, err := os.ReadFile(config.VaultCACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Vault CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in Vault CA certificate %s", config.VaultCACert)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	return &VaultSigningBackend{
+		Address:         strings.TrimSuffix(config.VaultAddress, "/"),
+		Mount:           strings.Trim(config.VaultPKIMount, "/"),
+		Role:            config.VaultPKIRole,
+		AuthMethod:      config.VaultAuthMethod,
+		Token:           config.VaultToken,
+		KubernetesRole:  config.VaultKubernetesRole,
+		KubernetesMount: strings.Trim(config.VaultKubernetesMount, "/"),
+		TokenPath:       vaultServiceAccountTokenPath,
+		HTTPClient:      &http.Client{Transport: transport, Timeout: vaultRequestTimeout},
+		CA:              ca,
+		RefreshInterval: vaultIssuerRefreshInterval,
+	}, nil
+}
+
+// Issuer returns Vault's issuing CA, once it has been fetched
+func (b *VaultSigningBackend) Issuer() *x509.Certificate {
+	return b.CA.GetCert()
+}
+
+// Start fetches the issuing CA chain every RefreshInterval until the context is cancelled
+func (b *VaultSigningBackend) Start(ctx context.Context) error {
+	ticker := time.NewTicker(b.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := b.refreshIssuer(ctx); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to fetch the Vault issuing CA")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection is false: every replica needs the issuer to be ready
+func (b *VaultSigningBackend) NeedLeaderElection() bool {
+	return false
+}
+
+// refreshIssuer loads <mount>/ca_chain into CA when it changed
+func (b *VaultSigningBackend) refreshIssuer(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.url("ca_chain"), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := b.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch Vault CA chain: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read Vault CA chain: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return vaultError(resp.StatusCode, body)
+	}
+
+	certs, err := parseCertificatesPEM(body)
+	if err != nil {
+		return fmt.Errorf("invalid Vault CA chain: %w", err)
+	}
+	cert, chain := certs[0], certs[1:]
+	if err := verifyChain(cert, chain); err != nil {
+		return fmt.Errorf("invalid Vault CA chain: %w", err)
+	}
+	if current := b.CA.GetCert(); current != nil && current.Equal(cert) {
+		return nil
+	}
+	b.CA.set(&caKeyPair{cert: cert, chain: chain}, nil)
+	log.FromContext(ctx).Info("Loaded Vault issuing CA", "subject", cert.Subject.String(), "notAfter", cert.NotAfter)
+	return nil
+}
+
+// vaultSignResponse is the data of a Vault PKI sign response
+type vaultSignResponse struct {
+	Data struct {
+		Certificate string   `json:"certificate"`
+		IssuingCA   string   `json:"issuing_ca"`
+		CAChain     []string `json:"ca_chain"`
+	} `json:"data"`
+}
+
+// Issue submits pub with the template's subject, SANs, usages and expiry to Vault
+func (b *VaultSigningBackend) Issue(ctx context.Context, template *x509.Certificate, pub crypto.PublicKey) ([]byte, error) {
+	csrPEM, err := vaultCSR(template, pub)
+	if err != nil {
+		return nil, err
+	}
+
+	params := map[string]any{
+		"csr":       string(csrPEM),
+		"format":    "pem",
+		"not_after": template.NotAfter.UTC().Format(time.RFC3339),
+	}
+	path := "sign-verbatim"
+	if b.Role != "" {
+		// Role endpoints take the SANs from the request, checked against the role
+		path = "sign/" + b.Role
+		params["common_name"] = template.Subject.CommonName
+		params["alt_names"] = strings.Join(template.DNSNames, ",")
+		params["ip_sans"] = strings.Join(vaultIPSANs(template), ",")
+		params["uri_sans"] = strings.Join(vaultURISANs(template), ",")
+	} else {
+		params["key_usage"] = vaultKeyUsages(template.KeyUsage)
+		params["ext_key_usage"] = vaultExtKeyUsages(template.ExtKeyUsage)
+		var oids []string
+		for _, oid := range template.UnknownExtKeyUsage {
+			oids = append(oids, oid.String())
+		}
+		params["ext_key_usage_oids"] = oids
+	}
+
+	var resp vaultSignResponse
+	if err := b.write(ctx, path, params, &resp); err != nil {
+		return nil, err
+	}
+
+	leaves, err := parseCertificatesPEM([]byte(resp.Data.Certificate))
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate from Vault: %w", err)
+	}
+	if !publicKeysEqual(leaves[0].PublicKey, pub) {
+		return nil, fmt.Errorf("certificate from Vault does not carry the requested public key")
+	}
+
+	// The leaf followed by the intermediates, like the local backend
+	chainPEM := resp.Data.CAChain
+	if len(chainPEM) == 0 && resp.Data.IssuingCA != "" {
+		chainPEM = []string{resp.Data.IssuingCA}
+	}
+	var chain []*x509.Certificate
+	for _, certPEM := range chainPEM {
+		certs, err := parseCertificatesPEM([]byte(certPEM))
+		if err != nil {
+			return nil, fmt.Errorf("invalid CA chain from Vault: %w", err)
+		}
+		chain = append(chain, certs...)
+	}
+	return append(encodeCertificatesPEM(leaves[:1]), encodeIntermediatesPEM(chain)...), nil
+}
+
+// write POSTs params to <mount>/<path>, logging in again once if the token was rejected
+func (b *VaultSigningBackend) write(ctx context.Context, path string, params map[string]any, out any) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		token, err := b.clientToken(ctx)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.url(path), bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("X-Vault-Token", token)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := b.HTTPClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("vault request to %s failed: %w", path, err)
+		}
+		respBody, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read Vault response: %w", err)
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusForbidden && b.AuthMethod == VaultAuthKubernetes && attempt == 0:
+			b.resetToken()
+			continue
+		case resp.StatusCode != http.StatusOK:
+			return vaultError(resp.StatusCode, respBody)
+		}
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("failed to decode Vault response: %w", err)
+		}
+		return nil
+	}
+}
+
+// clientToken returns the Vault token, logging in with Kubernetes auth when the cached one is about to expire
+func (b *VaultSigningBackend) clientToken(ctx context.Context) (string, error) {
+	if b.AuthMethod == VaultAuthToken {
+		return b.Token, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.token != "" && time.Now().Before(b.tokenExpiry) {
+		return b.token, nil
+	}
+
+	jwt, err := os.ReadFile(b.TokenPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read service account token: %w", err)
+	}
+	body, err := json.Marshal(map[string]string{"role": b.KubernetesRole, "jwt": strings.TrimSpace(string(jwt))})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/v1/auth/%s/login", b.Address, b.KubernetesMount), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := b.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vault Kubernetes login failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Vault login response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault Kubernetes login failed: %w", vaultError(resp.StatusCode, respBody))
+	}
+
+	var login struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	if err := json.Unmarshal(respBody, &login); err != nil {
+		return "", fmt.Errorf("failed to decode Vault login response: %w", err)
+	}
+	if login.Auth.ClientToken == "" {
+		return "", fmt.Errorf("vault Kubernetes login returned no token")
+	}
+
+	// Log in again after 80% of the lease, before Vault revokes the token
+	b.token = login.Auth.ClientToken
+	b.tokenExpiry = time.Now().Add(time.Duration(login.Auth.LeaseDuration) * time.Second * 4 / 5)
+	return b.token, nil
+}
+
+// resetToken drops the cached Kubernetes auth token
+func (b *VaultSigningBackend) resetToken() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.token = ""
+}
+
+func (b *VaultSigningBackend) url(path string) string {
+	return fmt.Sprintf("%s/v1/%s/%s", b.Address, b.Mount, path)
+}
+
+// vaultError formats a Vault error response
+func vaultError(status int, body []byte) error {
+	var resp struct {
+		Errors []string `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil && len(resp.Errors) > 0 {
+		return fmt.Errorf("vault returned %d: %s", status, strings.Join(resp.Errors, "; "))
+	}
+	return fmt.Errorf("vault returned %d", status)
+}
+
+// vaultCSR wraps pub and the template's subject and SANs in the CSR Vault's sign
+// endpoints require. Only the Pod holds the matching private key, and
+// kube-apiserver already verified its proof of possession, so the CSR is
+// signed with a throwaway key and its self-signature does not verify.
+func vaultCSR(template *x509.Certificate, pub crypto.PublicKey) ([]byte, error) {
+	spki, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public key: %w", err)
+	}
+	throwaway, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:     template.Subject,
+		DNSNames:    template.DNSNames,
+		IPAddresses: template.IPAddresses,
+		URIs:        template.URIs,
+	}, throwaway)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CSR: %w", err)
+	}
+
+	// Swap the throwaway public key for pub and re-sign the request info
+	var csr struct {
+		Info      asn1.RawValue
+		Algorithm pkix.AlgorithmIdentifier
+		Signature asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(der, &csr); err != nil {
+		return nil, fmt.Errorf("failed to parse CSR: %w", err)
+	}
+	var info struct {
+		Version    int
+		Subject    asn1.RawValue
+		PublicKey  asn1.RawValue
+		Attributes asn1.RawValue
+	}
+	if _, err := asn1.Unmarshal(csr.Info.FullBytes, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse CSR info: %w", err)
+	}
+	info.PublicKey = asn1.RawValue{FullBytes: spki}
+	infoDER, err := asn1.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(infoDER)
+	signature, err := ecdsa.SignASN1(rand.Reader, throwaway, digest[:])
+	if err != nil {
+		return nil, err
+	}
+	csr.Info = asn1.RawValue{FullBytes: infoDER}
+	csr.Signature = asn1.BitString{Bytes: signature, BitLength: len(signature) * 8}
+
+	der, err = asn1.Marshal(csr)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), nil
+}
+
+func vaultIPSANs(template *x509.Certificate) []string {
+	var ips []string
+	for _, ip := range template.IPAddresses {
+		ips = append(ips, ip.String())
+	}
+	return ips
+}
+
+func vaultURISANs(template *x509.Certificate) []string {
+	var uris []string
+	for _, uri := range template.URIs {
+		uris = append(uris, uri.String())
+	}
+	return uris
+}
+
+// vaultKeyUsages names key usages the way Vault's key_usage parameter expects
+func vaultKeyUsages(usage x509.KeyUsage) []string {
+	names := map[x509.KeyUsage]string{
+		x509.KeyUsageDigitalSignature:  "DigitalSignature",
+		x509.KeyUsageContentCommitment: "ContentCommitment",
+		x509.KeyUsageKeyEncipherment:   "KeyEncipherment",
+		x509.KeyUsageDataEncipherment:  "DataEncipherment",
+		x509.KeyUsageKeyAgreement:      "KeyAgreement",
+	}
+	var usages []string
+	for bit, name := range names {
+		if usage&bit != 0 {
+			usages = append(usages, name)
+		}
+	}
+	slices.Sort(usages)
+	return usages
+}
+
+// vaultExtKeyUsages names extended key usages the way Vault's ext_key_usage parameter expects
+func vaultExtKeyUsages(usages []x509.ExtKeyUsage) []string {
+	names := map[x509.ExtKeyUsage]string{
+		x509.ExtKeyUsageServerAuth:      "ServerAuth",
+		x509.ExtKeyUsageClientAuth:      "ClientAuth",
+		x509.ExtKeyUsageCodeSigning:     "CodeSigning",
+		x509.ExtKeyUsageEmailProtection: "EmailProtection",
+		x509.ExtKeyUsageTimeStamping:    "TimeStamping",
+		x509.ExtKeyUsageOCSPSigning:     "OCSPSigning",
+	}
+	var out []string
+	for _, usage := range usages {
+		if name, ok := names[usage]; ok {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// publicKeysEqual compares public keys of any supported type
+func publicKeysEqual(a, b crypto.PublicKey) bool {
+	key, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && key.Equal(b)
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+// fakeVault is a minimal Vault PKI engine mounted at pki, signing with a local intermediate
+type fakeVault struct {
+	root, intermediate *x509.Certificate
+	ca                 *CAHelper
+
+	mu       sync.Mutex
+	requests map[string][]map[string]any
+	logins   int
+	// rejectToken makes the next signing request fail with 403
+	rejectToken bool
+}
+
+func newFakeVault(t *testing.T) (*fakeVault, *httptest.Server) {
+	rootKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	root := newTestCACert("Corporate Root", rootKey, nil, nil)
+	intKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	intermediate := newTestCACert("Vault Intermediate", intKey, root, rootKey)
+
+	v := &fakeVault{
+		root:         root,
+		intermediate: intermediate,
+		ca:           &CAHelper{Cert: intermediate, Key: intKey},
+		requests:     map[string][]map[string]any{},
+	}
+	server := httptest.NewServer(http.HandlerFunc(v.serveHTTP))
+	t.Cleanup(server.Close)
+	return v, server
+}
+
+func (v *fakeVault) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	var params map[string]any
+	if r.Method == http.MethodPost {
+		_ = json.NewDecoder(r.Body).Decode(&params)
+	}
+	v.requests[r.URL.Path] = append(v.requests[r.URL.Path], params)
+
+	switch {
+	case r.URL.Path == "/v1/pki/ca_chain":
+		_, _ = w.Write(encodeTestCerts(v.intermediate, v.root))
+	case r.URL.Path == "/v1/auth/kubernetes/login":
+		if params["jwt"] != "sa-token" || params["role"] != "signer" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		v.logins++
+		_ = json.NewEncoder(w).Encode(map[string]any{"auth": map[string]any{"client_token": "client-token", "lease_duration": 3600}})
+	case r.URL.Path == "/v1/pki/sign-verbatim" || strings.HasPrefix(r.URL.Path, "/v1/pki/sign/"):
+		if v.rejectToken {
+			v.rejectToken = false
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		if token := r.Header.Get("X-Vault-Token"); token != "client-token" && token != "static-token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		v.sign(w, params)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"errors":[]}`))
+	}
+}
+
+// sign issues a certificate for the CSR's public key, taking the SANs from the
+// CSR like sign-verbatim and roles with use_csr_sans. It checks the CSR the way
+// Vault does: it must parse and carry a supported public key, but its
+// self-signature is not verified. TestVaultSigningBackend_DevServer checks that
+// against a real Vault.
+func (v *fakeVault) sign(w http.ResponseWriter, params map[string]any) {
+	block, _ := pem.Decode([]byte(params["csr"].(string)))
+	if block == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"errors":["csr contains no data"]}`))
+		return
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"errors":["certificate request could not be parsed"]}`))
+		return
+	}
+	if csr.PublicKeyAlgorithm == x509.UnknownPublicKeyAlgorithm || csr.PublicKey == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"errors":["Refusing to sign CSR with empty PublicKey"]}`))
+		return
+	}
+	notAfter, _ := time.Parse(time.RFC3339, params["not_after"].(string))
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      csr.Subject,
+		DNSNames:     csr.DNSNames,
+		IPAddresses:  csr.IPAddresses,
+		URIs:         csr.URIs,
+		NotBefore:    time.Now(),
+		NotAfter:     notAfter,
+	}, v.intermediate, csr.PublicKey, v.ca.Key)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{
+		"certificate": string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		"issuing_ca":  string(encodeTestCerts(v.intermediate)),
+		"ca_chain":    []string{string(encodeTestCerts(v.intermediate)), string(encodeTestCerts(v.root))},
+	}})
+}
+
+func newTestVaultBackend(t *testing.T, address string, config *Config) *VaultSigningBackend {
+	config.VaultAddress = address
+	if config.VaultPKIMount == "" {
+		config.VaultPKIMount = "pki"
+	}
+	if config.VaultKubernetesMount == "" {
+		config.VaultKubernetesMount = "kubernetes"
+	}
+	backend, err := newVaultSigningBackend(config, &CAHelper{})
+	Expect(err).NotTo(HaveOccurred())
+
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	Expect(os.WriteFile(tokenPath, []byte("sa-token\n"), 0o600)).To(Succeed())
+	backend.TokenPath = tokenPath
+	return backend
+}
+
+func testLeafTemplate() *x509.Certificate {
+	return &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "app-0.pod.cluster.local"},
+		DNSNames:     []string{"app-0.pod.cluster.local"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour).Truncate(time.Second),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+	}
+}
+
+func TestVaultSigningBackend_SignVerbatimWithKubernetesAuth(t *testing.T) {
+	RegisterTestingT(t)
+
+	vault, server := newFakeVault(t)
+	backend := newTestVaultBackend(t, server.URL, &Config{VaultAuthMethod: VaultAuthKubernetes, VaultKubernetesRole: "signer"})
+
+	Expect(backend.Issuer()).To(BeNil())
+	Expect(backend.refreshIssuer(context.Background())).To(Succeed())
+	Expect(backend.Issuer().Equal(vault.intermediate)).To(BeTrue())
+	Expect(string(backend.CA.TrustAnchorsPEM())).To(Equal(string(encodeTestCerts(vault.root))))
+
+	leafKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := testLeafTemplate()
+	chainPEM, err := backend.Issue(context.Background(), template, leafKey.Public())
+	Expect(err).NotTo(HaveOccurred())
+
+	// The leaf carries the Pod's key and expiry, followed by the intermediate only
+	certs, err := parseCertificatesPEM(chainPEM)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(certs).To(HaveLen(2))
+	Expect(certs[0].PublicKey).To(Equal(leafKey.Public()))
+	Expect(certs[0].DNSNames).To(ConsistOf("app-0.pod.cluster.local"))
+	Expect(certs[0].NotAfter.Equal(template.NotAfter)).To(BeTrue())
+	Expect(certs[1].Equal(vault.intermediate)).To(BeTrue())
+
+	requests := vault.requests["/v1/pki/sign-verbatim"]
+	Expect(requests).To(HaveLen(1))
+	Expect(requests[0]["ext_key_usage"]).To(ConsistOf("ClientAuth", "ServerAuth"))
+	Expect(requests[0]["key_usage"]).To(ConsistOf("DigitalSignature"))
+
+	// The token is cached, and a rejected token triggers one new login
+	_, err = backend.Issue(context.Background(), testLeafTemplate(), leafKey.Public())
+	Expect(err).NotTo(HaveOccurred())
+	Expect(vault.logins).To(Equal(1))
+	vault.rejectToken = true
+	_, err = backend.Issue(context.Background(), testLeafTemplate(), leafKey.Public())
+	Expect(err).NotTo(HaveOccurred())
+	Expect(vault.logins).To(Equal(2))
+}
+
+func TestVaultSigningBackend_RoleWithTokenAuth(t *testing.T) {
+	RegisterTestingT(t)
+
+	vault, server := newFakeVault(t)
+	backend := newTestVaultBackend(t, server.URL, &Config{VaultAuthMethod: VaultAuthToken, VaultToken: "static-token", VaultPKIRole: "pods"})
+
+	leafKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, err := backend.Issue(context.Background(), testLeafTemplate(), leafKey.Public())
+	Expect(err).NotTo(HaveOccurred())
+
+	requests := vault.requests["/v1/pki/sign/pods"]
+	Expect(requests).To(HaveLen(1))
+	Expect(requests[0]["common_name"]).To(Equal("app-0.pod.cluster.local"))
+	Expect(requests[0]["alt_names"]).To(Equal("app-0.pod.cluster.local"))
+	Expect(vault.logins).To(BeZero())
+}
+
+func TestVaultSigningBackend_Errors(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, server := newFakeVault(t)
+	backend := newTestVaultBackend(t, server.URL, &Config{VaultAuthMethod: VaultAuthKubernetes, VaultKubernetesRole: "other"})
+	leafKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, err := backend.Issue(context.Background(), testLeafTemplate(), leafKey.Public())
+	Expect(err).To(MatchError(ContainSubstring("permission denied")))
+
+	backend = newTestVaultBackend(t, server.URL, &Config{VaultAuthMethod: VaultAuthToken, VaultToken: "static-token", VaultPKIMount: "missing"})
+	Expect(backend.refreshIssuer(context.Background())).To(MatchError(ContainSubstring("vault returned 404")))
+
+	_, err = newVaultSigningBackend(&Config{VaultAuthMethod: VaultAuthToken}, &CAHelper{})
+	Expect(err).To(MatchError(ContainSubstring("VAULT_ADDR")))
+	_, err = newVaultSigningBackend(&Config{VaultAddress: server.URL, VaultAuthMethod: "approle"}, &CAHelper{})
+	Expect(err).To(MatchError(ContainSubstring("unknown Vault auth method")))
+}
+
+func TestVaultCSR_CarriesPublicKey(t *testing.T) {
+	RegisterTestingT(t)
+
+	leafKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := testLeafTemplate()
+	csrPEM, err := vaultCSR(template, leafKey.Public())
+	Expect(err).NotTo(HaveOccurred())
+
+	block, _ := pem.Decode(csrPEM)
+	Expect(block.Type).To(Equal("CERTIFICATE REQUEST"))
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(csr.PublicKey).To(Equal(leafKey.Public()))
+	Expect(csr.Subject.CommonName).To(Equal(template.Subject.CommonName))
+	Expect(csr.DNSNames).To(Equal(template.DNSNames))
+	// Only the Pod holds the key, so the self-signature cannot verify; Vault does not check it
+	Expect(csr.CheckSignature()).To(HaveOccurred())
+}
+
+// vaultDevServer returns the address and root token of a Vault dev server started with
+//
+//	vault server -dev -dev-root-token-id=signer-test
+//
+// from VAULT_DEV_ADDR and VAULT_DEV_TOKEN. The test is skipped otherwise.
+func vaultDevServer(t *testing.T) (string, string) {
+	address := os.Getenv("VAULT_DEV_ADDR")
+	if address == "" {
+		t.Skip("VAULT_DEV_ADDR not set")
+	}
+	token := os.Getenv("VAULT_DEV_TOKEN")
+	if token == "" {
+		token = "signer-test"
+	}
+	return strings.TrimSuffix(address, "/"), token
+}
+
+// vaultDevRequest sends a request with the root token and fails the test unless Vault accepts it
+func vaultDevRequest(address, token, method, path string, params map[string]any) {
+	var body io.Reader
+	if params != nil {
+		data, err := json.Marshal(params)
+		Expect(err).NotTo(HaveOccurred())
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, address+"/v1/"+path, body)
+	Expect(err).NotTo(HaveOccurred())
+	req.Header.Set("X-Vault-Token", token)
+	resp, err := http.DefaultClient.Do(req)
+	Expect(err).NotTo(HaveOccurred())
+	defer func() { _ = resp.Body.Close() }()
+	respBody, _ := io.ReadAll(resp.Body)
+	Expect(resp.StatusCode).To(BeNumerically("<", 300), "%s %s: %s", method, path, respBody)
+}
+
+// TestVaultSigningBackend_DevServer checks against a real Vault that its sign
+// endpoints accept the CSRs vaultCSR builds, whose self-signature does not verify
+func TestVaultSigningBackend_DevServer(t *testing.T) {
+	address, token := vaultDevServer(t)
+	RegisterTestingT(t)
+
+	// A PKI mount of its own, with a root CA and a role for the pod SANs
+	mount := fmt.Sprintf("signer-test-%d", time.Now().UnixNano())
+	vaultDevRequest(address, token, http.MethodPost, "sys/mounts/"+mount, map[string]any{
+		"type":   "pki",
+		"config": map[string]any{"max_lease_ttl": "87600h"},
+	})
+	t.Cleanup(func() { vaultDevRequest(address, token, http.MethodDelete, "sys/mounts/"+mount, nil) })
+	vaultDevRequest(address, token, http.MethodPost, mount+"/root/generate/internal", map[string]any{
+		"common_name": "Signer Test Root",
+		"ttl":         "87600h",
+	})
+	vaultDevRequest(address, token, http.MethodPost, mount+"/roles/pods", map[string]any{
+		"allow_any_name":    true,
+		"enforce_hostnames": false,
+		"allow_ip_sans":     true,
+		"allowed_uri_sans":  "spiffe://*",
+		"max_ttl":           "24h",
+	})
+
+	template := testLeafTemplate()
+	template.IPAddresses = []net.IP{net.ParseIP("10.0.0.1")}
+	spiffe, _ := url.Parse("spiffe://cluster.local/ns/default/sa/app")
+	template.URIs = []*url.URL{spiffe}
+
+	for _, role := range []string{"", "pods"} {
+		backend, err := newVaultSigningBackend(&Config{
+			VaultAddress:    address,
+			VaultPKIMount:   mount,
+			VaultPKIRole:    role,
+			VaultAuthMethod: VaultAuthToken,
+			VaultToken:      token,
+		}, &CAHelper{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(backend.refreshIssuer(context.Background())).To(Succeed())
+
+		leafKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		chainPEM, err := backend.Issue(context.Background(), template, leafKey.Public())
+		Expect(err).NotTo(HaveOccurred(), "role %q", role)
+		certs, err := parseCertificatesPEM(chainPEM)
+		Expect(err).NotTo(HaveOccurred())
+		leaf := certs[0]
+		Expect(leaf.PublicKey).To(Equal(leafKey.Public()))
+		Expect(leaf.CheckSignatureFrom(backend.Issuer())).To(Succeed())
+		Expect(leaf.DNSNames).To(Equal(template.DNSNames))
+		Expect(leaf.IPAddresses[0].Equal(template.IPAddresses[0])).To(BeTrue())
+		Expect(leaf.URIs[0].String()).To(Equal(spiffe.String()))
+	}
+}